@@ -0,0 +1,97 @@
+// Command initdata prints correctly signed fake Telegram initData for local
+// testing, so the server can run with real validation instead of the
+// development ?user_id= bypass.
+//
+//	go run ./cmd/initdata -user-id 42 -username alice
+//
+// The output is signed with BOT_TOKEN (hash). With -seed, it is also signed
+// with that Ed25519 key (signature); start the server with
+// INITDATA_PUBLIC_KEY set to the printed public key to accept it.
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lastclick/lastclick/internal/auth"
+)
+
+func main() {
+	botToken := flag.String("bot-token", os.Getenv("BOT_TOKEN"), "bot token used for the HMAC hash")
+	botID := flag.Int64("bot-id", 0, "bot ID for the Ed25519 signature (default: prefix of -bot-token)")
+	seedHex := flag.String("seed", "", "hex Ed25519 seed (32 bytes) to add a signature")
+	genKey := flag.Bool("gen-key", false, "print a new Ed25519 seed and public key, then exit")
+	userID := flag.Int64("user-id", 1, "Telegram user ID")
+	username := flag.String("username", "", "Telegram username")
+	firstName := flag.String("first-name", "Dev", "first name")
+	lastName := flag.String("last-name", "", "last name")
+	lang := flag.String("lang", "en", "language code")
+	premium := flag.Bool("premium", false, "Telegram Premium user")
+	age := flag.Duration("age", 0, "backdate auth_date by this much (to test expiry)")
+	wsURL := flag.String("ws", "", "if set, print this WebSocket URL with initData appended")
+	flag.Parse()
+
+	if *genKey {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Printf("seed=%s\npublic_key=%s\n", hex.EncodeToString(priv.Seed()), hex.EncodeToString(pub))
+		return
+	}
+
+	var priv ed25519.PrivateKey
+	if *seedHex != "" {
+		seed, err := hex.DecodeString(*seedHex)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			fatal(fmt.Errorf("-seed must be %d hex bytes", ed25519.SeedSize))
+		}
+		priv = ed25519.NewKeyFromSeed(seed)
+		if *botID == 0 {
+			id, err := auth.BotIDFromToken(*botToken)
+			if err != nil {
+				fatal(fmt.Errorf("-bot-id required: %w", err))
+			}
+			*botID = id
+		}
+	}
+	if *botToken == "" && priv == nil {
+		fatal(fmt.Errorf("need -bot-token (or BOT_TOKEN) or -seed"))
+	}
+
+	user, err := json.Marshal(auth.User{
+		ID:           *userID,
+		FirstName:    *firstName,
+		LastName:     *lastName,
+		Username:     *username,
+		LanguageCode: *lang,
+		IsPremium:    *premium,
+	})
+	if err != nil {
+		fatal(err)
+	}
+	vals := url.Values{}
+	vals.Set("user", string(user))
+	vals.Set("auth_date", strconv.FormatInt(time.Now().Add(-*age).Unix(), 10))
+	vals.Set("query_id", "dev-"+strconv.FormatInt(time.Now().UnixNano(), 36))
+
+	initData := auth.SignInitData(vals, *botToken, *botID, priv)
+	if *wsURL != "" {
+		fmt.Printf("%s?initData=%s\n", *wsURL, url.QueryEscape(initData))
+		return
+	}
+	fmt.Println(initData)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "initdata:", err)
+	os.Exit(2)
+}
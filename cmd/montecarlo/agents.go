@@ -0,0 +1,61 @@
+package main
+
+import (
+	"math/rand"
+
+	"github.com/lastclick/lastclick/internal/game"
+)
+
+// newAgent returns the adaptive strategy behind an archetype, or nil for the
+// scripted archetypes that pulse from genPlayerPulses.
+func newAgent(rng *rand.Rand, arch Archetype) game.SimAgent {
+	switch arch {
+	case Sniper:
+		return &sniperAgent{rng: rand.New(rand.NewSource(rng.Int63()))}
+	case Efficient:
+		return efficientAgent{}
+	case Bot:
+		return botAgent{}
+	}
+	return nil
+}
+
+// sniperAgent pulses only on the last safe tick of its pulse window, give or
+// take a tick of reaction time, and lets the global timer run out.
+type sniperAgent struct {
+	rng  *rand.Rand
+	wait int // ticks to wait after the next pulse is due; redrawn per pulse
+}
+
+func (a *sniperAgent) Pulse(obs game.Observation) bool {
+	if obs.Tick-obs.LastPulse < obs.WindowTicks-a.wait {
+		return false
+	}
+	a.wait = a.rng.Intn(2)
+	return true
+}
+
+// efficientAgent spends as few pulses as it can, which also wins ties under
+// the efficiency policy, but keeps the round alive while others can still
+// drop out: it pulses when the timer would hit zero this tick and a pulse
+// adds more than the tick takes away.
+type efficientAgent struct{}
+
+func (efficientAgent) Pulse(obs game.Observation) bool {
+	since := obs.Tick - obs.LastPulse
+	if since < obs.MinGapTicks {
+		return false
+	}
+	if since >= obs.WindowTicks-1 {
+		return true
+	}
+	return obs.AliveCount > 2 && obs.Timer <= obs.Decrement && obs.Extension > obs.Decrement
+}
+
+// botAgent clicks at a fixed interval of half the pulse window, whatever the
+// game is doing.
+type botAgent struct{}
+
+func (botAgent) Pulse(obs game.Observation) bool {
+	return obs.Tick-obs.LastPulse >= max(obs.WindowTicks/2, obs.MinGapTicks)
+}
@@ -0,0 +1,601 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const tickSec = 0.25
+
+// Summary is everything the report says about a run, in a form that
+// serialises to JSON and compares across sweep values.
+type Summary struct {
+	Seed          int64   `json:"seed"`
+	Players       int     `json:"players"`
+	ActivePlayers int     `json:"active_players"`
+	Rounds        int     `json:"rounds"`
+	Sessions      int     `json:"sessions"`
+	ElapsedSec    float64 `json:"elapsed_sec"`
+
+	StarsBurned  int64   `json:"stars_burned"`
+	Pool         int64   `json:"pool"`
+	Rake         int64   `json:"rake"`
+	Payouts      int64   `json:"payouts"`
+	HouseTakePct float64 `json:"house_take_pct"`
+	MeanBurn     float64 `json:"mean_burn"`
+	MedianBurn   float64 `json:"median_burn"`
+	P90Burn      float64 `json:"p90_burn"`
+
+	ShardsGenerated int64   `json:"shards_generated"`
+	ShardsPerStar   float64 `json:"shards_per_star"`
+	MeanShards      float64 `json:"mean_shards"`
+	MedianShards    float64 `json:"median_shards"`
+	P90Shards       float64 `json:"p90_shards"`
+
+	MeanSurvivalSec   float64 `json:"mean_survival_sec"`
+	MedianSurvivalSec float64 `json:"median_survival_sec"`
+	P10SurvivalSec    float64 `json:"p10_survival_sec"`
+	P90SurvivalSec    float64 `json:"p90_survival_sec"`
+
+	MeanEfficiency   float64 `json:"mean_efficiency"`
+	MedianEfficiency float64 `json:"median_efficiency"`
+	P90Efficiency    float64 `json:"p90_efficiency"`
+	P99Efficiency    float64 `json:"p99_efficiency"`
+	MaxEfficiency    float64 `json:"max_efficiency"`
+
+	Wins               int     `json:"wins"`
+	Top3               int     `json:"top3"`
+	WinFreqPct         float64 `json:"win_freq_pct"`
+	PlaceFreqPct       float64 `json:"place_freq_pct"`
+	NetPositive        int     `json:"net_positive"`
+	NetPositivePct     float64 `json:"net_positive_pct"`
+	MeanNet            float64 `json:"mean_net"`
+	MedianNet          float64 `json:"median_net"`
+	P10Net             float64 `json:"p10_net"`
+	P90Net             float64 `json:"p90_net"`
+	GamesPerWin        float64 `json:"games_per_win"`
+	MedianGamesPerWin  float64 `json:"median_games_per_win"`
+	GamesPerTop3       float64 `json:"games_per_top3"`
+	MedianGamesPerTop3 float64 `json:"median_games_per_top3"`
+
+	FinishReasons map[string]int     `json:"finish_reasons"`
+	Archetypes    []ArchetypeSummary `json:"archetypes"`
+}
+
+// ArchetypeSummary is one archetype's results across all its sessions.
+type ArchetypeSummary struct {
+	Archetype       string  `json:"archetype"`
+	Players         int     `json:"players"`
+	Games           int     `json:"games"`
+	Wins            int     `json:"wins"`
+	Top3            int     `json:"top3"`
+	WinPct          float64 `json:"win_pct"`
+	Top3Pct         float64 `json:"top3_pct"`
+	Burned          int64   `json:"burned"`
+	Payouts         int64   `json:"payouts"`
+	Shards          int64   `json:"shards"`
+	NetPerGame      float64 `json:"net_per_game"`
+	PulsesPerGame   float64 `json:"pulses_per_game"`
+	MeanSurvivalSec float64 `json:"mean_survival_sec"`
+	MeanEfficiency  float64 `json:"mean_efficiency"`
+}
+
+// RoundRow is one round in the per-round output.
+type RoundRow struct {
+	SweepValue      string  `json:"sweep_value,omitempty"`
+	Round           int     `json:"round"`
+	Tier            int     `json:"tier"`
+	Players         int     `json:"players"`
+	SurvivalSec     float64 `json:"survival_sec"`
+	FinishReason    string  `json:"finish_reason"`
+	Pool            int64   `json:"pool"`
+	Rake            int64   `json:"rake"`
+	Payouts         int64   `json:"payouts"`
+	Shards          int64   `json:"shards"`
+	WinnerArchetype string  `json:"winner_archetype,omitempty"`
+}
+
+func summarize(run Run) Summary {
+	s := Summary{
+		Seed:          run.Seed,
+		Players:       len(run.Players),
+		Rounds:        len(run.Rounds),
+		ElapsedSec:    run.Elapsed.Seconds(),
+		FinishReasons: make(map[string]int),
+	}
+
+	var allBurns, allShards, allTicks, allEff []float64
+	arch := make(map[Archetype]*ArchetypeSummary, len(allArchetypes))
+	archTicks := make(map[Archetype]float64)
+	archEff := make(map[Archetype]float64)
+	archPulses := make(map[Archetype]int)
+	for _, a := range allArchetypes {
+		arch[a] = &ArchetypeSummary{Archetype: a.Name()}
+	}
+
+	for _, r := range run.Rounds {
+		s.Pool += r.pool
+		s.Rake += r.rake
+		s.FinishReasons[r.finishReason]++
+		if r.hasWinner {
+			s.Wins++
+			arch[r.winnerArch].Wins++
+		}
+		for _, ps := range r.pstats {
+			allBurns = append(allBurns, float64(ps.burned))
+			allShards = append(allShards, float64(ps.shards))
+			allTicks = append(allTicks, float64(ps.ticks))
+			if ps.eff > 0 {
+				allEff = append(allEff, ps.eff)
+			}
+			s.StarsBurned += ps.burned
+			s.ShardsGenerated += ps.shards
+			s.Payouts += ps.payout
+			s.Sessions++
+
+			a := arch[ps.arch]
+			a.Games++
+			a.Burned += ps.burned
+			a.Payouts += ps.payout
+			a.Shards += ps.shards
+			archTicks[ps.arch] += float64(ps.ticks)
+			archEff[ps.arch] += ps.eff
+			archPulses[ps.arch] += ps.pulses
+			if ps.placed {
+				a.Top3++
+				s.Top3++
+			}
+		}
+	}
+
+	sort.Float64s(allBurns)
+	sort.Float64s(allShards)
+	sort.Float64s(allTicks)
+	sort.Float64s(allEff)
+
+	s.MeanBurn, s.MedianBurn, s.P90Burn = mean(allBurns), percentile(allBurns, 50), percentile(allBurns, 90)
+	s.MeanShards, s.MedianShards, s.P90Shards = mean(allShards), percentile(allShards, 50), percentile(allShards, 90)
+	s.MeanSurvivalSec = mean(allTicks) * tickSec
+	s.MedianSurvivalSec = percentile(allTicks, 50) * tickSec
+	s.P10SurvivalSec = percentile(allTicks, 10) * tickSec
+	s.P90SurvivalSec = percentile(allTicks, 90) * tickSec
+	if len(allEff) > 0 {
+		s.MeanEfficiency = mean(allEff)
+		s.MedianEfficiency = percentile(allEff, 50)
+		s.P90Efficiency = percentile(allEff, 90)
+		s.P99Efficiency = percentile(allEff, 99)
+		s.MaxEfficiency = allEff[len(allEff)-1]
+	}
+	if s.StarsBurned > 0 {
+		s.HouseTakePct = float64(s.Rake) / float64(s.StarsBurned) * 100
+		s.ShardsPerStar = float64(s.ShardsGenerated) / float64(s.StarsBurned)
+	}
+	if s.Sessions > 0 {
+		s.WinFreqPct = float64(s.Wins) / float64(s.Sessions) * 100
+		s.PlaceFreqPct = float64(s.Top3) / float64(s.Sessions) * 100
+	}
+
+	var netResults, gamesPerWin, gamesPerPlace []float64
+	for _, p := range run.Players {
+		arch[p.Archetype].Players++
+		if p.TotalGames == 0 {
+			continue
+		}
+		net := float64(p.TotalPayouts) - float64(p.TotalBurned)
+		netResults = append(netResults, net)
+		if net > 0 {
+			s.NetPositive++
+		}
+		if p.TotalWins > 0 {
+			gamesPerWin = append(gamesPerWin, float64(p.TotalGames)/float64(p.TotalWins))
+		}
+		if p.TotalPlaces > 0 {
+			gamesPerPlace = append(gamesPerPlace, float64(p.TotalGames)/float64(p.TotalPlaces))
+		}
+	}
+	sort.Float64s(netResults)
+	sort.Float64s(gamesPerWin)
+	sort.Float64s(gamesPerPlace)
+	s.ActivePlayers = len(netResults)
+	if s.ActivePlayers > 0 {
+		s.NetPositivePct = float64(s.NetPositive) / float64(s.ActivePlayers) * 100
+	}
+	s.MeanNet, s.MedianNet = mean(netResults), percentile(netResults, 50)
+	s.P10Net, s.P90Net = percentile(netResults, 10), percentile(netResults, 90)
+	s.GamesPerWin, s.MedianGamesPerWin = mean(gamesPerWin), percentile(gamesPerWin, 50)
+	s.GamesPerTop3, s.MedianGamesPerTop3 = mean(gamesPerPlace), percentile(gamesPerPlace, 50)
+
+	for _, a := range allArchetypes {
+		as := arch[a]
+		if as.Players == 0 {
+			continue
+		}
+		if as.Games > 0 {
+			as.WinPct = float64(as.Wins) / float64(as.Games) * 100
+			as.Top3Pct = float64(as.Top3) / float64(as.Games) * 100
+			as.NetPerGame = float64(as.Payouts-as.Burned) / float64(as.Games)
+			as.PulsesPerGame = float64(archPulses[a]) / float64(as.Games)
+			as.MeanSurvivalSec = archTicks[a] / float64(as.Games) * tickSec
+			as.MeanEfficiency = archEff[a] / float64(as.Games)
+		}
+		s.Archetypes = append(s.Archetypes, *as)
+	}
+	return s
+}
+
+func roundRows(run Run, sweepValue string) []RoundRow {
+	rows := make([]RoundRow, len(run.Rounds))
+	for i, r := range run.Rounds {
+		row := RoundRow{
+			SweepValue:   sweepValue,
+			Round:        i + 1,
+			Tier:         r.tierNum,
+			Players:      r.playerCount,
+			SurvivalSec:  float64(r.ticks) * tickSec,
+			FinishReason: r.finishReason,
+			Pool:         r.pool,
+			Rake:         r.rake,
+		}
+		for _, ps := range r.pstats {
+			row.Payouts += ps.payout
+			row.Shards += ps.shards
+		}
+		if r.hasWinner {
+			row.WinnerArchetype = r.winnerArch.Name()
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// --- Machine-readable output ---
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func ftoa(f float64) string { return strconv.FormatFloat(f, 'f', 4, 64) }
+
+func writeArchetypesCSV(w io.Writer, s Summary) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"archetype", "players", "games", "wins", "top3", "win_pct", "top3_pct",
+		"burned", "payouts", "shards", "net_per_game", "pulses_per_game", "mean_survival_sec", "mean_efficiency"})
+	for _, a := range s.Archetypes {
+		cw.Write([]string{a.Archetype, strconv.Itoa(a.Players), strconv.Itoa(a.Games),
+			strconv.Itoa(a.Wins), strconv.Itoa(a.Top3), ftoa(a.WinPct), ftoa(a.Top3Pct),
+			strconv.FormatInt(a.Burned, 10), strconv.FormatInt(a.Payouts, 10), strconv.FormatInt(a.Shards, 10),
+			ftoa(a.NetPerGame), ftoa(a.PulsesPerGame), ftoa(a.MeanSurvivalSec), ftoa(a.MeanEfficiency)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeRoundsCSV(w io.Writer, rows []RoundRow) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"sweep_value", "round", "tier", "players", "survival_sec", "finish_reason",
+		"pool", "rake", "payouts", "shards", "winner_archetype"})
+	for _, r := range rows {
+		cw.Write([]string{r.SweepValue, strconv.Itoa(r.Round), strconv.Itoa(r.Tier), strconv.Itoa(r.Players),
+			ftoa(r.SurvivalSec), r.FinishReason, strconv.FormatInt(r.Pool, 10), strconv.FormatInt(r.Rake, 10),
+			strconv.FormatInt(r.Payouts, 10), strconv.FormatInt(r.Shards, 10), r.WinnerArchetype})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// SweepRow is one sweep value's result.
+type SweepRow struct {
+	Param   string  `json:"param"`
+	Value   string  `json:"value"`
+	Summary Summary `json:"summary"`
+}
+
+// sweepColumns are the headline metrics the comparison table shows.
+var sweepColumns = []struct {
+	name string
+	get  func(s Summary) float64
+}{
+	{"survival_sec", func(s Summary) float64 { return s.MeanSurvivalSec }},
+	{"house_take_pct", func(s Summary) float64 { return s.HouseTakePct }},
+	{"shards_per_star", func(s Summary) float64 { return s.ShardsPerStar }},
+	{"win_freq_pct", func(s Summary) float64 { return s.WinFreqPct }},
+	{"place_freq_pct", func(s Summary) float64 { return s.PlaceFreqPct }},
+	{"net_positive_pct", func(s Summary) float64 { return s.NetPositivePct }},
+	{"liquidation_pct", func(s Summary) float64 { return s.finishPct("liquidation") }},
+}
+
+func (s Summary) finishPct(reason string) float64 {
+	if s.Rounds == 0 {
+		return 0
+	}
+	return float64(s.FinishReasons[reason]) / float64(s.Rounds) * 100
+}
+
+func writeSweepCSV(w io.Writer, rows []SweepRow) error {
+	cw := csv.NewWriter(w)
+	header := []string{"param", "value"}
+	for _, c := range sweepColumns {
+		header = append(header, c.name)
+	}
+	cw.Write(header)
+	for _, r := range rows {
+		rec := []string{r.Param, r.Value}
+		for _, c := range sweepColumns {
+			rec = append(rec, ftoa(c.get(r.Summary)))
+		}
+		cw.Write(rec)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func printSweepTable(w io.Writer, rows []SweepRow) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	header := []string{rows[0].Param}
+	for _, c := range sweepColumns {
+		header = append(header, c.name)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t")+"\t")
+	for _, r := range rows {
+		rec := []string{r.Value}
+		for _, c := range sweepColumns {
+			rec = append(rec, strconv.FormatFloat(c.get(r.Summary), 'f', 2, 64))
+		}
+		fmt.Fprintln(tw, strings.Join(rec, "\t")+"\t")
+	}
+	tw.Flush()
+}
+
+// --- Text report ---
+
+func printReport(w io.Writer, sc Scenario, s Summary) {
+	p := func(format string, args ...any) { fmt.Fprintf(w, format, args...) }
+	line := func(a ...any) { fmt.Fprintln(w, a...) }
+
+	line()
+	line("╔══════════════════════════════════════════════════════════════╗")
+	line("║              MONTE CARLO SIMULATION REPORT                  ║")
+	line("║                  (v2 — Top-3 Payouts)                       ║")
+	line("╚══════════════════════════════════════════════════════════════╝")
+	line()
+	p("  Players: %d  |  Rounds: %d  |  Sessions: %d  |  Seed: %d\n", s.Players, s.Rounds, s.Sessions, s.Seed)
+	var tiers []string
+	for _, t := range sortedTiers(sc.TierMix) {
+		tiers = append(tiers, fmt.Sprintf("T%d(%.0f%%)", t, sc.TierMix[t]*100))
+	}
+	p("  Tiers: %s\n", strings.Join(tiers, " "))
+	var archs []string
+	for _, a := range allArchetypes {
+		if sc.Archetypes[a.Name()] == 0 {
+			continue
+		}
+		archs = append(archs, fmt.Sprintf("%s(%.0f%%)", a, sc.Archetypes[a.Name()]*100))
+	}
+	p("  Archetypes: %s\n", strings.Join(archs, " "))
+	split := sc.Economy.Split(3)
+	shares := make([]string, len(split))
+	for i, pct := range split {
+		shares[i] = strconv.FormatInt(pct, 10)
+	}
+	p("  Rake: %d%%  |  Payouts: Top-%d (%s)  |  Pulses: Free  |  Economy: %s\n",
+		sc.Economy.RakePct, len(split), strings.Join(shares, "/"), sc.Economy.Ref())
+	p("  Elapsed: %v  |  Workers: %d\n", time.Duration(s.ElapsedSec*float64(time.Second)).Round(time.Millisecond), runtime.GOMAXPROCS(0))
+
+	line()
+	line("─── BURN ECONOMICS ────────────────────────────────────────────")
+	p("  Mean Stars burned/session:     %8.1f  (entry fee only)\n", s.MeanBurn)
+	p("  Median Stars burned/session:   %8.1f\n", s.MedianBurn)
+	p("  90th pctl burned:              %8.1f\n", s.P90Burn)
+	p("  Total Stars burned:          %10d\n", s.StarsBurned)
+	p("  Total pool collected:        %10d\n", s.Pool)
+	p("  Total rake (house):          %10d\n", s.Rake)
+	p("  Total payouts (top 3):       %10d\n", s.Payouts)
+	p("  Effective house take:          %7.2f%%\n", s.HouseTakePct)
+
+	line()
+	line("─── SHARD ECONOMICS ───────────────────────────────────────────")
+	p("  Mean Shards earned/session:    %8.1f\n", s.MeanShards)
+	p("  Median Shards earned:          %8.1f\n", s.MedianShards)
+	p("  90th pctl Shards:              %8.1f\n", s.P90Shards)
+	p("  Total Shards generated:      %10d\n", s.ShardsGenerated)
+	if s.StarsBurned > 0 {
+		p("  Shard inflation rate:          %8.4f shards/star\n", s.ShardsPerStar)
+	}
+
+	line()
+	line("─── SURVIVAL ──────────────────────────────────────────────────")
+	p("  Mean session length:           %7.1fs\n", s.MeanSurvivalSec)
+	p("  Median session length:         %7.1fs\n", s.MedianSurvivalSec)
+	p("  90th pctl session length:      %7.1fs\n", s.P90SurvivalSec)
+	p("  10th pctl session length:      %7.1fs\n", s.P10SurvivalSec)
+
+	line()
+	line("─── FINISH REASONS ────────────────────────────────────────────")
+	reasons := make([]string, 0, len(s.FinishReasons))
+	for reason := range s.FinishReasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		p("  %-20s %8d  (%5.1f%%)\n", reason, s.FinishReasons[reason], s.finishPct(reason))
+	}
+
+	line()
+	line("─── WIN & PLACEMENT RATES BY ARCHETYPE ────────────────────────")
+	for _, a := range s.Archetypes {
+		p("  %-15s  wins: %5d (%4.1f%%)  top3: %6d (%5.1f%%)  games: %7d\n",
+			a.Archetype, a.Wins, a.WinPct, a.Top3, a.Top3Pct, a.Games)
+	}
+	p("  %-15s  wins: %5d          top3: %6d           sessions: %d\n",
+		"TOTAL", s.Wins, s.Top3, s.Sessions)
+
+	line()
+	line("─── STRATEGY BALANCE ──────────────────────────────────────────")
+	for _, a := range s.Archetypes {
+		p("  %-15s  net: %+7.2f★/game  pulses: %6.1f/game  survival: %6.1fs  efficiency: %6.2f\n",
+			a.Archetype, a.NetPerGame, a.PulsesPerGame, a.MeanSurvivalSec, a.MeanEfficiency)
+	}
+
+	line()
+	line("─── EFFICIENCY DISTRIBUTION ───────────────────────────────────")
+	if s.MaxEfficiency > 0 {
+		p("  Mean efficiency:               %8.2f\n", s.MeanEfficiency)
+		p("  Median efficiency:             %8.2f\n", s.MedianEfficiency)
+		p("  90th pctl efficiency:          %8.2f\n", s.P90Efficiency)
+		p("  99th pctl efficiency:          %8.2f\n", s.P99Efficiency)
+		p("  Max efficiency:                %8.2f\n", s.MaxEfficiency)
+	}
+
+	line()
+	line("─── PLAYER LIFETIME RISK ──────────────────────────────────────")
+	p("  Active players (played >=1):   %8d / %d\n", s.ActivePlayers, s.Players)
+	p("  Net positive players:          %8d  (%5.1f%%)\n", s.NetPositive, s.NetPositivePct)
+	p("  Mean net P&L per player:       %8.1f stars\n", s.MeanNet)
+	p("  Median net P&L:                %8.1f stars\n", s.MedianNet)
+	p("  10th pctl (worst):             %8.1f stars\n", s.P10Net)
+	p("  90th pctl (best):              %8.1f stars\n", s.P90Net)
+	if s.GamesPerWin > 0 {
+		p("  Avg games per 1st place:       %8.1f\n", s.GamesPerWin)
+		p("  Median games per 1st:          %8.1f\n", s.MedianGamesPerWin)
+	}
+	if s.GamesPerTop3 > 0 {
+		p("  Avg games per top-3:           %8.1f\n", s.GamesPerTop3)
+		p("  Median games per top-3:        %8.1f\n", s.MedianGamesPerTop3)
+	}
+
+	line()
+	line("─── REINFORCEMENT FREQUENCY ───────────────────────────────────")
+	if s.Sessions > 0 {
+		p("  Win frequency (1st):           %7.2f%% per session\n", s.WinFreqPct)
+		p("  Placement frequency (top 3):   %7.2f%% per session\n", s.PlaceFreqPct)
+		p("  Micro-win frequency (top 5):   %7.2f%% (4th/5th get 2x/1.5x shards)\n", s.PlaceFreqPct*5.0/3.0)
+	}
+
+	line()
+	line("─── DIAGNOSIS ─────────────────────────────────────────────────")
+	switch {
+	case s.MeanSurvivalSec < 15:
+		line("  !! AVG SURVIVAL < 15s — HIGH CHURN RISK — players die too fast")
+	case s.MeanSurvivalSec < 30:
+		line("  ~~ AVG SURVIVAL 15-30s — moderate — watch for casual dropout")
+	default:
+		line("  OK AVG SURVIVAL > 30s — healthy session length")
+	}
+
+	switch {
+	case s.MeanBurn > 80:
+		line("  !! AVG BURN > 80 — burn velocity too high, LTV at risk")
+	case s.MeanBurn < 5:
+		line("  !! AVG BURN < 5 — burn velocity extremely low")
+	default:
+		p("  OK AVG BURN %.1f — within target range (entry-fee-only model)\n", s.MeanBurn)
+	}
+
+	switch {
+	case s.HouseTakePct < 7:
+		line("  !! HOUSE TAKE < 7% — margins too thin")
+	case s.HouseTakePct > 15:
+		line("  !! HOUSE TAKE > 15% — predatory — players will leave")
+	default:
+		p("  OK HOUSE TAKE %.1f%% — within 7-12%% target\n", s.HouseTakePct)
+	}
+
+	switch {
+	case s.ShardsPerStar > 0.8:
+		line("  !! SHARD INFLATION > 0.8 — cosmetic economy will hyperinflate")
+	case s.ShardsPerStar < 0.1:
+		line("  !! SHARD RATE < 0.1 — shards too scarce, players feel unrewarded")
+	default:
+		p("  OK SHARD RATE %.3f — balanced\n", s.ShardsPerStar)
+	}
+
+	switch {
+	case s.WinFreqPct >= 1 && s.WinFreqPct <= 3:
+		p("  OK WIN FREQ %.2f%% — within 1-3%% target\n", s.WinFreqPct)
+	case s.WinFreqPct < 1:
+		p("  !! WIN FREQ %.2f%% — below 1%%, players feel hopeless\n", s.WinFreqPct)
+	default:
+		p("  ~~ WIN FREQ %.2f%% — above 3%%, monitor pool sustainability\n", s.WinFreqPct)
+	}
+
+	if s.PlaceFreqPct >= 5 {
+		p("  OK PLACEMENT FREQ %.2f%% — healthy reinforcement via top-3\n", s.PlaceFreqPct)
+	} else {
+		p("  ~~ PLACEMENT FREQ %.2f%% — consider smaller rooms for more placements\n", s.PlaceFreqPct)
+	}
+
+	// A strategy that beats the rake on average is one players will copy.
+	if best, ok := s.bestArchetype(); ok {
+		if best.NetPerGame > 0 {
+			p("  !! STRATEGY %s nets %+.2f★/game — beats the rake, expect imitators\n", best.Archetype, best.NetPerGame)
+		} else {
+			p("  OK NO STRATEGY beats the rake — best is %s at %+.2f★/game\n", best.Archetype, best.NetPerGame)
+		}
+	}
+	// Rounds only reach max_ticks if pulses outrun the timer, i.e. the
+	// diminishing returns on PulseExtension fail to force an end.
+	if pct := s.finishPct("max_ticks"); pct > 1 {
+		p("  !! STALEMATES %.1f%% of rounds hit max_ticks — pulse extensions outpace the timer\n", pct)
+	} else {
+		p("  OK STALEMATES %.1f%% of rounds hit max_ticks\n", pct)
+	}
+
+	switch {
+	case s.NetPositivePct > 40:
+		line("  !! NET POSITIVE > 40% — house is losing money")
+	case s.NetPositivePct < 5:
+		line("  !! NET POSITIVE < 5% — almost nobody wins, churn imminent")
+	default:
+		p("  OK NET POSITIVE %.1f%% — healthy winner pool\n", s.NetPositivePct)
+	}
+
+	line()
+}
+
+// bestArchetype is the archetype with the best net result per game.
+func (s Summary) bestArchetype() (ArchetypeSummary, bool) {
+	var best ArchetypeSummary
+	found := false
+	for _, a := range s.Archetypes {
+		if a.Games > 0 && (!found || a.NetPerGame > best.NetPerGame) {
+			best, found = a, true
+		}
+	}
+	return best, found
+}
+
+func mean(s []float64) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	return sum(s) / float64(len(s))
+}
+
+func sum(s []float64) float64 {
+	t := 0.0
+	for _, v := range s {
+		t += v
+	}
+	return t
+}
+
+func percentile(sorted []float64, pct float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * pct / 100)
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lastclick/lastclick/internal/config"
+	"github.com/lastclick/lastclick/internal/game"
+	"github.com/lastclick/lastclick/internal/room"
+)
+
+// Scenario describes a Monte Carlo run. It is read from a JSON file and
+// flags override individual fields.
+type Scenario struct {
+	Players  int   `json:"players"`
+	Rounds   int   `json:"rounds"`
+	Seed     int64 `json:"seed"` // 0 picks a random seed, reported in the output
+	MaxTicks int   `json:"max_ticks"`
+
+	// PulseMinInterval is the server's game.pulse_min_interval: pulses
+	// closer together are dropped.
+	PulseMinInterval config.Duration `json:"pulse_min_interval"`
+
+	// Archetypes gives each archetype's share of players and TierMix each
+	// tier's share of rounds; both must add up to 1.
+	Archetypes map[string]float64 `json:"archetypes"`
+	TierMix    map[int]float64    `json:"tier_mix"`
+
+	// TiersFile replaces the built-in tiers, in the server's tiers file format.
+	TiersFile string `json:"tiers_file,omitempty"`
+	// Economy is laid over the default policy.
+	Economy room.EconomyPolicy `json:"economy"`
+
+	// Sweep reruns the scenario once per value, e.g.
+	// "tier2.base_extension=2s:4s:500ms" or "rake_pct=8,10,12".
+	Sweep string `json:"sweep,omitempty"`
+
+	// Seasons, when set, plays days of rounds with persistent balances
+	// instead of Rounds independent rounds.
+	Seasons *SeasonConfig `json:"seasons,omitempty"`
+}
+
+func defaultScenario() Scenario {
+	return Scenario{
+		Players:  10_000,
+		Rounds:   50_000,
+		Seed:     42,
+		MaxTicks: 2400,
+
+		PulseMinInterval: config.Duration(game.DefaultTiming.PulseMinInterval),
+		Archetypes: map[string]float64{
+			"conservative": 0.35,
+			"aggressive":   0.25,
+			"whale":        0.15,
+			"casual":       0.25,
+		},
+		TierMix: map[int]float64{1: 0.60, 2: 0.30, 3: 0.10},
+		Economy: room.DefaultEconomy(),
+	}
+}
+
+// loadScenario lays a scenario file over the defaults. Maps in the file
+// replace the default maps rather than merging into them.
+func loadScenario(path string) (Scenario, error) {
+	sc := defaultScenario()
+	if path == "" {
+		return sc, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sc, fmt.Errorf("read scenario: %w", err)
+	}
+	archetypes, tierMix := sc.Archetypes, sc.TierMix
+	sc.Archetypes, sc.TierMix = nil, nil
+	seasons := defaultSeasons()
+	sc.Seasons = &seasons
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sc); err != nil {
+		return sc, fmt.Errorf("decode scenario %s: %w", path, err)
+	}
+	if sc.Archetypes == nil {
+		sc.Archetypes = archetypes
+	}
+	if sc.TierMix == nil {
+		sc.TierMix = tierMix
+	}
+	// A seasons block is laid over the season defaults; without one the
+	// scenario runs independent rounds.
+	var present map[string]json.RawMessage
+	if json.Unmarshal(data, &present) == nil && present["seasons"] == nil {
+		sc.Seasons = nil
+	}
+	return sc, nil
+}
+
+// tiers returns the tier set the scenario plays with.
+func (sc Scenario) tiers() (map[int]room.TierConfig, error) {
+	if sc.TiersFile != "" {
+		return room.LoadTiersFile(sc.TiersFile)
+	}
+	out := make(map[int]room.TierConfig, len(room.Tiers))
+	for k, v := range room.Tiers {
+		out[k] = v
+	}
+	return out, nil
+}
+
+// Validate reports every problem with the scenario at once.
+func (sc Scenario) Validate(tiers map[int]room.TierConfig) error {
+	var errs []error
+	if sc.Players <= 0 {
+		errs = append(errs, fmt.Errorf("players must be positive, got %d", sc.Players))
+	}
+	if sc.Rounds <= 0 && sc.Seasons == nil {
+		errs = append(errs, fmt.Errorf("rounds must be positive, got %d", sc.Rounds))
+	}
+	if sc.MaxTicks <= 0 {
+		errs = append(errs, fmt.Errorf("max_ticks must be positive, got %d", sc.MaxTicks))
+	}
+	if sc.PulseMinInterval <= 0 {
+		errs = append(errs, fmt.Errorf("pulse_min_interval must be positive, got %s", time.Duration(sc.PulseMinInterval)))
+	}
+	var sum float64
+	for name, share := range sc.Archetypes {
+		if _, ok := archetypeByName(name); !ok {
+			errs = append(errs, fmt.Errorf("archetypes: unknown archetype %q", name))
+		}
+		if share < 0 {
+			errs = append(errs, fmt.Errorf("archetypes: %s share must not be negative", name))
+		}
+		sum += share
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		errs = append(errs, fmt.Errorf("archetypes: shares add up to %g, want 1", sum))
+	}
+	sum = 0
+	for tier, share := range sc.TierMix {
+		tc, ok := tiers[tier]
+		if !ok {
+			errs = append(errs, fmt.Errorf("tier_mix: tier %d is not defined", tier))
+		} else if share > 0 && tc.MinPlayers > sc.Players {
+			errs = append(errs, fmt.Errorf("tier_mix: tier %d needs %d players, scenario has %d", tier, tc.MinPlayers, sc.Players))
+		}
+		if share < 0 {
+			errs = append(errs, fmt.Errorf("tier_mix: tier %d share must not be negative", tier))
+		}
+		sum += share
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		errs = append(errs, fmt.Errorf("tier_mix: shares add up to %g, want 1", sum))
+	}
+	if err := sc.Economy.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("economy: %w", err))
+	}
+	if sc.Seasons != nil {
+		if sc.Sweep != "" {
+			errs = append(errs, errors.New("sweep is not supported with seasons"))
+		}
+		if err := sc.Seasons.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseShares reads "name=share,name=share" as used by -archetypes.
+func parseShares(s string) (map[string]float64, error) {
+	out := make(map[string]float64)
+	for _, part := range strings.Split(s, ",") {
+		name, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q: want name=share", part)
+		}
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", part, err)
+		}
+		out[strings.TrimSpace(name)] = f
+	}
+	return out, nil
+}
+
+// parseTierMix reads "1=0.6,2=0.3,3=0.1" as used by -tier-mix.
+func parseTierMix(s string) (map[int]float64, error) {
+	shares, err := parseShares(s)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int]float64, len(shares))
+	for k, v := range shares {
+		tier, err := strconv.Atoi(k)
+		if err != nil {
+			return nil, fmt.Errorf("tier %q: %w", k, err)
+		}
+		out[tier] = v
+	}
+	return out, nil
+}
+
+// sortedTiers returns the mix's tiers in order.
+func sortedTiers(mix map[int]float64) []int {
+	out := make([]int, 0, len(mix))
+	for t := range mix {
+		out = append(out, t)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// --- Sweeps ---
+
+type paramKind int
+
+const (
+	kindDuration paramKind = iota
+	kindInt
+	kindString
+)
+
+// sweepParam is a setting a sweep can vary. Tier settings apply to every tier
+// unless the name is prefixed with "tierN.".
+type sweepParam struct {
+	kind paramKind
+	tier func(tc *room.TierConfig, v string) error
+	econ func(e *room.EconomyPolicy, v string) error
+	game func(sc *Scenario, v string) error
+}
+
+var sweepParams = map[string]sweepParam{
+	"base_extension": {kind: kindDuration, tier: func(tc *room.TierConfig, v string) error {
+		return setDuration(&tc.BaseExtension, v)
+	}},
+	"pulse_window": {kind: kindDuration, tier: func(tc *room.TierConfig, v string) error {
+		return setDuration(&tc.PulseWindow, v)
+	}},
+	"survival_time": {kind: kindDuration, tier: func(tc *room.TierConfig, v string) error {
+		return setDuration(&tc.SurvivalTime, v)
+	}},
+	"entry_cost": {kind: kindInt, tier: func(tc *room.TierConfig, v string) error {
+		return setInt(&tc.EntryCost, v)
+	}},
+	"rake_pct": {kind: kindInt, econ: func(e *room.EconomyPolicy, v string) error {
+		return setInt(&e.RakePct, v)
+	}},
+	"war_chest_pct": {kind: kindInt, econ: func(e *room.EconomyPolicy, v string) error {
+		return setInt(&e.WarChestPct, v)
+	}},
+	"ties": {kind: kindString, econ: func(e *room.EconomyPolicy, v string) error {
+		e.Ties, e.TiesBy = v, nil
+		return nil
+	}},
+	"pulse_min_interval": {kind: kindDuration, game: func(sc *Scenario, v string) error {
+		return setDuration((*time.Duration)(&sc.PulseMinInterval), v)
+	}},
+}
+
+func setDuration(d *time.Duration, v string) error {
+	parsed, err := time.ParseDuration(v)
+	*d = parsed
+	return err
+}
+
+func setInt(n *int64, v string) error {
+	parsed, err := strconv.ParseInt(v, 10, 64)
+	*n = parsed
+	return err
+}
+
+// Sweep is a parsed sweep spec.
+type Sweep struct {
+	Name   string // as written, e.g. "tier2.base_extension"
+	Tier   int    // 0 means every tier
+	Param  sweepParam
+	Values []string
+}
+
+// parseSweep reads "param=a,b,c" or "param=from:to:step".
+func parseSweep(spec string) (*Sweep, error) {
+	name, vals, ok := strings.Cut(spec, "=")
+	if !ok || vals == "" {
+		return nil, fmt.Errorf("sweep %q: want param=a,b,c or param=from:to:step", spec)
+	}
+	sw := &Sweep{Name: name}
+	param := name
+	if t, rest, ok := strings.Cut(name, "."); ok && strings.HasPrefix(t, "tier") {
+		n, err := strconv.Atoi(strings.TrimPrefix(t, "tier"))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("sweep %q: bad tier %q", spec, t)
+		}
+		sw.Tier, param = n, rest
+	}
+	p, ok := sweepParams[param]
+	if !ok {
+		names := make([]string, 0, len(sweepParams))
+		for k := range sweepParams {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("sweep: unknown param %q; want one of %s", param, strings.Join(names, ", "))
+	}
+	if sw.Tier != 0 && p.tier == nil {
+		return nil, fmt.Errorf("sweep: %s is not a tier setting", param)
+	}
+	sw.Param = p
+
+	if from, rest, ok := strings.Cut(vals, ":"); ok {
+		to, step, ok := strings.Cut(rest, ":")
+		if !ok {
+			return nil, fmt.Errorf("sweep %q: a range needs from:to:step", spec)
+		}
+		values, err := expandRange(p.kind, from, to, step)
+		if err != nil {
+			return nil, fmt.Errorf("sweep %q: %w", spec, err)
+		}
+		sw.Values = values
+	} else {
+		for _, v := range strings.Split(vals, ",") {
+			sw.Values = append(sw.Values, strings.TrimSpace(v))
+		}
+	}
+	return sw, nil
+}
+
+const maxSweepValues = 100
+
+func expandRange(kind paramKind, from, to, step string) ([]string, error) {
+	var lo, hi, st int64
+	format := func(n int64) string { return strconv.FormatInt(n, 10) }
+	switch kind {
+	case kindDuration:
+		var ds [3]time.Duration
+		for i, s := range []string{from, to, step} {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, err
+			}
+			ds[i] = d
+		}
+		lo, hi, st = int64(ds[0]), int64(ds[1]), int64(ds[2])
+		format = func(n int64) string { return time.Duration(n).String() }
+	case kindInt:
+		var ns [3]int64
+		for i, s := range []string{from, to, step} {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			ns[i] = n
+		}
+		lo, hi, st = ns[0], ns[1], ns[2]
+	default:
+		return nil, fmt.Errorf("this param takes a list of values, not a range")
+	}
+	if st <= 0 || hi < lo {
+		return nil, fmt.Errorf("need from <= to and a positive step")
+	}
+	if (hi-lo)/st+1 > maxSweepValues {
+		return nil, fmt.Errorf("more than %d values", maxSweepValues)
+	}
+	var out []string
+	for v := lo; v <= hi; v += st {
+		out = append(out, format(v))
+	}
+	return out, nil
+}
+
+// apply sets the swept value on the tiers, economy or game settings of one run.
+func (sw *Sweep) apply(value string, tiers map[int]room.TierConfig, sc *Scenario) error {
+	if sw.Param.game != nil {
+		if err := sw.Param.game(sc, value); err != nil {
+			return fmt.Errorf("%s=%s: %w", sw.Name, value, err)
+		}
+		return nil
+	}
+	if sw.Param.econ != nil {
+		if err := sw.Param.econ(&sc.Economy, value); err != nil {
+			return fmt.Errorf("%s=%s: %w", sw.Name, value, err)
+		}
+		return nil
+	}
+	if sw.Tier != 0 {
+		if _, ok := tiers[sw.Tier]; !ok {
+			return fmt.Errorf("%s: tier %d is not defined", sw.Name, sw.Tier)
+		}
+	}
+	for n, tc := range tiers {
+		if sw.Tier != 0 && n != sw.Tier {
+			continue
+		}
+		if err := sw.Param.tier(&tc, value); err != nil {
+			return fmt.Errorf("%s=%s: %w", sw.Name, value, err)
+		}
+		if err := tc.Validate(); err != nil {
+			return fmt.Errorf("%s=%s: tier %d: %w", sw.Name, value, n, err)
+		}
+		tiers[n] = tc
+	}
+	return nil
+}
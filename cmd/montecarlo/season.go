@@ -0,0 +1,563 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lastclick/lastclick/internal/economy"
+	"github.com/lastclick/lastclick/internal/game"
+	"github.com/lastclick/lastclick/internal/room"
+	"github.com/lastclick/lastclick/internal/squad"
+)
+
+// SeasonConfig turns a run into a longitudinal simulation: players keep
+// Stars and shards from day to day, top up, buy cosmetics, feed squad war
+// chests and eventually leave.
+type SeasonConfig struct {
+	Seasons          int `json:"seasons"`
+	DaysPerSeason    int `json:"days_per_season"`
+	RoundsPerDay     int `json:"rounds_per_day"`
+	NewPlayersPerDay int `json:"new_players_per_day"`
+
+	StartStars int64              `json:"start_stars"`  // balance a new player arrives with
+	TopUpStars int64              `json:"top_up_stars"` // Stars bought per top-up
+	TopUpProb  map[string]float64 `json:"top_up_prob"`  // by archetype, when short of an entry fee
+
+	// Each day a player leaves for good with probability ChurnBase, plus
+	// ChurnPerStarLost for every Star lost net over the last ChurnWindowDays,
+	// capped at ChurnMax.
+	ChurnBase        float64 `json:"churn_base"`
+	ChurnPerStarLost float64 `json:"churn_per_star_lost"`
+	ChurnMax         float64 `json:"churn_max"`
+	ChurnWindowDays  int     `json:"churn_window_days"`
+
+	// ShardDecayRate is applied to every balance each ShardDecayDays, the
+	// way ShardService.ApplyDecay does it.
+	ShardDecayRate   float64 `json:"shard_decay_rate"`
+	ShardDecayDays   int     `json:"shard_decay_days"`
+	SeasonShardReset bool    `json:"season_shard_reset"` // zero all shards when a season ends
+
+	CosmeticPrice   int64   `json:"cosmetic_price"`    // in shards
+	CosmeticBuyProb float64 `json:"cosmetic_buy_prob"` // per player per day, when affordable
+
+	SquadSize  int     `json:"squad_size"`  // members per squad; 0 disables squads
+	SquadShare float64 `json:"squad_share"` // fraction of players who join one
+}
+
+func defaultSeasons() SeasonConfig {
+	return SeasonConfig{
+		Seasons:          3,
+		DaysPerSeason:    30,
+		RoundsPerDay:     300,
+		NewPlayersPerDay: 60,
+		StartStars:       50,
+		TopUpStars:       100,
+		TopUpProb: map[string]float64{
+			"conservative": 0.30,
+			"aggressive":   0.45,
+			"whale":        0.90,
+			"casual":       0.10,
+			"sniper":       0.50,
+			"efficient":    0.50,
+			"bot":          0.50,
+		},
+		ChurnBase:        0.01,
+		ChurnPerStarLost: 0.0005,
+		ChurnMax:         0.5,
+		ChurnWindowDays:  7,
+		ShardDecayRate:   0.1,
+		ShardDecayDays:   7,
+		CosmeticPrice:    200,
+		CosmeticBuyProb:  0.2,
+		SquadSize:        5,
+		SquadShare:       0.6,
+	}
+}
+
+func (c SeasonConfig) validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("seasons."+format, args...))
+		}
+	}
+	prob := func(p float64) bool { return p >= 0 && p <= 1 }
+	check(c.Seasons > 0, "seasons must be positive, got %d", c.Seasons)
+	check(c.DaysPerSeason > 0, "days_per_season must be positive, got %d", c.DaysPerSeason)
+	check(c.RoundsPerDay > 0, "rounds_per_day must be positive, got %d", c.RoundsPerDay)
+	check(c.NewPlayersPerDay >= 0, "new_players_per_day must not be negative")
+	check(c.StartStars >= 0 && c.TopUpStars >= 0, "start_stars and top_up_stars must not be negative")
+	for name, p := range c.TopUpProb {
+		_, ok := archetypeByName(name)
+		check(ok, "top_up_prob: unknown archetype %q", name)
+		check(prob(p), "top_up_prob %s must be between 0 and 1", name)
+	}
+	check(prob(c.ChurnBase) && prob(c.ChurnMax) && c.ChurnPerStarLost >= 0,
+		"churn_base and churn_max must be between 0 and 1, churn_per_star_lost not negative")
+	check(c.ChurnWindowDays > 0, "churn_window_days must be positive, got %d", c.ChurnWindowDays)
+	check(prob(c.ShardDecayRate), "shard_decay_rate must be between 0 and 1")
+	check(c.ShardDecayDays > 0, "shard_decay_days must be positive, got %d", c.ShardDecayDays)
+	check(c.CosmeticPrice > 0, "cosmetic_price must be positive, got %d", c.CosmeticPrice)
+	check(prob(c.CosmeticBuyProb), "cosmetic_buy_prob must be between 0 and 1")
+	check(c.SquadSize >= 0 && prob(c.SquadShare), "squad_size must not be negative and squad_share between 0 and 1")
+	return errors.Join(errs...)
+}
+
+// DayStats is one simulated day. Stocks (Active, ShardSupply, StarsHeld,
+// WarChestBalance) are end-of-day values; everything else is that day's flow.
+type DayStats struct {
+	Day    int `json:"day"`
+	Season int `json:"season"`
+
+	Active  int `json:"active"`
+	New     int `json:"new"`
+	Churned int `json:"churned"`
+
+	Rounds   int   `json:"rounds"`
+	Sessions int   `json:"sessions"`
+	TopUps   int   `json:"top_ups"`
+	Revenue  int64 `json:"revenue"` // Stars bought
+	Rake     int64 `json:"rake"`
+	House    int64 `json:"house"` // rake not sent to war chests, plus remainders
+	Payouts  int64 `json:"payouts"`
+
+	ShardsGranted int64 `json:"shards_granted"`
+	ShardsDecayed int64 `json:"shards_decayed"`
+	ShardsSpent   int64 `json:"shards_spent"`
+	ShardsReset   int64 `json:"shards_reset"`
+	CosmeticsSold int   `json:"cosmetics_sold"`
+
+	WarChestIn  int64 `json:"war_chest_in"`
+	WarChestOut int64 `json:"war_chest_out"`
+
+	ShardSupply     int64 `json:"shard_supply"` // held by all players, churned included
+	StarsHeld       int64 `json:"stars_held"`   // held by active players
+	WarChestBalance int64 `json:"war_chest_balance"`
+}
+
+// period folds days into one row: flows summed, stocks from the last day.
+func period(days []DayStats) DayStats {
+	out := days[len(days)-1]
+	out.Day = days[0].Day
+	out.New, out.Churned, out.Rounds, out.Sessions, out.TopUps, out.CosmeticsSold = 0, 0, 0, 0, 0, 0
+	out.Revenue, out.Rake, out.House, out.Payouts = 0, 0, 0, 0
+	out.ShardsGranted, out.ShardsDecayed, out.ShardsSpent, out.ShardsReset = 0, 0, 0, 0
+	out.WarChestIn, out.WarChestOut = 0, 0
+	for _, d := range days {
+		out.New += d.New
+		out.Churned += d.Churned
+		out.Rounds += d.Rounds
+		out.Sessions += d.Sessions
+		out.TopUps += d.TopUps
+		out.CosmeticsSold += d.CosmeticsSold
+		out.Revenue += d.Revenue
+		out.Rake += d.Rake
+		out.House += d.House
+		out.Payouts += d.Payouts
+		out.ShardsGranted += d.ShardsGranted
+		out.ShardsDecayed += d.ShardsDecayed
+		out.ShardsSpent += d.ShardsSpent
+		out.ShardsReset += d.ShardsReset
+		out.WarChestIn += d.WarChestIn
+		out.WarChestOut += d.WarChestOut
+	}
+	return out
+}
+
+type seasonPlayer struct {
+	*MCPlayer
+	stars  int64
+	shards int64
+	squad  int // index into seasonSim.squads; -1 for none
+	active bool
+	recent []int64 // net Stars per day over the churn window, as a ring
+	today  int64
+}
+
+type seasonSim struct {
+	sc      Scenario
+	cfg     SeasonConfig
+	tiers   map[int]room.TierConfig
+	mix     []int
+	seed    int64
+	players []*seasonPlayer // players[id-1]
+	chests  []int64         // war chest per squad
+	members [][]*seasonPlayer
+}
+
+type seating struct {
+	tier    room.TierConfig
+	players []*seasonPlayer
+}
+
+// simulateSeasons plays sc.Seasons day by day and returns one row per day.
+func simulateSeasons(sc Scenario, tiers map[int]room.TierConfig, seed int64, workers int, progress io.Writer) ([]DayStats, time.Duration) {
+	start := time.Now()
+	room.ReplaceEconomy(sc.Economy)
+	s := &seasonSim{sc: sc, cfg: *sc.Seasons, tiers: tiers, mix: sortedTiers(sc.TierMix), seed: seed}
+
+	rng := rand.New(rand.NewSource(seed))
+	for range sc.Players {
+		s.join(rng)
+	}
+	totalDays := s.cfg.Seasons * s.cfg.DaysPerSeason
+	days := make([]DayStats, 0, totalDays)
+	for day := 1; day <= totalDays; day++ {
+		days = append(days, s.playDay(day, workers))
+		if day%s.cfg.DaysPerSeason == 0 {
+			d := days[len(days)-1]
+			fmt.Fprintf(progress, "  ... season %d done: %d active, %d shards held\n", d.Season, d.Active, d.ShardSupply)
+		}
+	}
+	return days, time.Since(start)
+}
+
+// join signs up a player with an archetype drawn by share, maybe into a squad.
+func (s *seasonSim) join(rng *rand.Rand) *seasonPlayer {
+	arch := allArchetypes[len(allArchetypes)-1]
+	r, cum := rng.Float64(), 0.0
+	for _, a := range allArchetypes {
+		cum += s.sc.Archetypes[a.Name()]
+		if r < cum {
+			arch = a
+			break
+		}
+	}
+	p := &seasonPlayer{
+		MCPlayer: &MCPlayer{ID: int64(len(s.players) + 1), Archetype: arch},
+		stars:    s.cfg.StartStars,
+		squad:    -1,
+		active:   true,
+		recent:   make([]int64, s.cfg.ChurnWindowDays),
+	}
+	if s.cfg.SquadSize > 0 && rng.Float64() < s.cfg.SquadShare {
+		if n := len(s.members); n == 0 || len(s.members[n-1]) >= s.cfg.SquadSize {
+			s.members = append(s.members, nil)
+			s.chests = append(s.chests, 0)
+		}
+		p.squad = len(s.members) - 1
+		s.members[p.squad] = append(s.members[p.squad], p)
+	}
+	s.players = append(s.players, p)
+	return p
+}
+
+func (s *seasonSim) playDay(day, workers int) DayStats {
+	rng := rand.New(rand.NewSource(s.seed + int64(day)*104729))
+	ds := DayStats{Day: day, Season: (day-1)/s.cfg.DaysPerSeason + 1}
+	if day > 1 {
+		for range s.cfg.NewPlayersPerDay {
+			s.join(rng)
+			ds.New++
+		}
+	}
+	var active []*seasonPlayer
+	for _, p := range s.players {
+		if p.active {
+			active = append(active, p)
+		}
+	}
+
+	// Seat every round first, charging entry as players sit down, so the
+	// expensive simulations can then run in parallel.
+	var seatings []seating
+	for range s.cfg.RoundsPerDay {
+		tier := pickTier(rng, s.mix, s.sc.TierMix, s.tiers)
+		if st, ok := s.seat(rng, active, tier, &ds); ok {
+			seatings = append(seatings, st)
+		}
+	}
+	results := s.playRounds(day, seatings, workers)
+	for i, st := range seatings {
+		s.settle(st, results[i], &ds)
+	}
+
+	s.endOfDay(rng, day, &ds)
+	return ds
+}
+
+// seat fills a room from the active players. Players short of the entry fee
+// top up or sit out; a room that cannot reach MinPlayers is refunded.
+func (s *seasonSim) seat(rng *rand.Rand, active []*seasonPlayer, tier room.TierConfig, ds *DayStats) (seating, bool) {
+	size := tier.MinPlayers + rng.Intn(tier.MaxPlayers-tier.MinPlayers+1)
+	st := seating{tier: tier}
+	taken := make(map[int64]bool, size)
+	for attempt := 0; attempt < size*3 && len(st.players) < size && len(active) > 0; attempt++ {
+		p := active[rng.Intn(len(active))]
+		if taken[p.ID] {
+			continue
+		}
+		taken[p.ID] = true
+		if p.stars < tier.EntryCost {
+			if rng.Float64() >= s.cfg.TopUpProb[p.Archetype.Name()] || s.cfg.TopUpStars == 0 {
+				continue
+			}
+			for p.stars < tier.EntryCost {
+				p.stars += s.cfg.TopUpStars
+				ds.Revenue += s.cfg.TopUpStars
+				ds.TopUps++
+			}
+		}
+		p.stars -= tier.EntryCost
+		p.today -= tier.EntryCost
+		st.players = append(st.players, p)
+	}
+	if len(st.players) < tier.MinPlayers {
+		for _, p := range st.players {
+			p.stars += tier.EntryCost
+			p.today += tier.EntryCost
+		}
+		return st, false
+	}
+	return st, true
+}
+
+func (s *seasonSim) playRounds(day int, seatings []seating, workers int) []roundResult {
+	results := make([]roundResult, len(seatings))
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := next.Add(1) - 1
+				if i >= int64(len(seatings)) {
+					return
+				}
+				st := seatings[i]
+				rng := rand.New(rand.NewSource(s.seed + int64(day)*104729 + (i+1)*7919))
+				mcs := make([]*MCPlayer, len(st.players))
+				for j, p := range st.players {
+					mcs[j] = p.MCPlayer
+				}
+				results[i] = playRound(rng, mcs, st.tier, s.sc.MaxTicks, time.Duration(s.sc.PulseMinInterval))
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// settle pays out a round. Each player's war chest share goes to their
+// squad; shares of squadless players and remainders stay with the house.
+func (s *seasonSim) settle(st seating, r roundResult, ds *DayStats) {
+	ds.Rounds++
+	ds.Sessions += len(r.pstats)
+	ds.Rake += r.rake
+	share := game.WarChestContribution(r.rake) / int64(len(r.pstats))
+	var paid, credited int64
+	for _, ps := range r.pstats {
+		p := s.players[ps.pid-1]
+		p.stars += ps.payout
+		p.today += ps.payout
+		p.shards += ps.shards
+		paid += ps.payout
+		ds.ShardsGranted += ps.shards
+		if p.squad >= 0 && share > 0 {
+			s.chests[p.squad] += share
+			credited += share
+		}
+	}
+	ds.Payouts += paid
+	ds.WarChestIn += credited
+	ds.House += r.pool - paid - credited
+}
+
+func (s *seasonSim) endOfDay(rng *rand.Rand, day int, ds *DayStats) {
+	for i, chest := range s.chests {
+		var live []*seasonPlayer
+		for _, p := range s.members[i] {
+			if p.active {
+				live = append(live, p)
+			}
+		}
+		_, perMember := squad.Distribution(chest, len(live))
+		for _, p := range live {
+			p.stars += perMember
+		}
+		s.chests[i] -= perMember * int64(len(live))
+		ds.WarChestOut += perMember * int64(len(live))
+	}
+
+	for _, p := range s.players {
+		if p.active && p.shards >= s.cfg.CosmeticPrice && rng.Float64() < s.cfg.CosmeticBuyProb {
+			p.shards -= s.cfg.CosmeticPrice
+			ds.ShardsSpent += s.cfg.CosmeticPrice
+			ds.CosmeticsSold++
+		}
+	}
+	if day%s.cfg.ShardDecayDays == 0 {
+		for _, p := range s.players {
+			after := economy.DecayedShards(p.shards, s.cfg.ShardDecayRate)
+			ds.ShardsDecayed += p.shards - after
+			p.shards = after
+		}
+	}
+	if day%s.cfg.DaysPerSeason == 0 && s.cfg.SeasonShardReset {
+		for _, p := range s.players {
+			ds.ShardsReset += p.shards
+			p.shards = 0
+		}
+	}
+
+	for _, p := range s.players {
+		if !p.active {
+			continue
+		}
+		p.recent[day%len(p.recent)] = p.today
+		p.today = 0
+		var net int64
+		for _, v := range p.recent {
+			net += v
+		}
+		churn := s.cfg.ChurnBase
+		if net < 0 {
+			churn += s.cfg.ChurnPerStarLost * float64(-net)
+		}
+		if rng.Float64() < min(churn, s.cfg.ChurnMax) {
+			p.active = false
+			ds.Churned++
+		}
+	}
+
+	for _, p := range s.players {
+		ds.ShardSupply += p.shards
+		if p.active {
+			ds.Active++
+			ds.StarsHeld += p.stars
+		}
+	}
+	for _, c := range s.chests {
+		ds.WarChestBalance += c
+	}
+}
+
+// --- Output ---
+
+var dayColumns = []struct {
+	name string
+	get  func(d DayStats) int64
+}{
+	{"active", func(d DayStats) int64 { return int64(d.Active) }},
+	{"new", func(d DayStats) int64 { return int64(d.New) }},
+	{"churned", func(d DayStats) int64 { return int64(d.Churned) }},
+	{"sessions", func(d DayStats) int64 { return int64(d.Sessions) }},
+	{"revenue", func(d DayStats) int64 { return d.Revenue }},
+	{"house", func(d DayStats) int64 { return d.House }},
+	{"shards_granted", func(d DayStats) int64 { return d.ShardsGranted }},
+	{"shards_decayed", func(d DayStats) int64 { return d.ShardsDecayed }},
+	{"shards_spent", func(d DayStats) int64 { return d.ShardsSpent }},
+	{"shard_supply", func(d DayStats) int64 { return d.ShardSupply }},
+	{"war_chest", func(d DayStats) int64 { return d.WarChestBalance }},
+}
+
+func printSeasonReport(w io.Writer, sc Scenario, seed int64, days []DayStats, elapsed time.Duration) {
+	cfg := sc.Seasons
+	fmt.Fprintf(w, "\nSEASON SIMULATION  |  Seasons: %d x %d days  |  Rounds/day: %d  |  Seed: %d  |  Elapsed: %v\n",
+		cfg.Seasons, cfg.DaysPerSeason, cfg.RoundsPerDay, seed, elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "Players: %d at start, +%d/day  |  Shard decay: %.0f%% every %d days  |  Economy: %s\n\n",
+		sc.Players, cfg.NewPlayersPerDay, cfg.ShardDecayRate*100, cfg.ShardDecayDays, sc.Economy.Ref())
+
+	fmt.Fprintln(w, "─── WEEKLY ────────────────────────────────────────────────────")
+	var weeks []DayStats
+	for i := 0; i < len(days); i += 7 {
+		weeks = append(weeks, period(days[i:min(i+7, len(days))]))
+	}
+	printPeriods(w, "from_day", weeks, func(d DayStats) string { return strconv.Itoa(d.Day) })
+
+	fmt.Fprintln(w, "\n─── SEASONS ───────────────────────────────────────────────────")
+	var seasons []DayStats
+	for i := 0; i < len(days); i += cfg.DaysPerSeason {
+		seasons = append(seasons, period(days[i:min(i+cfg.DaysPerSeason, len(days))]))
+	}
+	printPeriods(w, "season", seasons, func(d DayStats) string { return strconv.Itoa(d.Season) })
+
+	first, last := days[0], days[len(days)-1]
+	total := period(days)
+	fmt.Fprintln(w, "\n─── DIAGNOSIS ─────────────────────────────────────────────────")
+	if total.Sessions > 0 {
+		fmt.Fprintf(w, "  Shard supply per active player: %.0f (day 1) → %.0f (day %d)\n",
+			perActive(first.ShardSupply, first.Active), perActive(last.ShardSupply, last.Active), last.Day)
+	}
+	if total.ShardsGranted > 0 {
+		sink := float64(total.ShardsDecayed+total.ShardsSpent+total.ShardsReset) / float64(total.ShardsGranted) * 100
+		switch {
+		case sink < 50:
+			fmt.Fprintf(w, "  !! SHARD SINKS remove %.0f%% of grants — supply is saturating\n", sink)
+		default:
+			fmt.Fprintf(w, "  OK SHARD SINKS remove %.0f%% of grants\n", sink)
+		}
+	}
+	if peak := maxActive(days); peak > 0 {
+		retained := float64(last.Active) / float64(peak) * 100
+		if retained < 50 {
+			fmt.Fprintf(w, "  !! ACTIVE PLAYERS at %.0f%% of peak — churn outpaces signups\n", retained)
+		} else {
+			fmt.Fprintf(w, "  OK ACTIVE PLAYERS at %.0f%% of peak\n", retained)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func printPeriods(w io.Writer, label string, rows []DayStats, key func(DayStats) string) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	header := []string{label}
+	for _, c := range dayColumns {
+		header = append(header, c.name)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t")+"\t")
+	for _, r := range rows {
+		rec := []string{key(r)}
+		for _, c := range dayColumns {
+			rec = append(rec, strconv.FormatInt(c.get(r), 10))
+		}
+		fmt.Fprintln(tw, strings.Join(rec, "\t")+"\t")
+	}
+	tw.Flush()
+}
+
+func writeDaysCSV(w io.Writer, days []DayStats) error {
+	cw := csv.NewWriter(w)
+	header := []string{"day", "season"}
+	for _, c := range dayColumns {
+		header = append(header, c.name)
+	}
+	cw.Write(append(header, "top_ups", "rake", "payouts", "shards_reset", "cosmetics_sold", "war_chest_in", "war_chest_out", "stars_held"))
+	for _, d := range days {
+		rec := []string{strconv.Itoa(d.Day), strconv.Itoa(d.Season)}
+		for _, c := range dayColumns {
+			rec = append(rec, strconv.FormatInt(c.get(d), 10))
+		}
+		for _, v := range []int64{int64(d.TopUps), d.Rake, d.Payouts, d.ShardsReset, int64(d.CosmeticsSold), d.WarChestIn, d.WarChestOut, d.StarsHeld} {
+			rec = append(rec, strconv.FormatInt(v, 10))
+		}
+		cw.Write(rec)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func perActive(n int64, active int) float64 {
+	if active == 0 {
+		return 0
+	}
+	return float64(n) / float64(active)
+}
+
+func maxActive(days []DayStats) int {
+	peak := 0
+	for _, d := range days {
+		peak = max(peak, d.Active)
+	}
+	return peak
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lastclick/lastclick/internal/game"
+	"github.com/lastclick/lastclick/internal/room"
+)
+
+type Archetype int
+
+const (
+	Conservative Archetype = iota
+	Aggressive
+	Whale
+	Casual
+
+	// Adaptive archetypes decide each tick from the game state; see agents.go.
+	Sniper
+	Efficient
+	Bot
+)
+
+var allArchetypes = []Archetype{Conservative, Aggressive, Whale, Casual, Sniper, Efficient, Bot}
+
+func (a Archetype) String() string {
+	return [...]string{"Conservative", "Aggressive", "Whale", "Casual", "Sniper", "Efficient", "Bot"}[a]
+}
+
+// Name is the archetype's key in scenario files and output.
+func (a Archetype) Name() string {
+	return strings.ToLower(a.String())
+}
+
+func archetypeByName(name string) (Archetype, bool) {
+	for _, a := range allArchetypes {
+		if a.Name() == name {
+			return a, true
+		}
+	}
+	return 0, false
+}
+
+type MCPlayer struct {
+	ID        int64
+	Archetype Archetype
+
+	mu              sync.Mutex
+	TotalBurned     int64
+	TotalShards     int64
+	TotalWins       int
+	TotalPlaces     int // top 3 finishes
+	TotalGames      int
+	TotalPayouts    int64
+	TotalTicks      int64
+	TotalEfficiency float64
+}
+
+type roundResult struct {
+	tierNum      int
+	playerCount  int
+	ticks        int
+	finishReason string
+	pool         int64
+	rake         int64
+	hasWinner    bool
+	winnerArch   Archetype
+	pstats       []pstat
+}
+
+type pstat struct {
+	pid       int64
+	arch      Archetype
+	burned    int64
+	shards    int64
+	ticks     int
+	pulses    int
+	won       bool
+	placed    bool // top 3
+	placement int
+	payout    int64
+	eff       float64
+}
+
+// Run is one simulated scenario.
+type Run struct {
+	Seed    int64
+	Players []*MCPlayer
+	Rounds  []roundResult
+	Elapsed time.Duration
+}
+
+// simulate plays sc.Rounds rounds on workers goroutines. Each round draws from
+// its own RNG derived from the seed, so results do not depend on workers.
+func simulate(sc Scenario, tiers map[int]room.TierConfig, seed int64, workers int, progress io.Writer) Run {
+	start := time.Now()
+	room.ReplaceEconomy(sc.Economy)
+
+	players := makePlayers(sc, seed)
+	mix := sortedTiers(sc.TierMix)
+	results := make([]roundResult, sc.Rounds)
+
+	var next, done atomic.Int64
+	step := max(int64(sc.Rounds/10), 1)
+	var wg sync.WaitGroup
+	for w := 0; w < max(workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := next.Add(1) - 1
+				if i >= int64(sc.Rounds) {
+					return
+				}
+				rng := rand.New(rand.NewSource(seed + (i+1)*7919))
+				tier := pickTier(rng, mix, sc.TierMix, tiers)
+				results[i] = runRound(rng, players, tier, sc.MaxTicks, time.Duration(sc.PulseMinInterval))
+				if n := done.Add(1); n%step == 0 {
+					fmt.Fprintf(progress, "  ... %d/%d rounds (%.0f%%)\n", n, sc.Rounds, float64(n)/float64(sc.Rounds)*100)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return Run{Seed: seed, Players: players, Rounds: results, Elapsed: time.Since(start)}
+}
+
+// makePlayers assigns archetypes by share, then shuffles them.
+func makePlayers(sc Scenario, seed int64) []*MCPlayer {
+	players := make([]*MCPlayer, sc.Players)
+	for i := range players {
+		r := float64(i) / float64(sc.Players)
+		arch := allArchetypes[len(allArchetypes)-1]
+		var cum float64
+		for _, a := range allArchetypes {
+			cum += sc.Archetypes[a.Name()]
+			if r < cum {
+				arch = a
+				break
+			}
+		}
+		players[i] = &MCPlayer{ID: int64(i + 1), Archetype: arch}
+	}
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(players), func(i, j int) { players[i], players[j] = players[j], players[i] })
+	return players
+}
+
+func pickTier(rng *rand.Rand, order []int, mix map[int]float64, tiers map[int]room.TierConfig) room.TierConfig {
+	tr := rng.Float64()
+	var cum float64
+	for _, t := range order {
+		cum += mix[t]
+		if tr < cum {
+			return tiers[t]
+		}
+	}
+	return tiers[order[len(order)-1]]
+}
+
+func runRound(rng *rand.Rand, allPlayers []*MCPlayer, tier room.TierConfig, maxTicks int, pulseMin time.Duration) roundResult {
+	roomSize := tier.MinPlayers + rng.Intn(tier.MaxPlayers-tier.MinPlayers+1)
+	if roomSize > len(allPlayers) {
+		roomSize = len(allPlayers)
+	}
+
+	indices := rng.Perm(len(allPlayers))[:roomSize]
+	selected := make([]*MCPlayer, roomSize)
+	for i, idx := range indices {
+		selected[i] = allPlayers[idx]
+	}
+	return playRound(rng, selected, tier, maxTicks, pulseMin)
+}
+
+// playRound simulates one round with the given players and adds the results
+// to their totals.
+func playRound(rng *rand.Rand, selected []*MCPlayer, tier room.TierConfig, maxTicks int, pulseMin time.Duration) roundResult {
+	roomSize := len(selected)
+	ids := make([]int64, roomSize)
+	for i, p := range selected {
+		ids[i] = p.ID
+	}
+
+	volScript := genVolScript(rng, tier, maxTicks)
+
+	pulseSchedule := make(map[int][]int64)
+	pulseWindowTicks := int(tier.PulseWindow / (250 * time.Millisecond))
+	var agents map[int64]game.SimAgent
+
+	for _, p := range selected {
+		if agent := newAgent(rng, p.Archetype); agent != nil {
+			if agents == nil {
+				agents = make(map[int64]game.SimAgent)
+			}
+			agents[p.ID] = agent
+			continue
+		}
+		genPlayerPulses(rng, p.ID, p.Archetype, pulseWindowTicks, maxTicks, pulseSchedule)
+	}
+
+	result := game.RunSimulation(game.SimConfig{
+		Tier:             tier,
+		PlayerIDs:        ids,
+		VolScript:        volScript,
+		PulseSchedule:    pulseSchedule,
+		Agents:           agents,
+		PulseMinInterval: pulseMin,
+		MaxTicks:         maxTicks,
+		SilentMode:       true,
+	})
+
+	pool := int64(roomSize) * tier.EntryCost
+	rake := game.RakeAmount(pool)
+
+	pstats := make([]pstat, 0, roomSize)
+	var winnerArch Archetype
+
+	for _, p := range selected {
+		st := result.PlayerStats[p.ID]
+		survTicks := result.TotalTicks
+		if st.EliminatedAt > 0 {
+			survTicks = st.EliminatedAt
+		}
+		won := p.ID == result.WinnerID
+		placed := st.Placement > 0 && st.Placement <= 3
+
+		ps := pstat{
+			pid:       p.ID,
+			arch:      p.Archetype,
+			burned:    tier.EntryCost,
+			shards:    st.ShardsEarned,
+			ticks:     survTicks,
+			pulses:    st.PulseCount,
+			won:       won,
+			placed:    placed,
+			placement: st.Placement,
+			payout:    st.Payout,
+			eff:       st.Efficiency,
+		}
+		pstats = append(pstats, ps)
+
+		if won {
+			winnerArch = p.Archetype
+		}
+
+		p.mu.Lock()
+		p.TotalBurned += tier.EntryCost
+		p.TotalShards += st.ShardsEarned
+		p.TotalPayouts += st.Payout
+		p.TotalTicks += int64(survTicks)
+		p.TotalEfficiency += ps.eff
+		p.TotalGames++
+		if won {
+			p.TotalWins++
+		}
+		if placed {
+			p.TotalPlaces++
+		}
+		p.mu.Unlock()
+	}
+
+	return roundResult{
+		tierNum:      tier.Tier,
+		playerCount:  roomSize,
+		ticks:        result.TotalTicks,
+		finishReason: result.FinishReason,
+		pool:         pool,
+		rake:         rake,
+		hasWinner:    result.WinnerID != 0,
+		winnerArch:   winnerArch,
+		pstats:       pstats,
+	}
+}
+
+func genVolScript(rng *rand.Rand, tier room.TierConfig, maxTicks int) map[int]float64 {
+	script := make(map[int]float64)
+	ratio := 0.1 + rng.Float64()*0.2
+	survivalTicks := int(tier.SurvivalTime / (250 * time.Millisecond))
+
+	for tick := 1; tick <= maxTicks; tick++ {
+		progress := float64(tick) / float64(survivalTicks)
+		noise := rng.NormFloat64() * 0.02
+		target := 0.3 + 0.7*math.Pow(math.Min(progress, 1.5), 1.5)
+		reversion := (target - ratio) * 0.05
+		spike := 0.0
+		if rng.Float64() < 0.03 {
+			spike = (rng.Float64() - 0.3) * 0.15
+		}
+		ratio += 0.005 + noise + reversion + spike
+		ratio = math.Max(0.01, math.Min(1.0, ratio))
+
+		if tick%4 == 0 {
+			script[tick] = ratio
+		}
+
+		if ratio >= 1.0 {
+			script[tick] = 1.0
+			break
+		}
+	}
+	return script
+}
+
+func genPlayerPulses(rng *rand.Rand, pid int64, arch Archetype, pwTicks, maxTicks int, schedule map[int][]int64) {
+	switch arch {
+	case Conservative:
+		interval := 3
+		for tick := 1 + rng.Intn(3); tick <= maxTicks; tick += interval {
+			schedule[tick] = append(schedule[tick], pid)
+		}
+
+	case Aggressive:
+		interval := pwTicks - 2
+		if interval < 3 {
+			interval = 3
+		}
+		for tick := 1; tick <= maxTicks; tick += interval + rng.Intn(3) - 1 {
+			if tick < 1 {
+				tick = 1
+			}
+			schedule[tick] = append(schedule[tick], pid)
+		}
+
+	case Whale:
+		for tick := 1; tick <= maxTicks; tick += 2 {
+			schedule[tick] = append(schedule[tick], pid)
+		}
+
+	case Casual:
+		interval := 6 + rng.Intn(5)
+		for tick := 1 + rng.Intn(5); tick <= maxTicks; tick += interval {
+			if rng.Float64() < 0.25 {
+				continue
+			}
+			schedule[tick] = append(schedule[tick], pid)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -10,11 +12,14 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/lastclick/lastclick/internal/auth"
 	"github.com/lastclick/lastclick/internal/cache"
 	"github.com/lastclick/lastclick/internal/config"
 	"github.com/lastclick/lastclick/internal/game"
 	"github.com/lastclick/lastclick/internal/room"
+	"github.com/lastclick/lastclick/internal/sanction"
 	"github.com/lastclick/lastclick/internal/server"
+	"github.com/lastclick/lastclick/internal/settlement"
 	"github.com/lastclick/lastclick/internal/squad"
 	"github.com/lastclick/lastclick/internal/store"
 )
@@ -23,11 +28,21 @@ func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	slog.SetDefault(logger)
 
-	cfg, err := config.Load()
+	configFile := flag.String("config", "", "JSON config file (default $CONFIG_FILE); environment variables override it")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted, then exit")
+	flag.Parse()
+
+	cfg, err := config.Load(*configFile)
 	if err != nil {
 		logger.Error("load config", "err", err)
 		os.Exit(1)
 	}
+	if *printConfig {
+		out, _ := json.MarshalIndent(cfg.Redacted(), "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+	room.ReplaceEconomy(cfg.Economy)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -48,83 +63,76 @@ func main() {
 
 	// Stores
 	playerStore := store.NewPlayerStore(db)
-	txStore := store.NewTransactionStore(db)
 	squadStore := store.NewSquadStore(db)
 
 	// Room manager
+	if cfg.TiersFile != "" {
+		tiers, err := room.LoadTiersFile(cfg.TiersFile)
+		if err != nil {
+			logger.Error("load tiers", "file", cfg.TiersFile, "err", err)
+			os.Exit(1)
+		}
+		room.ReplaceTiers(tiers)
+	}
 	rooms := room.NewManager()
 
-	// End-of-round callback: payouts, shards, then send round_result to each player for results screen.
-	onEnd := func(r *room.Room, hub *server.Hub) {
-		endCtx, endCancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer endCancel()
-
-		pool := r.Pool
-		rake := game.RakeAmount(pool)
-		placements := r.Placements()
-		payouts := game.PlacementPayouts(pool, len(r.Players))
+	// Settlement outbox: the round finished record is written first, then a
+	// retrying worker applies payouts, shards and war chest exactly once.
+	sanctionStore := store.NewSanctionStore(db)
+	sanctions := sanction.NewChecker(sanctionStore, logger)
 
-		placementMap := make(map[int64]int, len(placements))
-		for i, pid := range placements {
-			placementMap[pid] = i + 1
-		}
+	settlementStore := store.NewSettlementStore(db)
+	settler := settlement.NewWorker(settlementStore, logger)
+	settler.SetSanctionChecker(sanctions)
+	go settler.Run(ctx)
 
-		topPlaces := len(payouts)
-		for _, pp := range payouts {
-			if pp.Place-1 < len(placements) {
-				pid := placements[pp.Place-1]
-				_ = playerStore.UpdateBalance(endCtx, pid, pp.Amount, 0)
-				roomID := r.ID
-				_ = txStore.Record(endCtx, pid, store.TxPayout, pp.Amount, &roomID)
-			}
-		}
+	// End-of-round callback: record the round for settlement, then send round_result to each player for results screen.
+	onEnd := func(rs *store.RoundSettlement, hub *server.Hub) {
+		endCtx, endCancel := context.WithTimeout(ctx, 10*time.Second)
+		defer endCancel()
 
-		shardMap := make(map[int64]int64)
-		for _, p := range r.Players {
-			place := placementMap[p.ID]
-			if place > 0 && place <= topPlaces {
-				continue
-			}
-			shards := game.ShardsForLoser(r.Tier.EntryCost, r.VolatilityMul, place)
-			if shards > 0 {
-				_ = playerStore.UpdateBalance(endCtx, p.ID, 0, shards)
-				roomID := r.ID
-				_ = txStore.Record(endCtx, p.ID, store.TxShardGrant, shards, &roomID)
-				shardMap[p.ID] = shards
-			}
+		plan, err := settlement.BuildPlan(rs)
+		if err != nil {
+			logger.Error("round settlement plan", "room", rs.RoomID, "err", err)
 		}
-
-		warChest := game.WarChestContribution(rake)
-		if warChest > 0 {
-			for _, p := range r.Players {
-				player, err := playerStore.Get(endCtx, p.ID)
-				if err != nil || player == nil || player.SquadID == nil {
-					continue
-				}
-				share := warChest / int64(r.PlayerCount())
-				_ = squadStore.AddToWarChest(endCtx, *player.SquadID, share)
-			}
+		if err := settler.Submit(endCtx, rs); err != nil {
+			logger.Error("round settlement lost", "room", rs.RoomID, "pool", rs.Pool, "err", err)
 		}
+		// Show sanctioned players what settlement will actually credit.
+		plan.Withhold(func(pid int64) bool { return settler.Blocked(endCtx, pid) })
 
 		// Send round_result to each player for results screen (placement, shards, re-enter).
+		// Co-survivors also learn how their tie was resolved.
 		if hub != nil {
-			for _, p := range r.Players {
-				place := placementMap[p.ID]
-				shards := shardMap[p.ID]
-				payload, _ := json.Marshal(map[string]any{
-					"placement": place,
-					"shards":    shards,
-				})
-				hub.SendTo(p.ID, server.WSMessage{Type: "round_result", Payload: payload})
+			for _, pid := range rs.PlayerIDs {
+				result := map[string]any{
+					"placement": plan.Placement[pid],
+					"shards":    plan.Shards[pid],
+					"stars":     plan.Stars[pid],
+				}
+				if place := plan.Placement[pid]; rs.CoSurvivors > 1 && place > 0 && place <= rs.CoSurvivors {
+					result["tie_policy"] = plan.Ties
+					result["co_survivors"] = rs.CoSurvivors
+				}
+				payload, _ := json.Marshal(result)
+				hub.SendTo(pid, server.WSMessage{Type: "round_result", Payload: payload})
 			}
 		}
 
+		var winner int64
+		if len(rs.Placements) > 0 {
+			winner = rs.Placements[0]
+		}
 		logger.Info("room finished",
-			"room", r.ID,
-			"winner", r.WinnerID,
-			"pool", pool,
-			"rake", rake,
-			"placements", len(placements),
+			"room", rs.RoomID,
+			"winner", winner,
+			"pool", rs.Pool,
+			"economy", plan.Economy.Ref(),
+			"rake", plan.Rake,
+			"placements", len(rs.Placements),
+			"co_survivors", rs.CoSurvivors,
+			"ties", plan.Ties,
+			"settlement", rs.ID,
 		)
 	}
 
@@ -132,11 +140,64 @@ func main() {
 	engine := game.NewEngine(rooms, nil, logger, onEnd)
 	hub := server.NewHub(cfg.BotToken, cfg.Env == "development", engine, logger)
 	engine.SetHub(hub)
+	engine.SetTiming(game.Timing{
+		TickRate:         time.Duration(cfg.Game.TickRate),
+		NextRoundDelay:   time.Duration(cfg.Game.NextRoundDelay),
+		PulseMinInterval: time.Duration(cfg.Game.PulseMinInterval),
+	})
+	hub.SetWSOptions(server.WSOptions{
+		OriginPatterns: cfg.WSOrigins,
+		ReadLimit:      cfg.WSReadLimit,
+		PingInterval:   time.Duration(cfg.WSPingInterval),
+		MessageRate:    float64(cfg.WSMessageRate),
+		MessageBurst:   cfg.WSMessageBurst,
+		Compression:    cfg.WSCompression,
+	})
+
+	// Session tokens outlive initData's 5-minute window; accepted by the hub and the HTTP API.
+	sessionSecret := []byte(cfg.SessionSecret)
+	if len(sessionSecret) == 0 {
+		sessionSecret = auth.DeriveSessionSecret(cfg.BotToken)
+	}
+	sessions := server.NewSessionManager(sessionSecret, rdb)
+	hub.SetSessionManager(sessions)
+
+	verifier, err := newInitDataVerifier(cfg)
+	if err != nil {
+		logger.Error("initData verifier", "err", err)
+		os.Exit(1)
+	}
+	hub.SetVerifier(verifier)
+
+	// Every initData connect upserts the player; new players get the starter grant.
+	registrar := server.NewRegistrar(playerStore, store.StarterGrant{
+		Stars:  cfg.StarterStars,
+		Shards: cfg.StarterShards,
+	}, logger)
+	hub.SetRegistrar(registrar)
+
+	hub.SetSanctionChecker(sanctions)
+	engine.SetSanctionChecker(sanctions)
+	engine.SetRoundStore(store.NewRoundStore(db))
 
 	engine.EnsureRooms()
 
 	srv := server.New(cfg, db, rdb, hub, logger)
+
+	// One metrics registry behind /metrics for the hub, engine and settlement.
+	hub.SetMetrics(srv.Metrics())
+	engine.SetMetrics(srv.Metrics())
+	settler.SetMetrics(srv.Metrics())
 	srv.SetPlayerStore(playerStore)
+	srv.SetSessionManager(sessions)
+	srv.SetRegistrar(registrar)
+	srv.SetVerifier(verifier)
+	srv.SetSanctions(sanctionStore, sanctions)
+	srv.SetRoomAdmin(rooms, engine)
+	srv.SetSettlementStore(settlementStore)
+	if cfg.RateLimitStore == "redis" {
+		srv.SetRateLimitStore(server.NewRedisLimiter(rdb, logger))
+	}
 
 	// Squad service
 	squadSvc := squad.NewService(squadStore, playerStore, logger)
@@ -169,3 +230,25 @@ func main() {
 		logger.Error("shutdown", "err", err)
 	}
 }
+
+// newInitDataVerifier accepts the bot-token hash and, when a public key is
+// configured, Telegram's Ed25519 third-party signature.
+func newInitDataVerifier(cfg *config.Config) (auth.Verifier, error) {
+	window := auth.Window{MaxAge: time.Duration(cfg.InitDataMaxAge), ClockSkew: time.Duration(cfg.InitDataClockSkew)}
+	v := &auth.MultiVerifier{HMAC: auth.NewHMACVerifier(cfg.BotToken, window)}
+	if cfg.InitDataPublicKey == "" {
+		return v, nil
+	}
+	pub, err := auth.ParsePublicKey(cfg.InitDataPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	botID := cfg.TelegramBotID
+	if botID == 0 {
+		if botID, err = auth.BotIDFromToken(cfg.BotToken); err != nil {
+			return nil, fmt.Errorf("TELEGRAM_BOT_ID: %w", err)
+		}
+	}
+	v.Ed25519 = auth.NewEd25519Verifier(botID, pub, window)
+	return v, nil
+}
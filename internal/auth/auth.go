@@ -8,14 +8,26 @@ import (
 	"net/url"
 	"sort"
 	"strings"
-	"time"
 )
 
-const initDataMaxAge = 5 * time.Minute
-
 // ValidateInitData validates Telegram Mini App initData according to
 // https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app
+// using the default freshness window.
 func ValidateInitData(initData, botToken string) error {
+	return NewHMACVerifier(botToken, DefaultWindow).Verify(initData)
+}
+
+// HMACVerifier checks the bot-token HMAC "hash" field.
+type HMACVerifier struct {
+	botToken string
+	window   Window
+}
+
+func NewHMACVerifier(botToken string, w Window) *HMACVerifier {
+	return &HMACVerifier{botToken: botToken, window: w}
+}
+
+func (v *HMACVerifier) Verify(initData string) error {
 	vals, err := url.ParseQuery(initData)
 	if err != nil {
 		return fmt.Errorf("parse init data: %w", err)
@@ -26,39 +38,37 @@ func ValidateInitData(initData, botToken string) error {
 		return fmt.Errorf("missing hash")
 	}
 
-	authDate := vals.Get("auth_date")
-	if authDate == "" {
-		return fmt.Errorf("missing auth_date")
-	}
-
-	var ts int64
-	if _, err := fmt.Sscanf(authDate, "%d", &ts); err != nil {
-		return fmt.Errorf("invalid auth_date: %w", err)
+	if err := v.window.check(vals); err != nil {
+		return err
 	}
 
-	if time.Since(time.Unix(ts, 0)) > initDataMaxAge {
-		return fmt.Errorf("init data expired")
-	}
-
-	dataCheckString := buildDataCheckString(vals)
-
-	secretKey := hmacSHA256([]byte("WebAppData"), []byte(botToken))
-	computed := hex.EncodeToString(hmacSHA256(secretKey, []byte(dataCheckString)))
-
-	if computed != receivedHash {
+	if computeHash(vals, v.botToken) != receivedHash {
 		return fmt.Errorf("hash mismatch")
 	}
 
 	return nil
 }
 
-func buildDataCheckString(vals url.Values) string {
+func computeHash(vals url.Values, botToken string) string {
+	secretKey := hmacSHA256([]byte("WebAppData"), []byte(botToken))
+	return hex.EncodeToString(hmacSHA256(secretKey, []byte(buildDataCheckString(vals, "hash"))))
+}
+
+// buildDataCheckString joins the sorted "key=value" pairs with newlines,
+// leaving out the given keys.
+func buildDataCheckString(vals url.Values, exclude ...string) string {
 	keys := make([]string, 0, len(vals))
 	for k := range vals {
-		if k == "hash" {
-			continue
+		skip := false
+		for _, e := range exclude {
+			if k == e {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			keys = append(keys, k)
 		}
-		keys = append(keys, k)
 	}
 	sort.Strings(keys)
 
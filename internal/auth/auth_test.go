@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testBotToken = "123456:TEST-TOKEN"
+
+func testInitData(authDate time.Time, priv ed25519.PrivateKey) string {
+	vals := url.Values{}
+	vals.Set("user", `{"id":42,"first_name":"Ann","username":"ann"}`)
+	vals.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+	return SignInitData(vals, testBotToken, 123456, priv)
+}
+
+func TestHMACVerifier(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	w := Window{MaxAge: 5 * time.Minute, ClockSkew: 10 * time.Second, Now: func() time.Time { return now }}
+	v := NewHMACVerifier(testBotToken, w)
+
+	if err := v.Verify(testInitData(now.Add(-time.Minute), nil)); err != nil {
+		t.Fatalf("fresh initData rejected: %v", err)
+	}
+	if err := v.Verify(testInitData(now.Add(5*time.Second), nil)); err != nil {
+		t.Fatalf("initData within skew rejected: %v", err)
+	}
+	if err := v.Verify(testInitData(now.Add(time.Minute), nil)); err == nil {
+		t.Fatal("future initData accepted")
+	}
+	if err := v.Verify(testInitData(now.Add(-6*time.Minute), nil)); err == nil {
+		t.Fatal("expired initData accepted")
+	}
+	if err := NewHMACVerifier("123456:OTHER", w).Verify(testInitData(now, nil)); err == nil {
+		t.Fatal("initData accepted with wrong bot token")
+	}
+}
+
+func TestEd25519Verifier(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	w := Window{MaxAge: 5 * time.Minute, Now: func() time.Time { return now }}
+	priv := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	pub := priv.Public().(ed25519.PublicKey)
+
+	data := testInitData(now, priv)
+	if err := NewEd25519Verifier(123456, pub, w).Verify(data); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := NewEd25519Verifier(654321, pub, w).Verify(data); err == nil {
+		t.Fatal("signature accepted for another bot")
+	}
+
+	vals, _ := url.ParseQuery(data)
+	vals.Set("user", `{"id":43}`)
+	if err := NewEd25519Verifier(123456, pub, w).Verify(vals.Encode()); err == nil {
+		t.Fatal("tampered user accepted")
+	}
+
+	// The bot-token hash covers the signature field, so both schemes hold at once.
+	m := &MultiVerifier{HMAC: NewHMACVerifier(testBotToken, w), Ed25519: NewEd25519Verifier(123456, pub, w)}
+	if err := m.Verify(data); err != nil {
+		t.Fatalf("multi verifier: %v", err)
+	}
+	if err := m.HMAC.Verify(data); err != nil {
+		t.Fatalf("hash over signed initData: %v", err)
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	for _, k := range []string{"prod", "test", TelegramPublicKeyProd} {
+		if _, err := ParsePublicKey(k); err != nil {
+			t.Errorf("ParsePublicKey(%q): %v", k, err)
+		}
+	}
+	if _, err := ParsePublicKey("abcd"); err == nil {
+		t.Error("short key accepted")
+	}
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Telegram's public keys for third-party initData validation.
+// https://core.telegram.org/bots/webapps#validating-data-for-third-party-use
+const (
+	TelegramPublicKeyProd = "e7bf03a2fa4602af4580703d88dda5bb59f32ed8b02a56c187fe7d34caed242d"
+	TelegramPublicKeyTest = "40055058a4ee38156a06562e52eece92a771bcd8346a8c4615cb7376eddf72ec"
+)
+
+// Ed25519Verifier checks the "signature" field, which needs only the bot ID
+// and Telegram's public key rather than the bot token.
+type Ed25519Verifier struct {
+	botID  int64
+	pub    ed25519.PublicKey
+	window Window
+}
+
+func NewEd25519Verifier(botID int64, pub ed25519.PublicKey, w Window) *Ed25519Verifier {
+	return &Ed25519Verifier{botID: botID, pub: pub, window: w}
+}
+
+func (v *Ed25519Verifier) Verify(initData string) error {
+	vals, err := url.ParseQuery(initData)
+	if err != nil {
+		return fmt.Errorf("parse init data: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(vals.Get("signature"), "="))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("malformed signature")
+	}
+	if err := v.window.check(vals); err != nil {
+		return err
+	}
+	if !ed25519.Verify(v.pub, []byte(ed25519CheckString(vals, v.botID)), sig) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func ed25519CheckString(vals url.Values, botID int64) string {
+	return strconv.FormatInt(botID, 10) + ":WebAppData\n" + buildDataCheckString(vals, "hash", "signature")
+}
+
+// ParsePublicKey decodes a hex Ed25519 public key. "prod" and "test" name
+// Telegram's own keys.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	switch s {
+	case "prod":
+		s = TelegramPublicKeyProd
+	case "test":
+		s = TelegramPublicKeyTest
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode public key: %w", err)
+	}
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes", ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// BotIDFromToken returns the numeric prefix of a bot token ("123456:ABC…").
+func BotIDFromToken(botToken string) (int64, error) {
+	id, _, ok := strings.Cut(botToken, ":")
+	if !ok {
+		return 0, fmt.Errorf("malformed bot token")
+	}
+	return strconv.ParseInt(id, 10, 64)
+}
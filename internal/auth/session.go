@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TokenKind distinguishes short-lived access tokens from refresh tokens.
+type TokenKind string
+
+const (
+	AccessToken  TokenKind = "access"
+	RefreshToken TokenKind = "refresh"
+)
+
+// SessionClaims is the payload of a signed session token.
+type SessionClaims struct {
+	PlayerID  int64     `json:"sub"`
+	SessionID string    `json:"sid"`
+	Kind      TokenKind `json:"typ"`
+	IssuedAt  int64     `json:"iat"`
+	ExpiresAt int64     `json:"exp"`
+}
+
+// SignSession encodes claims as base64url(json) + "." + base64url(HMAC-SHA256).
+func SignSession(c SessionClaims, secret []byte) (string, error) {
+	body, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(body)
+	sig := base64.RawURLEncoding.EncodeToString(hmacSHA256(secret, []byte(payload)))
+	return payload + "." + sig, nil
+}
+
+// ParseSession verifies the signature and expiry of a token produced by SignSession.
+// Revocation is not checked here; that needs the server-side session store.
+func ParseSession(token string, secret []byte, now time.Time) (*SessionClaims, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, fmt.Errorf("malformed token")
+	}
+	gotSig, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature: %w", err)
+	}
+	if !hmac.Equal(gotSig, hmacSHA256(secret, []byte(payload))) {
+		return nil, fmt.Errorf("signature mismatch")
+	}
+	body, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("malformed payload: %w", err)
+	}
+	var c SessionClaims
+	if err := json.Unmarshal(body, &c); err != nil {
+		return nil, fmt.Errorf("decode claims: %w", err)
+	}
+	if c.PlayerID == 0 || c.SessionID == "" {
+		return nil, fmt.Errorf("incomplete claims")
+	}
+	if now.Unix() >= c.ExpiresAt {
+		return nil, fmt.Errorf("token expired")
+	}
+	return &c, nil
+}
+
+// DeriveSessionSecret derives a session signing key from the bot token, for
+// deployments that don't configure a dedicated secret.
+func DeriveSessionSecret(botToken string) []byte {
+	h := sha256.Sum256([]byte("lastclick-session:" + botToken))
+	return h[:]
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSession(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1_700_000_000, 0)
+	token, err := SignSession(SessionClaims{
+		PlayerID: 42, SessionID: "s1", Kind: AccessToken,
+		IssuedAt: now.Unix(), ExpiresAt: now.Add(15 * time.Minute).Unix(),
+	}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := ParseSession(token, secret, now); err != nil || c.PlayerID != 42 || c.Kind != AccessToken {
+		t.Fatalf("valid token: %+v, %v", c, err)
+	}
+
+	payload, sig, _ := strings.Cut(token, ".")
+	flip := func(s string) string {
+		b := []byte(s)
+		if b[0] == 'A' {
+			b[0] = 'B'
+		} else {
+			b[0] = 'A'
+		}
+		return string(b)
+	}
+	forged, _ := SignSession(SessionClaims{PlayerID: 7, SessionID: "s1", Kind: AccessToken, ExpiresAt: now.Add(time.Hour).Unix()}, secret)
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	cases := []struct {
+		name  string
+		token string
+		at    time.Time
+		want  string
+	}{
+		{"tampered signature", payload + "." + flip(sig), now, "signature mismatch"},
+		{"swapped payload", forgedPayload + "." + sig, now, "signature mismatch"},
+		{"other secret", token, now, "signature mismatch"},
+		{"expired", token, now.Add(15 * time.Minute), "token expired"},
+		{"no signature", payload, now, "malformed token"},
+	}
+	for _, c := range cases {
+		key := secret
+		if c.name == "other secret" {
+			key = []byte("other")
+		}
+		if _, err := ParseSession(c.token, key, c.at); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: err %v, want %q", c.name, err, c.want)
+		}
+	}
+}
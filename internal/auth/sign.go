@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/url"
+)
+
+// SignInitData adds an Ed25519 "signature" (when priv is set) and the bot-token
+// "hash" to vals and returns the encoded initData. Used by the dev tooling and
+// tests to produce initData the verifiers accept.
+func SignInitData(vals url.Values, botToken string, botID int64, priv ed25519.PrivateKey) string {
+	vals.Del("hash")
+	vals.Del("signature")
+	if priv != nil {
+		sig := ed25519.Sign(priv, []byte(ed25519CheckString(vals, botID)))
+		vals.Set("signature", base64.RawURLEncoding.EncodeToString(sig))
+	}
+	if botToken != "" {
+		vals.Set("hash", computeHash(vals, botToken))
+	}
+	return vals.Encode()
+}
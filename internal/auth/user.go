@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// User is the Telegram user object carried in initData's "user" field.
+type User struct {
+	ID           int64  `json:"id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name,omitempty"`
+	Username     string `json:"username,omitempty"`
+	LanguageCode string `json:"language_code,omitempty"`
+	IsPremium    bool   `json:"is_premium,omitempty"`
+	PhotoURL     string `json:"photo_url,omitempty"`
+}
+
+// DisplayName is the handle shown in room rosters: the username when set,
+// otherwise the first and last name.
+func (u *User) DisplayName() string {
+	if u.Username != "" {
+		return u.Username
+	}
+	if u.LastName != "" {
+		return u.FirstName + " " + u.LastName
+	}
+	return u.FirstName
+}
+
+// ParseUser extracts the user object from initData. It does not validate the
+// hash; call ValidateInitData first.
+func ParseUser(initData string) (*User, error) {
+	vals, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, fmt.Errorf("parse init data: %w", err)
+	}
+	userJSON := vals.Get("user")
+	if userJSON == "" {
+		return nil, fmt.Errorf("missing user")
+	}
+	var u User
+	if err := json.Unmarshal([]byte(userJSON), &u); err != nil {
+		return nil, fmt.Errorf("decode user: %w", err)
+	}
+	if u.ID == 0 {
+		return nil, fmt.Errorf("invalid user id")
+	}
+	return &u, nil
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Verifier checks that initData was signed by Telegram and is fresh.
+type Verifier interface {
+	Verify(initData string) error
+}
+
+// Window bounds how old initData may be. ClockSkew tolerates auth_date values
+// slightly in the future and stretches MaxAge by the same amount.
+type Window struct {
+	MaxAge    time.Duration
+	ClockSkew time.Duration
+	Now       func() time.Time // nil means time.Now
+}
+
+var DefaultWindow = Window{MaxAge: 5 * time.Minute, ClockSkew: 30 * time.Second}
+
+func (w Window) check(vals url.Values) error {
+	authDate := vals.Get("auth_date")
+	if authDate == "" {
+		return fmt.Errorf("missing auth_date")
+	}
+	ts, err := strconv.ParseInt(authDate, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid auth_date: %w", err)
+	}
+	now := time.Now()
+	if w.Now != nil {
+		now = w.Now()
+	}
+	age := now.Sub(time.Unix(ts, 0))
+	if age < -w.ClockSkew {
+		return fmt.Errorf("init data from the future")
+	}
+	if age > w.MaxAge+w.ClockSkew {
+		return fmt.Errorf("init data expired")
+	}
+	return nil
+}
+
+// MultiVerifier accepts initData signed with either scheme: the Ed25519
+// "signature" when present and an Ed25519 verifier is configured, otherwise
+// the bot-token "hash".
+type MultiVerifier struct {
+	HMAC    *HMACVerifier
+	Ed25519 *Ed25519Verifier
+}
+
+func (m *MultiVerifier) Verify(initData string) error {
+	vals, err := url.ParseQuery(initData)
+	if err != nil {
+		return fmt.Errorf("parse init data: %w", err)
+	}
+	if m.Ed25519 != nil && vals.Get("signature") != "" {
+		return m.Ed25519.Verify(initData)
+	}
+	if m.HMAC != nil && vals.Get("hash") != "" {
+		return m.HMAC.Verify(initData)
+	}
+	return fmt.Errorf("no supported signature")
+}
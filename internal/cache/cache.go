@@ -27,4 +27,7 @@ const (
 	KeyMatchQueue  = "matchmaking:tier:%d"
 	KeyLeaderboard = "leaderboard:efficiency:season:%d"
 	KeySquadBoard  = "leaderboard:squad:season:%d"
+
+	KeySession        = "session:%s"
+	KeyPlayerSessions = "session:player:%d"
 )
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lastclick/lastclick/internal/room"
+)
+
+func writeFile(t *testing.T, body string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoadLayersEnvOverFileOverDefaults(t *testing.T) {
+	t.Setenv("ENV", "production")
+	t.Setenv("BOT_TOKEN", "123:abc")
+	t.Setenv("WS_MESSAGE_RATE", "7")
+	p := writeFile(t, `{
+		"ws_message_rate": 50,
+		"ws_message_burst": 80,
+		"game": {"tick_rate": "100ms"},
+		"economy": {"version": 2, "rake_pct": 10}
+	}`)
+
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.WSMessageRate != 7 {
+		t.Errorf("env should win: ws_message_rate = %d", cfg.WSMessageRate)
+	}
+	if cfg.WSMessageBurst != 80 || time.Duration(cfg.Game.TickRate) != 100*time.Millisecond {
+		t.Errorf("file values lost: burst=%d tick=%s", cfg.WSMessageBurst, time.Duration(cfg.Game.TickRate))
+	}
+	if cfg.Economy.RakePct != 10 || cfg.Economy.WarChestPct != 3 || len(cfg.Economy.Payouts) != 3 {
+		t.Errorf("economy not layered over defaults: %+v", cfg.Economy)
+	}
+	if cfg.RedisAddr != "localhost:6379" {
+		t.Errorf("default lost: redis_addr = %q", cfg.RedisAddr)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	t.Setenv("ENV", "production")
+	t.Setenv("BOT_TOKEN", "")
+	t.Setenv("WS_MESSAGE_BURST", "lots")
+	p := writeFile(t, `{
+		"rate_limit_store": "memcached",
+		"economy": {"payouts": [{"min_players": 1, "shares_pct": [90]}]}
+	}`)
+
+	_, err := Load(p)
+	if err == nil {
+		t.Fatal("invalid config accepted")
+	}
+	for _, want := range []string{"BOT_TOKEN", "WS_MESSAGE_BURST", "rate_limit_store", "add up to 90%", "new id or version"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadCannotRewriteEconomyV1(t *testing.T) {
+	t.Setenv("BOT_TOKEN", "123:abc")
+	p := writeFile(t, `{"economy": {"id": "standard", "version": 1, "payouts": [
+		{"min_players": 2, "shares_pct": [50, 50]},
+		{"min_players": 3, "shares_pct": [50, 30, 20]}
+	]}}`)
+
+	_, err := Load(p)
+	if err == nil || !strings.Contains(err.Error(), "rules differ from standard@v1") {
+		t.Fatalf("changed v1 rules accepted: %v", err)
+	}
+	if v1 := room.EconomyV1(); !reflect.DeepEqual(v1.Split(2), []int64{75, 25}) || !reflect.DeepEqual(v1.Split(3), []int64{60, 25, 15}) {
+		t.Fatalf("config file rewrote EconomyV1: %+v", v1.Payouts)
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	t.Setenv("BOT_TOKEN", "123:abc")
+	_, err := Load(writeFile(t, `{"tick_rate": "100ms"}`))
+	if err == nil || !strings.Contains(err.Error(), `unknown field "tick_rate"`) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := defaults()
+	cfg.BotToken = "123:secret"
+	cfg.DatabaseURL = "postgres://app:hunter2@db/lastclick"
+	r := cfg.Redacted()
+	if r.BotToken != "REDACTED" || strings.Contains(r.DatabaseURL, "hunter2") {
+		t.Errorf("secrets leaked: %q %q", r.BotToken, r.DatabaseURL)
+	}
+	if cfg.BotToken != "123:secret" {
+		t.Error("Redacted modified the original")
+	}
+}
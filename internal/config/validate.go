@@ -0,0 +1,61 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"time"
+
+	"github.com/lastclick/lastclick/internal/room"
+)
+
+// Validate checks every setting and returns all problems joined, each naming
+// the file key and, where there is one, the environment variable.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.BotToken != "", "bot_token (BOT_TOKEN) is required")
+	check(c.HTTPAddr != "", "http_addr (HTTP_ADDR) is required")
+	check(c.DatabaseURL != "", "database_url (DATABASE_URL) is required")
+	check(c.RedisAddr != "", "redis_addr (REDIS_ADDR) is required")
+	check(c.RedisDB >= 0, "redis_db (REDIS_DB) must not be negative, got %d", c.RedisDB)
+
+	check(c.WSReadLimit > 0, "ws_read_limit (WS_READ_LIMIT) must be positive, got %d", c.WSReadLimit)
+	check(time.Duration(c.WSPingInterval) >= time.Second, "ws_ping_interval (WS_PING_INTERVAL_SEC) must be at least 1s, got %s", time.Duration(c.WSPingInterval))
+	check(c.WSMessageRate > 0, "ws_message_rate (WS_MESSAGE_RATE) must be positive, got %d", c.WSMessageRate)
+	check(c.WSMessageBurst > 0, "ws_message_burst (WS_MESSAGE_BURST) must be positive, got %d", c.WSMessageBurst)
+	for _, p := range c.WSOrigins {
+		if p == "*" {
+			errs = append(errs, fmt.Errorf("ws_allowed_origins (WS_ALLOWED_ORIGINS): \"*\" would allow any site; list the origins instead"))
+		} else if _, err := path.Match(p, ""); err != nil {
+			errs = append(errs, fmt.Errorf("ws_allowed_origins (WS_ALLOWED_ORIGINS): bad pattern %q", p))
+		}
+	}
+
+	check(c.StarterStars >= 0, "starter_stars (STARTER_STARS) must not be negative, got %d", c.StarterStars)
+	check(c.StarterShards >= 0, "starter_shards (STARTER_SHARDS) must not be negative, got %d", c.StarterShards)
+	check(c.RateLimitStore == "memory" || c.RateLimitStore == "redis",
+		"rate_limit_store (RATE_LIMIT_STORE): want memory or redis, got %q", c.RateLimitStore)
+	check(c.InitDataMaxAge > 0, "initdata_max_age (INITDATA_MAX_AGE_SEC) must be positive")
+	check(c.InitDataClockSkew >= 0, "initdata_clock_skew (INITDATA_CLOCK_SKEW_SEC) must not be negative")
+
+	tick := time.Duration(c.Game.TickRate)
+	check(tick >= 10*time.Millisecond && tick <= time.Second, "game.tick_rate must be between 10ms and 1s, got %s", tick)
+	check(c.Game.NextRoundDelay > 0, "game.next_round_delay must be positive, got %s", time.Duration(c.Game.NextRoundDelay))
+	check(c.Game.PulseMinInterval > 0, "game.pulse_min_interval must be positive, got %s", time.Duration(c.Game.PulseMinInterval))
+
+	if err := c.Economy.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("economy: %w", err))
+	}
+	v1 := room.EconomyV1()
+	if c.Economy.ID == v1.ID && c.Economy.Version == v1.Version && !reflect.DeepEqual(c.Economy, v1) {
+		errs = append(errs, fmt.Errorf("economy: rules differ from %s; give the policy a new id or version", v1.Ref()))
+	}
+	return errors.Join(errs...)
+}
@@ -37,11 +37,15 @@ func (s *ShardService) ApplyDecay(ctx context.Context, playerID int64, decayRate
 	if err != nil || player == nil {
 		return err
 	}
-	decayed := int64(math.Floor(float64(player.ShardsBalance) * (1.0 - decayRate)))
-	delta := decayed - player.ShardsBalance
+	delta := DecayedShards(player.ShardsBalance, decayRate) - player.ShardsBalance
 	return s.players.UpdateBalance(ctx, playerID, 0, delta)
 }
 
+// DecayedShards is a shard balance after one decay step, rounded down.
+func DecayedShards(balance int64, decayRate float64) int64 {
+	return int64(math.Floor(float64(balance) * (1.0 - decayRate)))
+}
+
 // SeasonReset zeroes out shard balances for all players.
 func (s *ShardService) SeasonReset(ctx context.Context) error {
 	// Handled via direct SQL for efficiency in seasonal reset
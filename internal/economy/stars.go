@@ -104,6 +104,10 @@ func (s *StarsService) CreditStars(ctx context.Context, playerID int64, amount i
 	return s.txs.Record(ctx, playerID, store.TxEntry, amount, nil)
 }
 
+// ErrInsufficientStars wraps store.ErrNegativeBalance so callers outside the
+// economy package can match it.
+var ErrInsufficientStars = fmt.Errorf("insufficient stars balance: %w", store.ErrNegativeBalance)
+
 // DebitStars deducts Stars for a pulse or entry fee.
 func (s *StarsService) DebitStars(ctx context.Context, playerID int64, amount int64, roomID *string) error {
 	player, err := s.players.Get(ctx, playerID)
@@ -111,7 +115,7 @@ func (s *StarsService) DebitStars(ctx context.Context, playerID int64, amount in
 		return err
 	}
 	if player == nil || player.StarsBalance < amount {
-		return fmt.Errorf("insufficient stars balance")
+		return ErrInsufficientStars
 	}
 	if err := s.players.UpdateBalance(ctx, playerID, -amount, 0); err != nil {
 		return err
@@ -0,0 +1,254 @@
+package game
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/lastclick/lastclick/internal/room"
+	"github.com/lastclick/lastclick/internal/sanction"
+	"github.com/lastclick/lastclick/internal/server"
+)
+
+// maxRoomIDLen bounds client-supplied room IDs; real ones are UUIDs.
+const maxRoomIDLen = 64
+
+// commandPayload is the schema of one inbound message type.
+type commandPayload interface {
+	validate() error
+}
+
+// noPayload is the schema of commands that take no arguments.
+type noPayload struct{}
+
+func (noPayload) validate() error { return nil }
+
+type joinRoomPayload struct {
+	RoomID string `json:"room_id"`
+}
+
+func (p joinRoomPayload) validate() error {
+	if p.RoomID == "" {
+		return server.CommandErrorf(server.CodeBadRequest, "room_id is required")
+	}
+	if len(p.RoomID) > maxRoomIDLen {
+		return server.CommandErrorf(server.CodeBadRequest, "room_id is too long")
+	}
+	return nil
+}
+
+type resumePayload struct {
+	LastSeq uint64 `json:"last_seq"`
+	RoomID  string `json:"room_id"` // optional; defaults to the last room
+}
+
+func (p resumePayload) validate() error {
+	if len(p.RoomID) > maxRoomIDLen {
+		return server.CommandErrorf(server.CodeBadRequest, "room_id is too long")
+	}
+	return nil
+}
+
+// decodePayload strictly decodes msg.Payload into p and validates it. A
+// missing payload decodes as an empty object; unknown fields are rejected.
+func decodePayload(msg server.WSMessage, p commandPayload) error {
+	raw := bytes.TrimSpace(msg.Payload)
+	if len(raw) > 0 && !bytes.Equal(raw, []byte("null")) {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(p); err != nil {
+			return server.CommandErrorf(server.CodeBadRequest, "invalid %s payload: %v", msg.Type, err)
+		}
+	}
+	return p.validate()
+}
+
+func (e *Engine) handleCommand(ctx context.Context, client *server.Client, msg server.WSMessage) (any, error) {
+	switch msg.Type {
+	case "sync":
+		if err := decodePayload(msg, &noPayload{}); err != nil {
+			return nil, err
+		}
+		return e.syncCmd(client), nil
+
+	case "resume":
+		var p resumePayload
+		if err := decodePayload(msg, &p); err != nil {
+			return nil, err
+		}
+		return e.resumeCmd(client, p)
+
+	case "forfeit":
+		if err := decodePayload(msg, &noPayload{}); err != nil {
+			return nil, err
+		}
+		return nil, e.forfeitCmd(client)
+
+	case "join_room":
+		var p joinRoomPayload
+		if err := decodePayload(msg, &p); err != nil {
+			return nil, err
+		}
+		return e.joinRoomCmd(ctx, client, p)
+
+	case "pulse":
+		if err := decodePayload(msg, &noPayload{}); err != nil {
+			return nil, err
+		}
+		return nil, e.pulseCmd(ctx, client)
+
+	case "list_rooms":
+		if err := decodePayload(msg, &noPayload{}); err != nil {
+			return nil, err
+		}
+		e.listRoomsCmd(client)
+		return nil, nil
+
+	default:
+		return nil, server.CommandErrorf(server.CodeUnknownType, "unknown message type %q", msg.Type)
+	}
+}
+
+type roomResult struct {
+	RoomID string `json:"room_id,omitempty"`
+}
+
+// syncCmd restores the player's last room if there is one. Having nothing
+// to restore is not an error: the client sends sync on every connect.
+func (e *Engine) syncCmd(client *server.Client) roomResult {
+	r, ok := e.reconnect(client.ID, "")
+	if !ok {
+		return roomResult{}
+	}
+	e.hub.JoinRoom(client.ID, r.ID)
+	e.broadcastState(r)
+	return roomResult{RoomID: r.ID}
+}
+
+// resumeCmd is sync plus replay of every room broadcast after last_seq (or a
+// room_state snapshot if the replay buffer no longer covers it).
+func (e *Engine) resumeCmd(client *server.Client, p resumePayload) (roomResult, error) {
+	r, ok := e.reconnect(client.ID, p.RoomID)
+	if !ok {
+		return roomResult{}, server.CommandErrorf(server.CodeNotInRoom, "no room to resume")
+	}
+	snapshot := server.WSMessage{Type: "room_state", Payload: e.roomStatePayload(r)}
+	e.hub.Resume(client.ID, r.ID, p.LastSeq, snapshot)
+	e.broadcastState(r)
+	return roomResult{RoomID: r.ID}, nil
+}
+
+// currentRoom returns the room the client is playing in.
+func (e *Engine) currentRoom(client *server.Client) (*room.Room, error) {
+	if client.RoomID == "" {
+		return nil, server.CommandErrorf(server.CodeNotInRoom, "not in a room")
+	}
+	r, ok := e.rooms.Get(client.RoomID)
+	if !ok || !r.HasPlayer(client.ID) {
+		return nil, server.CommandErrorf(server.CodeNotInRoom, "not in a room")
+	}
+	return r, nil
+}
+
+// forfeitCmd is a voluntary exit, not a disconnect.
+func (e *Engine) forfeitCmd(client *server.Client) error {
+	r, err := e.currentRoom(client)
+	if err != nil {
+		return err
+	}
+	switch r.State {
+	case room.StateWaiting:
+		// Refund during WAITING. Remove from list.
+		if r.RemovePlayer(client.ID, true) {
+			e.hub.LeaveRoom(client.ID, r.ID)
+			e.broadcastState(r)
+		}
+	case room.StateActive:
+		// No refund once countdown started (prevents volatility scouting).
+		if r.RemovePlayer(client.ID, false) {
+			e.hub.LeaveRoom(client.ID, r.ID)
+			e.broadcastState(r)
+		}
+	case room.StateSurvival:
+		if !r.IsAlive(client.ID) {
+			return server.CommandErrorf(server.CodeEliminated, "already eliminated")
+		}
+		r.Eliminate(client.ID)
+		e.broadcastElimination(r, client.ID)
+		e.broadcastState(r)
+	default:
+		return server.CommandErrorf(server.CodeWrongState, "round already finished")
+	}
+	return nil
+}
+
+func (e *Engine) joinRoomCmd(ctx context.Context, client *server.Client, p joinRoomPayload) (roomResult, error) {
+	r, ok := e.rooms.Get(p.RoomID)
+	if !ok {
+		return roomResult{}, room.ErrRoomNotFound
+	}
+	if err := e.sanctions.Check(ctx, client.ID, sanction.Join); err != nil {
+		return roomResult{}, err
+	}
+	// Switching rooms would strand the entry already in the other pool.
+	if client.RoomID != "" && client.RoomID != p.RoomID {
+		if prev, ok := e.rooms.Get(client.RoomID); ok && prev.HasPlayer(client.ID) && prev.State != room.StateFinished {
+			return roomResult{}, server.CommandErrorf(server.CodeAlreadyInRoom, "already playing in another room")
+		}
+	}
+	if err := r.AddPlayer(client.ID, client.Username); err != nil {
+		return roomResult{}, err
+	}
+	e.hub.JoinRoom(client.ID, p.RoomID)
+	e.broadcastState(r)
+	if r.CanStart() {
+		e.StartRoom(ctx, r.ID)
+		e.EnsureRooms()
+	}
+	return roomResult{RoomID: r.ID}, nil
+}
+
+func (e *Engine) pulseCmd(ctx context.Context, client *server.Client) error {
+	r, err := e.currentRoom(client)
+	if err != nil {
+		return err
+	}
+	if r.State != room.StateSurvival {
+		return server.CommandErrorf(server.CodeWrongState, "pulses are only accepted during survival")
+	}
+	if !r.IsAlive(client.ID) {
+		return server.CommandErrorf(server.CodeEliminated, "already eliminated")
+	}
+	if err := e.sanctions.Check(ctx, client.ID, sanction.Pulse); err != nil {
+		return err
+	}
+	return e.SubmitPulse(client.ID, r.ID)
+}
+
+func (e *Engine) listRoomsCmd(client *server.Client) {
+	waiting := e.rooms.ListByState(room.StateWaiting)
+	active := e.rooms.ListByState(room.StateActive)
+	survival := e.rooms.ListByState(room.StateSurvival)
+	all := append(append(waiting, active...), survival...)
+	type roomInfo struct {
+		ID      string `json:"id"`
+		Type    string `json:"type"`
+		Tier    int    `json:"tier"`
+		State   string `json:"state"`
+		Players int    `json:"players"`
+		Pool    int64  `json:"pool"`
+	}
+	var list []roomInfo
+	for _, rm := range all {
+		list = append(list, roomInfo{
+			ID:      rm.ID,
+			Type:    string(rm.Type),
+			Tier:    rm.Tier.Tier,
+			State:   rm.State.String(),
+			Players: rm.PlayerCount(),
+			Pool:    rm.Pool,
+		})
+	}
+	payload, _ := json.Marshal(list)
+	e.hub.SendTo(client.ID, server.WSMessage{Type: "room_list", Payload: payload})
+}
@@ -0,0 +1,50 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/lastclick/lastclick/internal/server"
+)
+
+func decodeCode(t *testing.T, typ, payload string, p commandPayload) server.ErrorCode {
+	t.Helper()
+	msg := server.WSMessage{Type: typ}
+	if payload != "" {
+		msg.Payload = json.RawMessage(payload)
+	}
+	err := decodePayload(msg, p)
+	if err == nil {
+		return ""
+	}
+	var ce *server.CommandError
+	if !errors.As(err, &ce) {
+		t.Fatalf("%s %s: non-command error %v", typ, payload, err)
+	}
+	return ce.Code
+}
+
+func TestDecodePayload(t *testing.T) {
+	cases := []struct {
+		typ, payload string
+		p            commandPayload
+		want         server.ErrorCode
+	}{
+		{"join_room", `{"room_id":"abc"}`, &joinRoomPayload{}, ""},
+		{"join_room", ``, &joinRoomPayload{}, server.CodeBadRequest},
+		{"join_room", `{"room_id":""}`, &joinRoomPayload{}, server.CodeBadRequest},
+		{"join_room", `{"room_id":42}`, &joinRoomPayload{}, server.CodeBadRequest},
+		{"join_room", `{"room_id":"abc","tier":1}`, &joinRoomPayload{}, server.CodeBadRequest},
+		{"resume", ``, &resumePayload{}, ""},
+		{"resume", `{"last_seq":-1}`, &resumePayload{}, server.CodeBadRequest},
+		{"pulse", ``, &noPayload{}, ""},
+		{"pulse", `null`, &noPayload{}, ""},
+		{"pulse", `{"x":1}`, &noPayload{}, server.CodeBadRequest},
+	}
+	for _, c := range cases {
+		if got := decodeCode(t, c.typ, c.payload, c.p); got != c.want {
+			t.Errorf("%s %q: code %q, want %q", c.typ, c.payload, got, c.want)
+		}
+	}
+}
@@ -3,39 +3,63 @@ package game
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/lastclick/lastclick/internal/room"
+	"github.com/lastclick/lastclick/internal/sanction"
 	"github.com/lastclick/lastclick/internal/server"
+	"github.com/lastclick/lastclick/internal/settlement"
+	"github.com/lastclick/lastclick/internal/store"
 	"github.com/lastclick/lastclick/internal/volatility"
 )
 
-const tickRate = 250 * time.Millisecond
+// Timing is the engine's pacing. The zero value of a field keeps its default.
+type Timing struct {
+	TickRate         time.Duration // room loop period; the timer drains per tick
+	NextRoundDelay   time.Duration // round end → room reset; players can re-enter then
+	PulseMinInterval time.Duration // per-player pulse spacing
+}
+
+// DefaultTiming is what the engine runs with unless configured otherwise.
+var DefaultTiming = Timing{
+	TickRate:         250 * time.Millisecond,
+	NextRoundDelay:   12 * time.Second,
+	PulseMinInterval: 500 * time.Millisecond,
+}
 
 type PulseEvent struct {
-	PlayerID int64
-	RoomID   string
+	PlayerID   int64
+	RoomID     string
+	ReceivedAt time.Time
 }
 
 type roomRunner struct {
 	cancel context.CancelFunc
 	pulses chan PulseEvent
+	done   chan struct{} // closed when runLoop returns
 }
 
 // Engine orchestrates all active game rooms.
 type Engine struct {
 	rooms        *room.Manager
 	hub          *server.Hub
+	rounds       *store.RoundStore
+	sanctions    *sanction.Checker
+	metrics      *server.Metrics
 	logger       *slog.Logger
 	onEnd        EndCallback
 	mu           sync.Mutex
 	running      map[string]*roomRunner
 	pulseLimiter *PulseRateLimiter
+	timing       Timing
 }
 
-type EndCallback func(r *room.Room, hub *server.Hub)
+// EndCallback receives a finished round's settlement record. It runs on its
+// own goroutine, so it may block on the database without holding up the room.
+type EndCallback func(rs *store.RoundSettlement, hub *server.Hub)
 
 func NewEngine(rooms *room.Manager, hub *server.Hub, logger *slog.Logger, onEnd EndCallback) *Engine {
 	return &Engine{
@@ -44,7 +68,22 @@ func NewEngine(rooms *room.Manager, hub *server.Hub, logger *slog.Logger, onEnd
 		logger:       logger,
 		onEnd:        onEnd,
 		running:      make(map[string]*roomRunner),
-		pulseLimiter: NewPulseRateLimiter(500 * time.Millisecond),
+		pulseLimiter: NewPulseRateLimiter(DefaultTiming.PulseMinInterval),
+		timing:       DefaultTiming,
+	}
+}
+
+// SetTiming changes the engine's pacing. Call before rooms start.
+func (e *Engine) SetTiming(t Timing) {
+	if t.TickRate > 0 {
+		e.timing.TickRate = t.TickRate
+	}
+	if t.NextRoundDelay > 0 {
+		e.timing.NextRoundDelay = t.NextRoundDelay
+	}
+	if t.PulseMinInterval > 0 {
+		e.timing.PulseMinInterval = t.PulseMinInterval
+		e.pulseLimiter = NewPulseRateLimiter(t.PulseMinInterval)
 	}
 }
 
@@ -53,20 +92,39 @@ func (e *Engine) SetHub(hub *server.Hub) {
 	e.hub = hub
 }
 
-func (e *Engine) SubmitPulse(playerID int64, roomID string) {
+// SetRoundStore enables persisting a rooms row for every round that starts.
+func (e *Engine) SetRoundStore(rs *store.RoundStore) {
+	e.rounds = rs
+}
+
+func (e *Engine) SetMetrics(m *server.Metrics) {
+	e.metrics = m
+}
+
+// SetSanctionChecker blocks muted, frozen, banned and suspended players from
+// joining rooms and pulsing.
+func (e *Engine) SetSanctionChecker(c *sanction.Checker) {
+	e.sanctions = c
+}
+
+// SubmitPulse queues a pulse for the room loop. It fails when the player is
+// pulsing too fast, the round is not running or the loop is backed up.
+func (e *Engine) SubmitPulse(playerID int64, roomID string) error {
 	if !e.pulseLimiter.AllowPulse(playerID) {
-		return
+		return server.CommandErrorf(server.CodeRateLimited, "pulsing too fast")
 	}
 	e.mu.Lock()
 	rr, ok := e.running[roomID]
 	e.mu.Unlock()
 	if !ok {
-		return
+		return server.CommandErrorf(server.CodeWrongState, "round is not running")
 	}
 	select {
-	case rr.pulses <- PulseEvent{PlayerID: playerID, RoomID: roomID}:
+	case rr.pulses <- PulseEvent{PlayerID: playerID, RoomID: roomID, ReceivedAt: time.Now()}:
+		return nil
 	default:
 		e.logger.Warn("pulse dropped, buffer full", "room", roomID, "player", playerID)
+		return server.CommandErrorf(server.CodeRateLimited, "server busy, pulse again")
 	}
 }
 
@@ -85,6 +143,7 @@ func (e *Engine) StartRoom(ctx context.Context, roomID string) {
 	rr := &roomRunner{
 		cancel: cancel,
 		pulses: make(chan PulseEvent, 256),
+		done:   make(chan struct{}),
 	}
 	e.running[roomID] = rr
 	e.mu.Unlock()
@@ -93,15 +152,46 @@ func (e *Engine) StartRoom(ctx context.Context, roomID string) {
 	now := time.Now()
 	r.StartedAt = &now
 	e.broadcastState(r)
+	e.persistRoundStart(r)
+	e.metrics.RoomStarted(string(r.Type), r.Tier.Tier)
 
 	go e.runLoop(rCtx, r, rr)
 }
 
+// persistRoundStart inserts the round's row off the tick loop. The finished row
+// is written with the settlement record, so a failure here only delays visibility.
+func (e *Engine) persistRoundStart(r *room.Room) {
+	if e.rounds == nil {
+		return
+	}
+	rd := &store.Round{
+		ID:          r.RoundID,
+		SlotID:      r.ID,
+		Type:        string(r.Type),
+		Tier:        r.Tier.Tier,
+		EntryCost:   r.Tier.EntryCost,
+		Pool:        r.Pool,
+		State:       room.StateActive.String(),
+		PlayerCount: r.PlayerCount(),
+		StartedAt:   r.StartedAt,
+		Economy:     economyJSON(r),
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := e.rounds.Start(ctx, rd); err != nil {
+			e.logger.Warn("persist round start", "room", rd.SlotID, "round", rd.ID, "err", err)
+		}
+	}()
+}
+
 func (e *Engine) runLoop(ctx context.Context, r *room.Room, rr *roomRunner) {
 	defer func() {
 		e.mu.Lock()
 		delete(e.running, r.ID)
 		e.mu.Unlock()
+		e.metrics.RoomStopped(string(r.Type), r.Tier.Tier)
+		close(rr.done)
 	}()
 
 	// Create volatility feed
@@ -116,6 +206,7 @@ func (e *Engine) runLoop(ctx context.Context, r *room.Room, rr *roomRunner) {
 	defer close(stopFeed)
 	volCh := feed.Start(stopFeed)
 
+	tickRate := e.timing.TickRate
 	ticker := time.NewTicker(tickRate)
 	defer ticker.Stop()
 
@@ -155,7 +246,8 @@ func (e *Engine) runLoop(ctx context.Context, r *room.Room, rr *roomRunner) {
 				return
 			}
 
-		case <-ticker.C:
+		case scheduled := <-ticker.C:
+			e.metrics.TickLag(string(r.Type), r.Tier.Tier, time.Since(scheduled))
 			tickCount++
 			decrement := TickDecrement(tickRate, r.MarginRatio)
 			r.GlobalTimer -= decrement
@@ -163,7 +255,7 @@ func (e *Engine) runLoop(ctx context.Context, r *room.Room, rr *roomRunner) {
 				r.GlobalTimer = 0
 			}
 
-			graceDur := time.Duration(LatencyGraceTicks) * tickRate
+			graceDur := e.pulseGrace()
 			now := time.Now()
 			for _, p := range r.AlivePlayers() {
 				if now.Sub(p.LastPulseAt) > r.Tier.PulseWindow+graceDur {
@@ -194,12 +286,65 @@ func (e *Engine) runLoop(ctx context.Context, r *room.Room, rr *roomRunner) {
 			ext := PulseExtension(r.Tier.BaseExtension, r.AliveCount())
 			r.GlobalTimer += ext
 			e.broadcastPulse(r, pulse.PlayerID, ext, pulseAt)
+			e.metrics.Pulse(string(r.Type), r.Tier.Tier, time.Since(pulse.ReceivedAt))
 		}
 	}
 }
 
-// NextRoundDelay is how long after round end before room resets; players can re-enter then.
-const NextRoundDelay = 12 * time.Second
+// VoidRoom terminates the current round without settling it: the loop is
+// stopped, the round is recorded as voided, players are told why and the room
+// goes straight back to waiting. A round that already finished is left to settle.
+func (e *Engine) VoidRoom(ctx context.Context, roomID, reason string) error {
+	r, ok := e.rooms.Get(roomID)
+	if !ok {
+		return room.ErrRoomNotFound
+	}
+	e.mu.Lock()
+	rr := e.running[roomID]
+	e.mu.Unlock()
+	if rr != nil {
+		rr.cancel()
+		select {
+		case <-rr.done:
+		case <-time.After(2 * time.Second):
+			return fmt.Errorf("room loop did not stop")
+		}
+	}
+	if r.State == room.StateFinished {
+		return room.ErrRoundFinished
+	}
+
+	now := time.Now()
+	r.State = room.StateFinished
+	r.EndedAt = &now
+	if e.rounds != nil && r.StartedAt != nil {
+		if err := e.rounds.Void(ctx, &store.Round{
+			ID:          r.RoundID,
+			SlotID:      r.ID,
+			Type:        string(r.Type),
+			Tier:        r.Tier.Tier,
+			EntryCost:   r.Tier.EntryCost,
+			Pool:        r.Pool,
+			PlayerCount: r.PlayerCount(),
+			StartedAt:   r.StartedAt,
+			EndedAt:     r.EndedAt,
+			Economy:     economyJSON(r),
+		}); err != nil {
+			e.logger.Error("persist voided round", "room", r.ID, "round", r.RoundID, "err", err)
+		}
+	}
+	e.logger.Warn("round voided", "room", r.ID, "round", r.RoundID, "reason", reason)
+	e.metrics.RoundVoided(string(r.Type), r.Tier.Tier)
+
+	payload, _ := json.Marshal(map[string]string{"room_id": r.ID, "reason": reason})
+	e.hub.BroadcastRoom(r.ID, server.WSMessage{Type: "room_voided", Payload: payload})
+	e.hub.LeaveRoomAll(r.ID)
+	if r.ResetRound() {
+		e.broadcastState(r)
+	}
+	e.EnsureRooms()
+	return nil
+}
 
 func (e *Engine) finishRoom(r *room.Room) {
 	r.State = room.StateFinished
@@ -210,15 +355,20 @@ func (e *Engine) finishRoom(r *room.Room) {
 	if len(placements) > 0 {
 		r.WinnerID = placements[0]
 	}
+	if r.StartedAt != nil {
+		e.metrics.RoundFinished(string(r.Type), r.Tier.Tier, now.Sub(*r.StartedAt))
+	}
 
 	e.broadcastState(r)
 
 	if e.onEnd != nil {
-		e.onEnd(r, e.hub)
+		// Snapshot now: the room is reset for the next round while the
+		// callback may still be submitting this one.
+		go e.onEnd(settlement.FromRoom(r), e.hub)
 	}
 
 	go func() {
-		time.Sleep(NextRoundDelay)
+		time.Sleep(e.timing.NextRoundDelay)
 		e.hub.LeaveRoomAll(r.ID)
 		if r.ResetRound() {
 			e.broadcastState(r)
@@ -273,118 +423,51 @@ func (e *Engine) OnDisconnect(client *server.Client) {
 	}
 }
 
-// HandleMessage implements server.MessageHandler.
+// HandleMessage implements server.MessageHandler. Failed commands always get
+// an error with a stable code; successful ones are acked when they carry an id.
 func (e *Engine) HandleMessage(ctx context.Context, client *server.Client, msg server.WSMessage) {
-	switch msg.Type {
-	case "sync":
-		// Reconnect: restore if before pulse window expired, else eliminate. No mercy, server-authoritative.
-		roomID := e.hub.GetLastRoom(client.ID)
-		if roomID == "" {
-			return
-		}
-		r, ok := e.rooms.Get(roomID)
-		if !ok {
-			return
-		}
-		restore, eliminate := r.ReconnectCheck(client.ID)
-		if eliminate {
-			r.Eliminate(client.ID)
-			e.broadcastElimination(r, client.ID)
-		}
-		if restore {
-			r.ClearDisconnected(client.ID)
-		}
-		if restore || eliminate {
-			e.hub.JoinRoom(client.ID, roomID)
-			e.broadcastState(r)
-		}
-
-	case "forfeit":
-		// Voluntary exit. Not disconnect.
-		if client.RoomID == "" {
-			return
-		}
-		r, ok := e.rooms.Get(client.RoomID)
-		if !ok {
-			return
-		}
-		switch r.State {
-		case room.StateWaiting:
-			// Refund during WAITING. Remove from list.
-			if r.RemovePlayer(client.ID, true) {
-				e.hub.LeaveRoom(client.ID, client.RoomID)
-				e.broadcastState(r)
-			}
-		case room.StateActive:
-			// No refund once countdown started (prevents volatility scouting).
-			if r.RemovePlayer(client.ID, false) {
-				e.hub.LeaveRoom(client.ID, client.RoomID)
-				e.broadcastState(r)
-			}
-		case room.StateSurvival:
-			r.Eliminate(client.ID)
-			e.broadcastElimination(r, client.ID)
-			e.broadcastState(r)
-		}
-
-	case "join_room":
-		var payload struct {
-			RoomID string `json:"room_id"`
-		}
-		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
-			return
-		}
-		r, ok := e.rooms.Get(payload.RoomID)
-		if !ok {
-			return
-		}
-		if r.AddPlayer(client.ID, "") {
-			e.hub.JoinRoom(client.ID, payload.RoomID)
-			e.broadcastState(r)
-			if r.CanStart() {
-				e.StartRoom(ctx, r.ID)
-				e.EnsureRooms()
-			}
-		}
-
-	case "pulse":
-		if client.RoomID == "" {
-			return
-		}
-		e.SubmitPulse(client.ID, client.RoomID)
-
-	case "list_rooms":
-		waiting := e.rooms.ListByState(room.StateWaiting)
-		active := e.rooms.ListByState(room.StateActive)
-		survival := e.rooms.ListByState(room.StateSurvival)
-		all := append(append(waiting, active...), survival...)
-		type roomInfo struct {
-			ID      string `json:"id"`
-			Type    string `json:"type"`
-			Tier    int    `json:"tier"`
-			State   string `json:"state"`
-			Players int    `json:"players"`
-			Pool    int64  `json:"pool"`
-		}
-		var list []roomInfo
-		for _, rm := range all {
-			list = append(list, roomInfo{
-				ID:      rm.ID,
-				Type:    string(rm.Type),
-				Tier:    rm.Tier.Tier,
-				State:   rm.State.String(),
-				Players: rm.PlayerCount(),
-				Pool:    rm.Pool,
-			})
-		}
-		payload, _ := json.Marshal(list)
-		e.hub.SendTo(client.ID, server.WSMessage{Type: "room_list", Payload: payload})
+	result, err := e.handleCommand(ctx, client, msg)
+	if err != nil {
+		e.hub.Reject(client.ID, msg, err)
+		return
+	}
+	if msg.ID != "" {
+		e.hub.Ack(client.ID, msg, result)
+	}
+}
 
+// reconnect restores a returning player to the room they were in, or
+// eliminates them if the pulse window expired while they were gone. No mercy,
+// server-authoritative. roomID defaults to the client's last room. ok is false
+// when the player has nothing to come back to.
+func (e *Engine) reconnect(playerID int64, roomID string) (*room.Room, bool) {
+	if roomID == "" {
+		roomID = e.hub.GetLastRoom(playerID)
 	}
+	if roomID == "" {
+		return nil, false
+	}
+	r, ok := e.rooms.Get(roomID)
+	if !ok {
+		return nil, false
+	}
+	restore, eliminate := r.ReconnectCheck(playerID)
+	if eliminate {
+		r.Eliminate(playerID)
+		e.broadcastElimination(r, playerID)
+	}
+	if restore {
+		r.ClearDisconnected(playerID)
+	}
+	return r, restore || eliminate
 }
 
 func (e *Engine) broadcastState(r *room.Room) {
-	payload, _ := json.Marshal(map[string]any{
+	e.hub.BroadcastRoom(r.ID, server.WSMessage{Type: "room_state", Payload: e.roomStatePayload(r)})
+}
+
+func (e *Engine) roomStatePayload(r *room.Room) json.RawMessage {
+	state := map[string]any{
 		"room_id":        r.ID,
 		"state":          r.State.String(),
 		"type":           string(r.Type),
@@ -396,16 +479,51 @@ func (e *Engine) broadcastState(r *room.Room) {
 		"margin_ratio":   r.MarginRatio,
 		"volatility_mul": r.VolatilityMul,
 		"winner_id":      r.WinnerID,
-	})
-	e.hub.BroadcastRoom(r.ID, server.WSMessage{Type: "room_state", Payload: payload})
+		"economy":        r.Economy,
+	}
+	if r.State == room.StateSurvival {
+		state["server_time_ms"] = time.Now().UnixMilli()
+		state["pulse_deadlines"] = pulseDeadlines(r, e.pulseGrace())
+	}
+	payload, _ := json.Marshal(state)
+	return payload
+}
+
+// economyJSON is the round's policy snapshot as stored with the round.
+func economyJSON(r *room.Room) json.RawMessage {
+	b, _ := json.Marshal(r.Economy)
+	return b
+}
+
+type pulseDeadline struct {
+	PlayerID int64 `json:"player_id"`
+	// Server unix ms after which the player is eliminated unless they pulse.
+	// Clients shift it by their time_sync offset to render a local countdown.
+	DeadlineMs int64 `json:"pulse_deadline_server_ms"`
+}
+
+// pulseGrace is the slack past a player's pulse window before elimination.
+func (e *Engine) pulseGrace() time.Duration {
+	return time.Duration(LatencyGraceTicks) * e.timing.TickRate
+}
+
+func pulseDeadlines(r *room.Room, grace time.Duration) []pulseDeadline {
+	deadlines := r.PulseDeadlines(grace)
+	out := make([]pulseDeadline, 0, len(deadlines))
+	for id, t := range deadlines {
+		out = append(out, pulseDeadline{PlayerID: id, DeadlineMs: t.UnixMilli()})
+	}
+	return out
 }
 
 func (e *Engine) broadcastTick(r *room.Room) {
 	payload, _ := json.Marshal(map[string]any{
-		"timer_ms":       r.GlobalTimer.Milliseconds(),
-		"margin_ratio":   r.MarginRatio,
-		"volatility_mul": r.VolatilityMul,
-		"alive":          r.AliveCount(),
+		"timer_ms":        r.GlobalTimer.Milliseconds(),
+		"margin_ratio":    r.MarginRatio,
+		"volatility_mul":  r.VolatilityMul,
+		"alive":           r.AliveCount(),
+		"server_time_ms":  time.Now().UnixMilli(),
+		"pulse_deadlines": pulseDeadlines(r, e.pulseGrace()),
 	})
 	e.hub.BroadcastRoom(r.ID, server.WSMessage{Type: "tick", Payload: payload})
 }
@@ -420,10 +538,11 @@ func (e *Engine) broadcastElimination(r *room.Room, playerID int64) {
 
 func (e *Engine) broadcastPulse(r *room.Room, playerID int64, ext time.Duration, pulseAt time.Time) {
 	payload, _ := json.Marshal(map[string]any{
-		"player_id":      playerID,
-		"extension_ms":   ext.Milliseconds(),
-		"timer_ms":       r.GlobalTimer.Milliseconds(),
-		"server_time_ms": pulseAt.UnixMilli(),
+		"player_id":                playerID,
+		"extension_ms":             ext.Milliseconds(),
+		"timer_ms":                 r.GlobalTimer.Milliseconds(),
+		"server_time_ms":           pulseAt.UnixMilli(),
+		"pulse_deadline_server_ms": pulseAt.Add(r.Tier.PulseWindow + e.pulseGrace()).UnixMilli(),
 	})
 	e.hub.BroadcastRoom(r.ID, server.WSMessage{Type: "pulse_ack", Payload: payload})
 }
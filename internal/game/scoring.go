@@ -3,6 +3,8 @@ package game
 import (
 	"math"
 	"time"
+
+	"github.com/lastclick/lastclick/internal/room"
 )
 
 // VolatilityMultiplier increases as the margin ratio approaches liquidation (1.0).
@@ -23,57 +25,38 @@ func SurvivalEfficiency(timeSurvived time.Duration, volMul float64, starsSpent i
 // With 250ms ticks, 1 grace tick absorbs up to ~500ms of jitter.
 const LatencyGraceTicks = 1
 
-// RakeAmount computes the 12% pool rake.
+// RakeAmount, PlacementPayouts, WarChestContribution and ShardsForLoser
+// apply the live economy policy (see room.EconomyPolicy).
+
+// RakeAmount computes the pool rake.
 func RakeAmount(pool int64) int64 {
-	return pool * 12 / 100
+	return room.CurrentEconomy().RakeAmount(pool)
 }
 
 // PlacementPayout describes a single placement's star reward.
-type PlacementPayout struct {
-	Place  int
-	Amount int64
-}
+type PlacementPayout = room.PlacementPayout
 
-// PlacementPayouts returns top-3 payouts from the post-rake pool.
+// PlacementPayouts returns top-place payouts from the post-rake pool; with
+// the default policy:
 //
 //	>=3 players → 1st 60%, 2nd 25%, 3rd 15%
 //	 2 players → 1st 75%, 2nd 25%
 //	 1 player  → 1st 100%
-func PlacementPayouts(pool int64, numPlayers int) []PlacementPayout {
-	postRake := pool - RakeAmount(pool)
-	if numPlayers <= 1 {
-		return []PlacementPayout{{1, postRake}}
-	}
-	if numPlayers == 2 {
-		return []PlacementPayout{
-			{1, postRake * 75 / 100},
-			{2, postRake * 25 / 100},
-		}
-	}
-	return []PlacementPayout{
-		{1, postRake * 60 / 100},
-		{2, postRake * 25 / 100},
-		{3, postRake * 15 / 100},
-	}
+//
+// splitCount tied top places share their payouts (split-pot ties); pass 0
+// otherwise.
+func PlacementPayouts(pool int64, numPlayers, splitCount int) []PlacementPayout {
+	return room.CurrentEconomy().PlacementPayouts(pool, numPlayers, splitCount)
 }
 
-// WarChestContribution is 3% of total rake.
+// WarChestContribution is the war chest's share of the rake (3% by default).
 func WarChestContribution(rake int64) int64 {
-	return rake * 3 / 100
+	return room.CurrentEconomy().WarChestContribution(rake)
 }
 
 // ShardsForLoser converts entry cost into Blitz Shards for non-placing players.
-// 4th place gets 2x base, 5th gets 1.5x, everyone else gets base.
-// Base ratio scales 40-60% with volatility.
+// By default 4th place gets 2x base, 5th gets 1.5x, everyone else gets base,
+// and the base ratio scales 40-60% with volatility.
 func ShardsForLoser(entryCost int64, volMul float64, placement int) int64 {
-	ratio := 0.4 + 0.2*math.Min(1, (volMul-1)/4)
-	base := int64(float64(entryCost) * ratio)
-	switch placement {
-	case 4:
-		return base * 2
-	case 5:
-		return base * 3 / 2
-	default:
-		return base
-	}
+	return room.CurrentEconomy().ShardsForLoser(entryCost, volMul, placement)
 }
@@ -2,7 +2,6 @@ package game
 
 import (
 	"fmt"
-	"sort"
 	"time"
 
 	"github.com/lastclick/lastclick/internal/room"
@@ -22,10 +21,41 @@ type SimConfig struct {
 	// PulseSchedule maps tick number → list of player IDs that pulse at that tick.
 	PulseSchedule map[int][]int64
 
+	// Agents decide tick by tick whether their player pulses, on top of any
+	// scheduled pulses. They are asked in PlayerIDs order, after the
+	// scheduled pulses for the tick.
+	Agents map[int64]SimAgent
+
+	// Ties is the co-survivor tie policy (room.TieHash etc.); "" uses the
+	// live economy's policy for the tier.
+	Ties string
+
+	// PulseMinInterval is the per-player pulse spacing; 0 uses the engine's
+	// DefaultTiming, as the server does without game.pulse_min_interval.
+	PulseMinInterval time.Duration
+
 	MaxTicks   int  // safety cap; 0 defaults to 2400 (10 min at 250ms)
 	SilentMode bool // skip event recording for Monte Carlo perf
 }
 
+// Observation is what an agent sees when asked whether to pulse.
+type Observation struct {
+	Tick        int
+	Timer       time.Duration // global timer before this tick's decrement
+	MarginRatio float64
+	AliveCount  int
+	LastPulse   int           // tick of the player's last accepted pulse; 0 if none yet
+	WindowTicks int           // the player is eliminated once Tick-LastPulse exceeds this
+	MinGapTicks int           // pulses closer together than this are dropped
+	Extension   time.Duration // what a pulse would add to the timer now
+	Decrement   time.Duration // what this tick takes off the timer
+}
+
+// SimAgent is a player strategy that reacts to the game state.
+type SimAgent interface {
+	Pulse(obs Observation) bool
+}
+
 type SimEvent struct {
 	Tick   int
 	Type   string // "elimination", "pulse", "pulse_rejected", "timer_zero", "liquidation", "last_alive", "finish"
@@ -96,7 +126,12 @@ func RunSimulation(cfg SimConfig) SimResult {
 	silent := cfg.SilentMode
 	marginRatio := 0.0
 	volMul := 1.0
-	minPulseGap := int(500*time.Millisecond/simTickRate) + 1
+	pulseMin := cfg.PulseMinInterval
+	if pulseMin <= 0 {
+		pulseMin = DefaultTiming.PulseMinInterval
+	}
+	minPulseGap := int(pulseMin/simTickRate) + 1
+	pulseWindowTicks := int(cfg.Tier.PulseWindow/simTickRate) + LatencyGraceTicks
 
 	result := SimResult{PlayerStats: stats}
 
@@ -116,31 +151,55 @@ func RunSimulation(cfg SimConfig) SimResult {
 		}
 
 		// 2. Process pulses (free — no star cost)
-		if pulses, ok := cfg.PulseSchedule[tick]; ok {
-			for _, pid := range pulses {
-				st := stats[pid]
-				if !st.Alive {
-					continue
-				}
-				if last, ok := lastPulseTick[pid]; ok && (tick-last) < minPulseGap {
-					continue
-				}
+		pulse := func(pid int64) {
+			st := stats[pid]
+			if !st.Alive {
+				return
+			}
+			if last, ok := lastPulseTick[pid]; ok && (tick-last) < minPulseGap {
+				return
+			}
 
-				st.PulseCount++
-				lastPulseTick[pid] = tick
-				lastPulseTickForWindow[pid] = tick
+			st.PulseCount++
+			lastPulseTick[pid] = tick
+			lastPulseTickForWindow[pid] = tick
 
-				ext := PulseExtension(cfg.Tier.BaseExtension, r.AliveCount())
-				r.GlobalTimer += ext
+			ext := PulseExtension(cfg.Tier.BaseExtension, r.AliveCount())
+			r.GlobalTimer += ext
 
-				if !silent {
-					events = append(events, SimEvent{
-						Tick:   tick,
-						Type:   "pulse",
-						Player: pid,
-						Detail: fmt.Sprintf("ext=%dms timer=%dms", ext.Milliseconds(), r.GlobalTimer.Milliseconds()),
-					})
-				}
+			if !silent {
+				events = append(events, SimEvent{
+					Tick:   tick,
+					Type:   "pulse",
+					Player: pid,
+					Detail: fmt.Sprintf("ext=%dms timer=%dms", ext.Milliseconds(), r.GlobalTimer.Milliseconds()),
+				})
+			}
+		}
+		for _, pid := range cfg.PulseSchedule[tick] {
+			pulse(pid)
+		}
+		for _, pid := range cfg.PlayerIDs {
+			if len(cfg.Agents) == 0 {
+				break
+			}
+			agent, ok := cfg.Agents[pid]
+			if !ok || !stats[pid].Alive {
+				continue
+			}
+			obs := Observation{
+				Tick:        tick,
+				Timer:       r.GlobalTimer,
+				MarginRatio: marginRatio,
+				AliveCount:  r.AliveCount(),
+				LastPulse:   lastPulseTickForWindow[pid],
+				WindowTicks: pulseWindowTicks,
+				MinGapTicks: minPulseGap,
+				Extension:   PulseExtension(cfg.Tier.BaseExtension, r.AliveCount()),
+				Decrement:   TickDecrement(simTickRate, marginRatio),
+			}
+			if agent.Pulse(obs) {
+				pulse(pid)
 			}
 		}
 
@@ -152,7 +211,6 @@ func RunSimulation(cfg SimConfig) SimResult {
 		}
 
 		// 4. Pulse window check (with latency grace)
-		pulseWindowTicks := int(cfg.Tier.PulseWindow/simTickRate) + LatencyGraceTicks
 		for _, pid := range cfg.PlayerIDs {
 			st := stats[pid]
 			if !st.Alive {
@@ -205,32 +263,22 @@ func RunSimulation(cfg SimConfig) SimResult {
 		}
 	}
 
-	// Compute placements: alive sorted by pulse count desc, then eliminated in reverse order
-	type pidCount struct {
-		pid   int64
-		count int
+	// Compute placements: co-survivors ranked by the tie policy, then
+	// eliminated in reverse order
+	ties := cfg.Ties
+	if ties == "" {
+		ties = room.CurrentEconomy().TiePolicy("", cfg.Tier.Tier)
 	}
-	var aliveSorted []pidCount
+	var aliveSorted []room.Survivor
 	for _, pid := range cfg.PlayerIDs {
 		if stats[pid].Alive {
-			aliveSorted = append(aliveSorted, pidCount{pid, stats[pid].PulseCount})
+			aliveSorted = append(aliveSorted, room.Survivor{ID: pid, PulseCount: stats[pid].PulseCount})
 		}
 	}
-	// Hash-based deterministic shuffle — latency-neutral co-survivor ranking
-	tickSeed := int64(result.TotalTicks) * 7919
-	mix := func(id int64) int64 {
-		h := id ^ (tickSeed * 2654435761)
-		h ^= h >> 16
-		h *= 0x45d9f3b
-		h ^= h >> 16
-		return h
-	}
-	sort.Slice(aliveSorted, func(i, j int) bool {
-		return mix(aliveSorted[i].pid) < mix(aliveSorted[j].pid)
-	})
+	room.RankSurvivors(ties, aliveSorted, int64(result.TotalTicks)*7919)
 	placements := make([]int64, 0, len(cfg.PlayerIDs))
 	for _, a := range aliveSorted {
-		placements = append(placements, a.pid)
+		placements = append(placements, a.ID)
 	}
 	for i := len(eliminationOrder) - 1; i >= 0; i-- {
 		placements = append(placements, eliminationOrder[i])
@@ -247,7 +295,11 @@ func RunSimulation(cfg SimConfig) SimResult {
 	result.FinalVolMul = volMul
 
 	pool := int64(len(cfg.PlayerIDs)) * cfg.Tier.EntryCost
-	payouts := PlacementPayouts(pool, len(cfg.PlayerIDs))
+	splitCount := 0
+	if ties == room.TieSplit && len(aliveSorted) >= 2 {
+		splitCount = len(aliveSorted)
+	}
+	payouts := PlacementPayouts(pool, len(cfg.PlayerIDs), splitCount)
 	topPlaces := len(payouts)
 
 	for i, pid := range placements {
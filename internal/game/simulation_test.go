@@ -366,7 +366,7 @@ func TestRakeAndPayouts(t *testing.T) {
 	}
 
 	// Top-3 payouts from pool of 100 (12 rake, 88 post-rake)
-	payouts := PlacementPayouts(100, 10)
+	payouts := PlacementPayouts(100, 10, 0)
 	if len(payouts) != 3 {
 		t.Fatalf("expected 3 payouts, got %d", len(payouts))
 	}
@@ -382,7 +382,7 @@ func TestRakeAndPayouts(t *testing.T) {
 	}
 
 	// 2-player room: 75/25 split
-	payouts2 := PlacementPayouts(100, 2)
+	payouts2 := PlacementPayouts(100, 2, 0)
 	if len(payouts2) != 2 {
 		t.Fatalf("expected 2 payouts for 2-player room, got %d", len(payouts2))
 	}
@@ -901,3 +901,93 @@ func TestLatencyNormalization(t *testing.T) {
 		})
 	}
 }
+
+// ---------------------------------------------------------------------------
+// 22. Agents — same decisions as a schedule give the same round
+// ---------------------------------------------------------------------------
+
+type everyNTicks struct {
+	n   int
+	obs []Observation
+}
+
+func (a *everyNTicks) Pulse(obs Observation) bool {
+	a.obs = append(a.obs, obs)
+	return obs.Tick%a.n == 1
+}
+
+func TestAgentsMatchSchedule(t *testing.T) {
+	players := []int64{1, 2, 3}
+	vol := map[int]float64{1: 0.1, 40: 0.4}
+	scheduled := RunSimulation(SimConfig{
+		Tier:      t1,
+		PlayerIDs: players,
+		VolScript: vol,
+		PulseSchedule: mergePulses(
+			playerPulses(1, 1, 5, 9, 13, 17, 21, 25, 29, 33, 37, 41, 45),
+			playerPulses(2, 1, 4, 7, 10),
+		),
+		MaxTicks: 48,
+	})
+
+	agent := &everyNTicks{n: 4}
+	agents := RunSimulation(SimConfig{
+		Tier:          t1,
+		PlayerIDs:     players,
+		VolScript:     vol,
+		PulseSchedule: playerPulses(2, 1, 4, 7, 10),
+		Agents:        map[int64]SimAgent{1: agent},
+		MaxTicks:      48,
+	})
+
+	if agents.TotalTicks != scheduled.TotalTicks || agents.FinalTimer != scheduled.FinalTimer ||
+		agents.FinishReason != scheduled.FinishReason {
+		t.Fatalf("agent run %d ticks/%v/%s, scheduled run %d ticks/%v/%s",
+			agents.TotalTicks, agents.FinalTimer, agents.FinishReason,
+			scheduled.TotalTicks, scheduled.FinalTimer, scheduled.FinishReason)
+	}
+	for _, pid := range players {
+		if a, s := agents.PlayerStats[pid], scheduled.PlayerStats[pid]; a.PulseCount != s.PulseCount || a.EliminatedAt != s.EliminatedAt {
+			t.Errorf("player %d: agent run %+v, scheduled run %+v", pid, *a, *s)
+		}
+	}
+
+	first, second := agent.obs[0], agent.obs[1]
+	if first.LastPulse != 0 || second.LastPulse != 1 {
+		t.Errorf("LastPulse = %d, %d; want 0, 1", first.LastPulse, second.LastPulse)
+	}
+	if first.AliveCount != 3 || first.Extension != PulseExtension(t1.BaseExtension, 3) {
+		t.Errorf("first observation %+v", first)
+	}
+	if want := int(t1.PulseWindow/simTickRate) + LatencyGraceTicks; first.WindowTicks != want {
+		t.Errorf("WindowTicks = %d, want %d", first.WindowTicks, want)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// 23. Pulse spacing follows SimConfig.PulseMinInterval
+// ---------------------------------------------------------------------------
+
+func TestSimPulseMinInterval(t *testing.T) {
+	run := func(minInterval time.Duration) (int, Observation) {
+		agent := &everyNTicks{n: 100}
+		res := RunSimulation(SimConfig{
+			Tier:             t1,
+			PlayerIDs:        []int64{1, 2, 3},
+			VolScript:        map[int]float64{1: 0.1},
+			PulseSchedule:    playerPulses(1, 1, 3, 5, 7),
+			Agents:           map[int64]SimAgent{2: agent},
+			PulseMinInterval: minInterval,
+			MaxTicks:         10,
+		})
+		return res.PlayerStats[1].PulseCount, agent.obs[0]
+	}
+
+	// The default 500ms drops pulses two ticks apart.
+	if n, obs := run(0); n != 2 || obs.MinGapTicks != 3 {
+		t.Errorf("default: %d pulses, MinGapTicks %d; want 2, 3", n, obs.MinGapTicks)
+	}
+	if n, obs := run(250 * time.Millisecond); n != 4 || obs.MinGapTicks != 2 {
+		t.Errorf("250ms: %d pulses, MinGapTicks %d; want 4, 2", n, obs.MinGapTicks)
+	}
+}
@@ -0,0 +1,289 @@
+// Package metrics implements the subset of Prometheus instrumentation the
+// server needs — labelled counters, gauges and histograms — and renders them
+// in the text exposition format (version 0.0.4), without client libraries.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry renders every registered metric family in registration order.
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+}
+
+type family interface {
+	write(w io.Writer)
+}
+
+func NewRegistry() *Registry { return &Registry{} }
+
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	r.families = append(r.families, f)
+	r.mu.Unlock()
+}
+
+// WriteText writes the Prometheus text format.
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.Lock()
+	fams := append([]family(nil), r.families...)
+	r.mu.Unlock()
+	for _, f := range fams {
+		f.write(w)
+	}
+}
+
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, d.kind)
+}
+
+// vec holds one child per distinct label-value tuple.
+type vec[T any] struct {
+	desc
+	mu       sync.RWMutex
+	children map[string]*T
+	values   map[string][]string
+	newChild func() *T
+}
+
+func newVec[T any](d desc, newChild func() *T) *vec[T] {
+	return &vec[T]{desc: d, children: make(map[string]*T), values: make(map[string][]string), newChild: newChild}
+}
+
+func (v *vec[T]) with(lvs []string) *T {
+	if len(lvs) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", v.name, len(v.labels), len(lvs)))
+	}
+	key := strings.Join(lvs, "\xff")
+	v.mu.RLock()
+	c, ok := v.children[key]
+	v.mu.RUnlock()
+	if ok {
+		return c
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok = v.children[key]; ok {
+		return c
+	}
+	c = v.newChild()
+	v.children[key] = c
+	v.values[key] = append([]string(nil), lvs...)
+	return c
+}
+
+// each visits children in a stable order so scrapes diff cleanly.
+func (v *vec[T]) each(fn func(lvs []string, c *T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.children))
+	for k := range v.children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	type pair struct {
+		lvs []string
+		c   *T
+	}
+	pairs := make([]pair, len(keys))
+	for i, k := range keys {
+		pairs[i] = pair{v.values[k], v.children[k]}
+	}
+	v.mu.RUnlock()
+	for _, p := range pairs {
+		fn(p.lvs, p.c)
+	}
+}
+
+// labelString renders {a="x",b="y"} plus any extra pair (used for "le").
+func labelString(names, values []string, extra ...string) string {
+	if len(names) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	n := 0
+	add := func(k, v string) {
+		if n > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(v))
+		b.WriteByte('"')
+		n++
+	}
+	for i, name := range names {
+		add(name, values[i])
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		add(extra[i], extra[i+1])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// --- Counter ---
+
+type Counter struct{ v atomic.Int64 }
+
+func (c *Counter) Inc()         { c.v.Add(1) }
+func (c *Counter) Add(n int64)  { c.v.Add(n) }
+func (c *Counter) Value() int64 { return c.v.Load() }
+
+type CounterVec struct{ *vec[Counter] }
+
+func NewCounterVec(r *Registry, name, help string, labels ...string) *CounterVec {
+	cv := &CounterVec{newVec(desc{name, help, "counter", labels}, func() *Counter { return &Counter{} })}
+	r.register(cv)
+	return cv
+}
+
+func (cv *CounterVec) With(lvs ...string) *Counter { return cv.with(lvs) }
+
+// Each visits every child with its label values.
+func (cv *CounterVec) Each(fn func(lvs []string, c *Counter)) { cv.each(fn) }
+
+func (cv *CounterVec) write(w io.Writer) {
+	cv.header(w)
+	cv.each(func(lvs []string, c *Counter) {
+		fmt.Fprintf(w, "%s%s %d\n", cv.name, labelString(cv.labels, lvs), c.Value())
+	})
+}
+
+// --- Gauge ---
+
+type Gauge struct{ v atomic.Int64 }
+
+func (g *Gauge) Inc()         { g.v.Add(1) }
+func (g *Gauge) Dec()         { g.v.Add(-1) }
+func (g *Gauge) Set(n int64)  { g.v.Store(n) }
+func (g *Gauge) Value() int64 { return g.v.Load() }
+
+type GaugeVec struct{ *vec[Gauge] }
+
+func NewGaugeVec(r *Registry, name, help string, labels ...string) *GaugeVec {
+	gv := &GaugeVec{newVec(desc{name, help, "gauge", labels}, func() *Gauge { return &Gauge{} })}
+	r.register(gv)
+	return gv
+}
+
+func (gv *GaugeVec) With(lvs ...string) *Gauge { return gv.with(lvs) }
+
+// Each visits every child with its label values.
+func (gv *GaugeVec) Each(fn func(lvs []string, g *Gauge)) { gv.each(fn) }
+
+func (gv *GaugeVec) write(w io.Writer) {
+	gv.header(w)
+	gv.each(func(lvs []string, g *Gauge) {
+		fmt.Fprintf(w, "%s%s %d\n", gv.name, labelString(gv.labels, lvs), g.Value())
+	})
+}
+
+// GaugeFunc samples its value at scrape time (goroutines, heap, uptime).
+type GaugeFunc struct {
+	desc
+	fn func() float64
+}
+
+func NewGaugeFunc(r *Registry, name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{desc: desc{name: name, help: help, kind: "gauge"}, fn: fn}
+	r.register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	g.header(w)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.fn()))
+}
+
+// --- Histogram ---
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	mu      sync.Mutex
+	bounds  []float64
+	counts  []uint64 // per bucket, non-cumulative; last is +Inf
+	sum     float64
+	samples uint64
+}
+
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v) // first bound >= v
+	h.mu.Lock()
+	h.counts[i]++
+	h.sum += v
+	h.samples++
+	h.mu.Unlock()
+}
+
+type HistogramVec struct {
+	*vec[Histogram]
+	bounds []float64
+}
+
+// DefaultLatencyBuckets span 1ms to 10s, for durations in seconds.
+var DefaultLatencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+func NewHistogramVec(r *Registry, name, help string, buckets []float64, labels ...string) *HistogramVec {
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	hv := &HistogramVec{bounds: bounds}
+	hv.vec = newVec(desc{name, help, "histogram", labels}, func() *Histogram {
+		return &Histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+	})
+	r.register(hv)
+	return hv
+}
+
+func (hv *HistogramVec) With(lvs ...string) *Histogram { return hv.with(lvs) }
+
+func (hv *HistogramVec) write(w io.Writer) {
+	hv.header(w)
+	hv.each(func(lvs []string, h *Histogram) {
+		h.mu.Lock()
+		counts := append([]uint64(nil), h.counts...)
+		sum, samples := h.sum, h.samples
+		h.mu.Unlock()
+
+		var cum uint64
+		for i, b := range hv.bounds {
+			cum += counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", hv.name, labelString(hv.labels, lvs, "le", formatFloat(b)), cum)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", hv.name, labelString(hv.labels, lvs, "le", "+Inf"), samples)
+		fmt.Fprintf(w, "%s_sum%s %s\n", hv.name, labelString(hv.labels, lvs), formatFloat(sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", hv.name, labelString(hv.labels, lvs), samples)
+	})
+}
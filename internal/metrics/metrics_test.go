@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	reg := NewRegistry()
+	c := NewCounterVec(reg, "test_pulses_total", "Pulses.", "type", "tier")
+	g := NewGaugeVec(reg, "test_conns", "Connections.")
+	h := NewHistogramVec(reg, "test_lag_seconds", "Lag.", []float64{0.1, 1}, "tier")
+
+	c.With("blitz", "1").Add(3)
+	c.With(`we"ird`, "2").Inc()
+	g.With().Set(7)
+	h.With("1").Observe(0.05)
+	h.With("1").Observe(0.5)
+	h.With("1").Observe(5)
+
+	var b strings.Builder
+	reg.WriteText(&b)
+	want := `# HELP test_pulses_total Pulses.
+# TYPE test_pulses_total counter
+test_pulses_total{type="blitz",tier="1"} 3
+test_pulses_total{type="we\"ird",tier="2"} 1
+# HELP test_conns Connections.
+# TYPE test_conns gauge
+test_conns 7
+# HELP test_lag_seconds Lag.
+# TYPE test_lag_seconds histogram
+test_lag_seconds_bucket{tier="1",le="0.1"} 1
+test_lag_seconds_bucket{tier="1",le="1"} 2
+test_lag_seconds_bucket{tier="1",le="+Inf"} 3
+test_lag_seconds_sum{tier="1"} 5.55
+test_lag_seconds_count{tier="1"} 3
+`
+	if b.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
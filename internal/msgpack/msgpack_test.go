@@ -0,0 +1,95 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalKnownBytes(t *testing.T) {
+	cases := []struct {
+		v    any
+		want []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{int64(5), []byte{0x05}},
+		{int64(-1), []byte{0xff}},
+		{int64(200), []byte{0xcc, 0xc8}},
+		{int64(-200), []byte{0xd1, 0xff, 0x38}},
+		{float64(3), []byte{0x03}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"hi", []byte{0xa2, 'h', 'i'}},
+		{[]any{int64(1), "a"}, []byte{0x92, 0x01, 0xa1, 'a'}},
+		{map[string]any{"b": int64(2), "a": int64(1)}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+	}
+	for _, c := range cases {
+		got, err := Marshal(c.v)
+		if err != nil {
+			t.Fatalf("Marshal(%v): %v", c.v, err)
+		}
+		if !bytes.Equal(got, c.want) {
+			t.Errorf("Marshal(%v) = % x, want % x", c.v, got, c.want)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := map[string]any{
+		"type":  "tick",
+		"seq":   int64(math.MaxUint32 + 1),
+		"neg":   int64(math.MinInt32 - 1),
+		"big":   uint64(math.MaxUint64),
+		"ratio": 0.4321,
+		"ok":    false,
+		"none":  nil,
+		"list":  []any{map[string]any{"player_id": int64(7)}, "x"},
+		"long":  string(bytes.Repeat([]byte("z"), 300)),
+	}
+	b, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("round trip:\n got %#v\nwant %#v", out, in)
+	}
+}
+
+func TestJSONNumbers(t *testing.T) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(`{"ms":1700000000000,"r":0.25}`)))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := Unmarshal(b)
+	want := map[string]any{"ms": int64(1700000000000), "r": 0.25}
+	if !reflect.DeepEqual(out, want) {
+		t.Fatalf("got %#v", out)
+	}
+}
+
+func TestUnmarshalRejectsBadInput(t *testing.T) {
+	for _, b := range [][]byte{
+		{},
+		{0xa5, 'a'},                    // short string
+		{0xdd, 0xff, 0xff, 0xff, 0xff}, // huge array, no data
+		{0x81, 0x01, 0x02},             // non-string key
+		{0x01, 0x02},                   // trailing data
+		{0xd4, 0x00, 0x00},             // fixext
+	} {
+		if _, err := Unmarshal(b); err == nil {
+			t.Errorf("Unmarshal(% x) succeeded", b)
+		}
+	}
+}
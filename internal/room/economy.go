@@ -0,0 +1,245 @@
+package room
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// EconomyPolicy holds the money rules of a round. Percentages are whole
+// percent so every amount stays in integer Stars. A round keeps the policy it
+// opened with, and ID and Version are recorded with it, so any change to the
+// rules must come with a new version.
+type EconomyPolicy struct {
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+
+	RakePct     int64 `json:"rake_pct"`      // of the pool
+	WarChestPct int64 `json:"war_chest_pct"` // of the rake
+
+	// Payouts is the post-rake split for the top places. The entry with the
+	// largest MinPlayers not above the round's player count applies.
+	Payouts []PayoutSplit `json:"payouts"`
+
+	// Non-placing players get ShardRatio of the entry cost back as shards;
+	// the ratio moves from Min to Max as volatility rises to its peak.
+	ShardRatioMin float64      `json:"shard_ratio_min"`
+	ShardRatioMax float64      `json:"shard_ratio_max"`
+	ShardBonuses  []ShardBonus `json:"shard_bonuses"`
+
+	// Ties ranks players still alive at the end: TieHash, TieEfficiency or
+	// TieSplit. TiesBy overrides it per room type ("blitz") or tier ("tier2").
+	Ties   string            `json:"ties"`
+	TiesBy map[string]string `json:"ties_by,omitempty"`
+
+	// DustTo names who gets the Stars that integer division leaves over:
+	// DustHouse, DustWinner or DustWarChest (the winner's squad).
+	DustTo string `json:"dust_to"`
+
+	// PulseCost is Stars charged per pulse. Pulses are free today, so only 0
+	// is accepted; the field is here so players see it stated.
+	PulseCost int64 `json:"pulse_cost"`
+}
+
+// Destinations for division remainders.
+const (
+	DustHouse    = "house"
+	DustWinner   = "winner"
+	DustWarChest = "war_chest"
+)
+
+// PayoutSplit gives each top place's share of the post-rake pool in percent.
+type PayoutSplit struct {
+	MinPlayers int     `json:"min_players"`
+	SharesPct  []int64 `json:"shares_pct"`
+}
+
+// ShardBonus scales a place's shards, in percent of the base amount.
+type ShardBonus struct {
+	Place int   `json:"place"`
+	Pct   int64 `json:"pct"`
+}
+
+// PlacementPayout describes a single placement's star reward.
+type PlacementPayout struct {
+	Place  int
+	Amount int64
+}
+
+// EconomyV1 returns the original policy: 12% rake, 3% of it to the war chest,
+// 60/25/15 for three or more players, 4th and 5th earning extra shards,
+// co-survivors ordered by hash, remainders kept by the house.
+// Rounds recorded before policies were stored were all played under it, so
+// it must never change; each call returns fresh slices so no caller can edit
+// it in place.
+func EconomyV1() EconomyPolicy {
+	return EconomyPolicy{
+		ID:          "standard",
+		Version:     1,
+		RakePct:     12,
+		WarChestPct: 3,
+		Payouts: []PayoutSplit{
+			{MinPlayers: 1, SharesPct: []int64{100}},
+			{MinPlayers: 2, SharesPct: []int64{75, 25}},
+			{MinPlayers: 3, SharesPct: []int64{60, 25, 15}},
+		},
+		ShardRatioMin: 0.4,
+		ShardRatioMax: 0.6,
+		ShardBonuses:  []ShardBonus{{Place: 4, Pct: 200}, {Place: 5, Pct: 150}},
+		Ties:          TieHash,
+		DustTo:        DustHouse,
+	}
+}
+
+// DefaultEconomy returns the policy used unless the config file sets one.
+func DefaultEconomy() EconomyPolicy { return EconomyV1() }
+
+var (
+	liveEconomyMu sync.RWMutex
+	liveEconomy   = DefaultEconomy()
+)
+
+// CurrentEconomy returns the live economy policy.
+func CurrentEconomy() EconomyPolicy {
+	liveEconomyMu.RLock()
+	defer liveEconomyMu.RUnlock()
+	return liveEconomy
+}
+
+// ReplaceEconomy swaps the live economy policy, e.g. from the config file at
+// startup. The policy must already be valid.
+func ReplaceEconomy(p EconomyPolicy) {
+	liveEconomyMu.Lock()
+	liveEconomy = p
+	liveEconomyMu.Unlock()
+}
+
+// Ref names the policy in logs, e.g. "standard@v1".
+func (p EconomyPolicy) Ref() string {
+	return fmt.Sprintf("%s@v%d", p.ID, p.Version)
+}
+
+// DustDestination returns DustTo, reading unset as DustHouse: snapshots taken
+// before the field existed kept their remainders with the house.
+func (p EconomyPolicy) DustDestination() string {
+	if p.DustTo == "" {
+		return DustHouse
+	}
+	return p.DustTo
+}
+
+// RakeAmount is the house's cut of the pool.
+func (p EconomyPolicy) RakeAmount(pool int64) int64 {
+	return pool * p.RakePct / 100
+}
+
+// WarChestContribution is the part of the rake funnelled to squad war chests.
+func (p EconomyPolicy) WarChestContribution(rake int64) int64 {
+	return rake * p.WarChestPct / 100
+}
+
+// Split returns the payout split for a round with numPlayers players.
+func (p EconomyPolicy) Split(numPlayers int) []int64 {
+	var shares []int64
+	for _, s := range p.Payouts {
+		if s.MinPlayers <= max(numPlayers, 1) {
+			shares = s.SharesPct
+		}
+	}
+	return shares
+}
+
+// PlacementPayouts returns the top-place payouts from the post-rake pool.
+// With splitCount of 2 or more, that many tied top places share the combined
+// payout of the places they occupy equally; see SplitCount.
+func (p EconomyPolicy) PlacementPayouts(pool int64, numPlayers, splitCount int) []PlacementPayout {
+	postRake := pool - p.RakeAmount(pool)
+	shares := p.Split(numPlayers)
+	out := make([]PlacementPayout, max(len(shares), splitCount))
+	for i := range out {
+		out[i].Place = i + 1
+		if i < len(shares) {
+			out[i].Amount = postRake * shares[i] / 100
+		}
+	}
+	if splitCount >= 2 {
+		var combined int64
+		for _, pp := range out[:splitCount] {
+			combined += pp.Amount
+		}
+		for i := range out[:splitCount] {
+			out[i].Amount = combined / int64(splitCount)
+		}
+	}
+	return out
+}
+
+// ShardsForLoser converts entry cost into Blitz Shards for a non-placing
+// player. volMul runs from 1 (calm) to 5 (at liquidation).
+func (p EconomyPolicy) ShardsForLoser(entryCost int64, volMul float64, placement int) int64 {
+	ratio := p.ShardRatioMin + (p.ShardRatioMax-p.ShardRatioMin)*math.Min(1, (volMul-1)/4)
+	base := int64(float64(entryCost) * ratio)
+	for _, b := range p.ShardBonuses {
+		if b.Place == placement {
+			return base * b.Pct / 100
+		}
+	}
+	return base
+}
+
+// Validate reports the first rule that would make payouts inconsistent.
+func (p EconomyPolicy) Validate() error {
+	switch {
+	case p.ID == "":
+		return fmt.Errorf("id is required so rounds can record which rules they used")
+	case p.Version < 1:
+		return fmt.Errorf("version must be at least 1, got %d", p.Version)
+	case p.PulseCost != 0:
+		return fmt.Errorf("pulse_cost must be 0; charging for pulses is not supported")
+	case p.DustTo != "" && p.DustTo != DustHouse && p.DustTo != DustWinner && p.DustTo != DustWarChest:
+		return fmt.Errorf("dust_to: want house, winner or war_chest, got %q", p.DustTo)
+	case p.RakePct < 0 || p.RakePct > 100:
+		return fmt.Errorf("rake_pct must be between 0 and 100, got %d", p.RakePct)
+	case p.WarChestPct < 0 || p.WarChestPct > 100:
+		return fmt.Errorf("war_chest_pct must be between 0 and 100, got %d", p.WarChestPct)
+	case len(p.Payouts) == 0 || p.Payouts[0].MinPlayers != 1:
+		return fmt.Errorf("payouts must start with a split for min_players 1")
+	case p.ShardRatioMin < 0 || p.ShardRatioMax > 1 || p.ShardRatioMin > p.ShardRatioMax:
+		return fmt.Errorf("need 0 <= shard_ratio_min <= shard_ratio_max <= 1")
+	}
+	for i, s := range p.Payouts {
+		if i > 0 && s.MinPlayers <= p.Payouts[i-1].MinPlayers {
+			return fmt.Errorf("payouts: min_players must increase, %d follows %d", s.MinPlayers, p.Payouts[i-1].MinPlayers)
+		}
+		if len(s.SharesPct) == 0 || len(s.SharesPct) > s.MinPlayers {
+			return fmt.Errorf("payouts for %d players: need 1 to %d shares, got %d", s.MinPlayers, s.MinPlayers, len(s.SharesPct))
+		}
+		var sum int64
+		for _, pct := range s.SharesPct {
+			if pct <= 0 {
+				return fmt.Errorf("payouts for %d players: shares must be positive", s.MinPlayers)
+			}
+			sum += pct
+		}
+		if sum != 100 {
+			return fmt.Errorf("payouts for %d players: shares add up to %d%%, want 100%%", s.MinPlayers, sum)
+		}
+	}
+	if p.Ties != "" && !validTiePolicy(p.Ties) {
+		return fmt.Errorf("ties: want hash, efficiency or split, got %q", p.Ties)
+	}
+	for k, t := range p.TiesBy {
+		if !validTieKey(k) {
+			return fmt.Errorf("ties_by: %q is not a room type or tierN", k)
+		}
+		if !validTiePolicy(t) {
+			return fmt.Errorf("ties_by %s: want hash, efficiency or split, got %q", k, t)
+		}
+	}
+	for _, b := range p.ShardBonuses {
+		if b.Place < 1 || b.Pct <= 0 {
+			return fmt.Errorf("shard_bonuses: place and pct must be positive")
+		}
+	}
+	return nil
+}
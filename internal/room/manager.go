@@ -1,12 +1,21 @@
 package room
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrRoomNotFound  = errors.New("room not found")
+	ErrRoundFinished = errors.New("round already finished")
+	ErrRoomFull      = errors.New("room is full")
+	ErrJoinClosed    = errors.New("room is not accepting players")
+	ErrAlreadyJoined = errors.New("already in this room")
+)
+
 // Manager handles room lifecycle — creation, lookup, cleanup.
 type Manager struct {
 	mu    sync.RWMutex
@@ -20,7 +29,7 @@ func NewManager() *Manager {
 }
 
 func (m *Manager) Create(roomType RoomType, tier int) (*Room, error) {
-	tc, ok := Tiers[tier]
+	tc, ok := TierByID(tier)
 	if !ok {
 		return nil, fmt.Errorf("unknown tier: %d", tier)
 	}
@@ -59,6 +68,17 @@ func (m *Manager) ListByState(state RoomState) []*Room {
 	return out
 }
 
+// All returns every room regardless of state.
+func (m *Manager) All() []*Room {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*Room, 0, len(m.rooms))
+	for _, r := range m.rooms {
+		out = append(out, r)
+	}
+	return out
+}
+
 func (m *Manager) Count() int {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
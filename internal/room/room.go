@@ -1,9 +1,10 @@
 package room
 
 import (
-	"sort"
 	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // Room holds the full mutable state for a single game room.
@@ -11,6 +12,7 @@ type Room struct {
 	mu sync.RWMutex
 
 	ID        string
+	RoundID   string // unique per round; ID is reused across rounds by ResetRound
 	Type      RoomType
 	Tier      TierConfig
 	State     RoomState
@@ -20,6 +22,7 @@ type Room struct {
 	CreatedAt time.Time
 	StartedAt *time.Time
 	EndedAt   *time.Time
+	Economy   EconomyPolicy // fixed when the round opens; the fees shown before joining are the ones applied
 
 	EliminationOrder []int64
 
@@ -32,6 +35,7 @@ type Room struct {
 func NewRoom(id string, roomType RoomType, tier TierConfig) *Room {
 	return &Room{
 		ID:            id,
+		RoundID:       uuid.New().String(),
 		Type:          roomType,
 		Tier:          tier,
 		State:         StateWaiting,
@@ -40,22 +44,23 @@ func NewRoom(id string, roomType RoomType, tier TierConfig) *Room {
 		GlobalTimer:   tier.SurvivalTime,
 		MarginRatio:   0,
 		VolatilityMul: 1.0,
+		Economy:       CurrentEconomy(),
 	}
 }
 
 // AddPlayer adds a player only when join is allowed: WAITING or COUNTDOWN (active).
 // SURVIVAL and FINISHED are locked so no midgame or post-round join.
-func (r *Room) AddPlayer(id int64, username string) bool {
+func (r *Room) AddPlayer(id int64, username string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if r.State == StateSurvival || r.State == StateFinished {
-		return false
-	}
-	if len(r.Players) >= r.Tier.MaxPlayers {
-		return false
+		return ErrJoinClosed
 	}
 	if _, exists := r.Players[id]; exists {
-		return false
+		return ErrAlreadyJoined
+	}
+	if len(r.Players) >= r.Tier.MaxPlayers {
+		return ErrRoomFull
 	}
 	r.Players[id] = &PlayerState{
 		ID:       id,
@@ -64,7 +69,7 @@ func (r *Room) AddPlayer(id int64, username string) bool {
 		JoinedAt: time.Now(),
 	}
 	r.Pool += r.Tier.EntryCost
-	return true
+	return nil
 }
 
 // RemovePlayer removes a player from the room. Refund=true deducts entry from pool (use when leaving during WAITING).
@@ -86,6 +91,22 @@ func (r *Room) RemovePlayer(id int64, refund bool) bool {
 	return true
 }
 
+// IsAlive reports whether the player is in the room and not eliminated.
+func (r *Room) IsAlive(id int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.Players[id]
+	return ok && p.Alive
+}
+
+// HasPlayer reports whether the player is in the room, alive or not.
+func (r *Room) HasPlayer(id int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.Players[id]
+	return ok
+}
+
 func (r *Room) AliveCount() int {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -110,6 +131,17 @@ func (r *Room) AlivePlayers() []*PlayerState {
 	return out
 }
 
+// AllPlayers returns every player in the room, alive or not.
+func (r *Room) AllPlayers() []*PlayerState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*PlayerState, 0, len(r.Players))
+	for _, p := range r.Players {
+		out = append(out, p)
+	}
+	return out
+}
+
 func (r *Room) Eliminate(id int64) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -176,35 +208,36 @@ func (r *Room) RecordPulse(id int64) (bool, time.Time) {
 	return true, now
 }
 
-// Placements returns player IDs ordered by finishing position.
-// Alive players first (co-survivors ranked by efficiency desc, then ID asc —
-// latency-neutral since efficiency is identical for co-survivors in the same room),
-// then eliminated players in reverse elimination order (last eliminated = best).
+// PulseDeadlines returns, for each alive player, when their pulse window plus
+// grace runs out on the server clock. Pass the same grace elimination allows.
+// Only meaningful during survival.
+func (r *Room) PulseDeadlines(grace time.Duration) map[int64]time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[int64]time.Time, len(r.Players))
+	for _, p := range r.Players {
+		if p.Alive && !p.LastPulseAt.IsZero() {
+			out[p.ID] = p.LastPulseAt.Add(r.Tier.PulseWindow + grace)
+		}
+	}
+	return out
+}
+
+// Placements returns player IDs ordered by finishing position: co-survivors
+// first, ranked by the round's tie policy, then eliminated players in reverse
+// elimination order (last eliminated = best).
 func (r *Room) Placements() []int64 {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	var alive []*PlayerState
+	var alive []Survivor
 	for _, p := range r.Players {
 		if p.Alive {
-			alive = append(alive, p)
+			alive = append(alive, Survivor{ID: p.ID, PulseCount: p.PulseCount})
 		}
 	}
-	// Hash-based deterministic shuffle — breaks ID correlation so co-survivors
-	// are ranked fairly regardless of ID assignment.
-	roomSeed := int64(0)
-	for _, c := range r.ID {
-		roomSeed = roomSeed*31 + int64(c)
-	}
-	mix := func(id int64) int64 {
-		h := id ^ (roomSeed * 2654435761)
-		h ^= h >> 16
-		h *= 0x45d9f3b
-		h ^= h >> 16
-		return h
-	}
-	sort.Slice(alive, func(i, j int) bool {
-		return mix(alive[i].ID) < mix(alive[j].ID)
-	})
+	// Seeded by the round ID, which ResetRound renews, so the same players
+	// tied in the same room do not always resolve the same way.
+	RankSurvivors(r.Economy.TiePolicy(r.Type, r.Tier.Tier), alive, RoundSeed(r.RoundID))
 	result := make([]int64, 0, len(r.Players))
 	for _, p := range alive {
 		result = append(result, p.ID)
@@ -236,6 +269,11 @@ func (r *Room) ResetRound() bool {
 		return false
 	}
 	r.State = StateWaiting
+	if tc, ok := TierByID(r.Tier.Tier); ok {
+		r.Tier = tc // pick up tier reloads
+	}
+	r.Economy = CurrentEconomy()
+	r.RoundID = uuid.New().String()
 	r.Pool = 0
 	r.WinnerID = 0
 	r.StartedAt = nil
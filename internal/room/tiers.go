@@ -0,0 +1,106 @@
+package room
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	liveTiersMu sync.RWMutex
+	liveTiers   = Tiers
+)
+
+// TierByID returns the live config for a tier.
+func TierByID(tier int) (TierConfig, bool) {
+	liveTiersMu.RLock()
+	defer liveTiersMu.RUnlock()
+	tc, ok := liveTiers[tier]
+	return tc, ok
+}
+
+// LiveTiers returns the live tier set ordered by tier number.
+func LiveTiers() []TierConfig {
+	liveTiersMu.RLock()
+	defer liveTiersMu.RUnlock()
+	out := make([]TierConfig, 0, len(liveTiers))
+	for _, tc := range liveTiers {
+		out = append(out, tc)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Tier < out[j].Tier })
+	return out
+}
+
+// ReplaceTiers swaps the live tier set. Rooms already created keep the config
+// they were created with; new rooms and rounds after a reset use the new one.
+func ReplaceTiers(tiers map[int]TierConfig) {
+	liveTiersMu.Lock()
+	liveTiers = tiers
+	liveTiersMu.Unlock()
+}
+
+type tierFileEntry struct {
+	Tier            int     `json:"tier"`
+	EntryCost       int64   `json:"entry_cost"`
+	MinPlayers      int     `json:"min_players"`
+	MaxPlayers      int     `json:"max_players"`
+	PulseWindowMs   int64   `json:"pulse_window_ms"`
+	BaseExtensionMs int64   `json:"base_extension_ms"`
+	SurvivalTimeSec int64   `json:"survival_time_sec"`
+	PrestigeMult    float64 `json:"prestige_mult"`
+}
+
+// LoadTiersFile reads and validates a JSON array of tier configs.
+func LoadTiersFile(path string) (map[int]TierConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tiers: %w", err)
+	}
+	var entries []tierFileEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("decode tiers: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no tiers defined")
+	}
+	out := make(map[int]TierConfig, len(entries))
+	for _, e := range entries {
+		tc := TierConfig{
+			Tier:          e.Tier,
+			EntryCost:     e.EntryCost,
+			MinPlayers:    e.MinPlayers,
+			MaxPlayers:    e.MaxPlayers,
+			PulseWindow:   time.Duration(e.PulseWindowMs) * time.Millisecond,
+			BaseExtension: time.Duration(e.BaseExtensionMs) * time.Millisecond,
+			SurvivalTime:  time.Duration(e.SurvivalTimeSec) * time.Second,
+			PrestigeMult:  e.PrestigeMult,
+		}
+		if err := tc.Validate(); err != nil {
+			return nil, fmt.Errorf("tier %d: %w", e.Tier, err)
+		}
+		if _, dup := out[tc.Tier]; dup {
+			return nil, fmt.Errorf("tier %d defined twice", tc.Tier)
+		}
+		out[tc.Tier] = tc
+	}
+	return out, nil
+}
+
+func (tc TierConfig) Validate() error {
+	switch {
+	case tc.Tier <= 0:
+		return fmt.Errorf("tier must be positive")
+	case tc.EntryCost <= 0:
+		return fmt.Errorf("entry_cost must be positive")
+	case tc.MinPlayers < 2 || tc.MaxPlayers < tc.MinPlayers:
+		return fmt.Errorf("need 2 <= min_players <= max_players")
+	case tc.PulseWindow <= 0 || tc.BaseExtension <= 0 || tc.SurvivalTime <= 0:
+		return fmt.Errorf("durations must be positive")
+	case tc.PrestigeMult <= 0:
+		return fmt.Errorf("prestige_mult must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package room
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Tie policies rank the players still alive when a round ends. Co-survivors
+// share survival time and entry cost, so their efficiency is identical.
+const (
+	TieHash       = "hash"       // order by a per-round hash of the ID
+	TieEfficiency = "efficiency" // fewest pulses first, then hash
+	TieSplit      = "split"      // hash order, but the top places' payouts are shared equally
+)
+
+// Survivor is what a tie policy may look at.
+type Survivor struct {
+	ID         int64
+	PulseCount int
+}
+
+// RankSurvivors orders co-survivors in place. Callers pass a seed that
+// differs per round (see RoundSeed) so the hash order varies and no ID is
+// favoured.
+func RankSurvivors(policy string, s []Survivor, seed int64) {
+	mix := func(id int64) int64 {
+		h := id ^ (seed * 2654435761)
+		h ^= h >> 16
+		h *= 0x45d9f3b
+		h ^= h >> 16
+		return h
+	}
+	sort.Slice(s, func(i, j int) bool {
+		if policy == TieEfficiency && s[i].PulseCount != s[j].PulseCount {
+			return s[i].PulseCount < s[j].PulseCount
+		}
+		return mix(s[i].ID) < mix(s[j].ID)
+	})
+}
+
+// RoundSeed derives a RankSurvivors seed from a round ID.
+func RoundSeed(roundID string) int64 {
+	var seed int64
+	for _, c := range roundID {
+		seed = seed*31 + int64(c)
+	}
+	return seed
+}
+
+// TiePolicy returns the policy for a room: a "tierN" entry in TiesBy first,
+// then the room type's entry, then Ties. Unset means TieHash.
+func (p EconomyPolicy) TiePolicy(rt RoomType, tier int) string {
+	if t, ok := p.TiesBy[fmt.Sprintf("tier%d", tier)]; ok {
+		return t
+	}
+	if t, ok := p.TiesBy[string(rt)]; ok {
+		return t
+	}
+	if p.Ties == "" {
+		return TieHash
+	}
+	return p.Ties
+}
+
+// SplitCount is how many top places share their payouts in a round that
+// ended with coSurvivors alive; 0 unless the room's policy is TieSplit.
+func (p EconomyPolicy) SplitCount(rt RoomType, tier, coSurvivors int) int {
+	if coSurvivors < 2 || p.TiePolicy(rt, tier) != TieSplit {
+		return 0
+	}
+	return coSurvivors
+}
+
+func validTiePolicy(t string) bool {
+	return t == TieHash || t == TieEfficiency || t == TieSplit
+}
+
+func validTieKey(k string) bool {
+	var n int
+	if _, err := fmt.Sscanf(k, "tier%d", &n); err == nil && n > 0 && k == fmt.Sprintf("tier%d", n) {
+		return true
+	}
+	return k == string(RoomAlpha) || k == string(RoomBlitz)
+}
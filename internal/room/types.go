@@ -44,7 +44,9 @@ type TierConfig struct {
 	PrestigeMult  float64
 }
 
-// Survival cap 90–110s: high energy, ~mean session, avoid endurance fatigue
+// Survival cap 90–110s: high energy, ~mean session, avoid endurance fatigue.
+// These are the built-in defaults; rooms are created from the live set, which
+// ReplaceTiers can swap at runtime.
 var Tiers = map[int]TierConfig{
 	1: {
 		Tier:          1,
@@ -0,0 +1,120 @@
+// Package sanction decides what a sanctioned player may still do. Sanctions
+// are read through a short-lived cache so enforcement can sit on hot paths
+// like pulses.
+package sanction
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lastclick/lastclick/internal/store"
+)
+
+// Action is something a player attempts that a sanction can block.
+type Action int
+
+const (
+	Connect Action = iota // open a WebSocket or use the authenticated API
+	Join                  // enter a room, paying its entry
+	Pulse                 // pulse during survival
+	Economy               // any balance movement
+)
+
+var (
+	ErrBanned    = errors.New("account banned")
+	ErrSuspended = errors.New("account suspended")
+	ErrMuted     = errors.New("pulsing restricted")
+	ErrFrozen    = errors.New("economy frozen")
+)
+
+const cacheTTL = 30 * time.Second
+
+type entry struct {
+	sanctions []store.Sanction
+	loadedAt  time.Time
+}
+
+// Checker answers "may this player do X?" from cached active sanctions.
+type Checker struct {
+	store  *store.SanctionStore
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	cache map[int64]entry
+}
+
+func NewChecker(st *store.SanctionStore, logger *slog.Logger) *Checker {
+	return &Checker{store: st, logger: logger, cache: make(map[int64]entry)}
+}
+
+// Check returns nil when the action is allowed, otherwise the blocking error.
+// A lookup failure allows the action: a database hiccup must not lock every
+// player out.
+func (c *Checker) Check(ctx context.Context, playerID int64, a Action) error {
+	if c == nil {
+		return nil
+	}
+	sanctions, err := c.active(ctx, playerID)
+	if err != nil {
+		c.logger.Error("load sanctions", "player", playerID, "err", err)
+		return nil
+	}
+	return Decide(sanctions, a, time.Now())
+}
+
+// Decide applies the sanction rules: ban and suspension block everything,
+// mute blocks pulses only, economy freeze blocks play (entry fees) and
+// balance changes.
+func Decide(sanctions []store.Sanction, a Action, now time.Time) error {
+	for i := range sanctions {
+		sn := &sanctions[i]
+		if !sn.ActiveAt(now) {
+			continue
+		}
+		switch sn.Kind {
+		case store.SanctionBan:
+			return ErrBanned
+		case store.SanctionSuspension:
+			return ErrSuspended
+		case store.SanctionMute:
+			if a == Pulse {
+				return ErrMuted
+			}
+		case store.SanctionEconomyFreeze:
+			if a == Join || a == Pulse || a == Economy {
+				return ErrFrozen
+			}
+		}
+	}
+	return nil
+}
+
+// Invalidate drops the cached sanctions so the next check reloads them.
+func (c *Checker) Invalidate(playerID int64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	delete(c.cache, playerID)
+	c.mu.Unlock()
+}
+
+func (c *Checker) active(ctx context.Context, playerID int64) ([]store.Sanction, error) {
+	c.mu.Lock()
+	e, ok := c.cache[playerID]
+	c.mu.Unlock()
+	if ok && time.Since(e.loadedAt) < cacheTTL {
+		return e.sanctions, nil
+	}
+	sanctions, err := c.store.Active(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.cache[playerID] = entry{sanctions: sanctions, loadedAt: time.Now()}
+	c.mu.Unlock()
+	return sanctions, nil
+}
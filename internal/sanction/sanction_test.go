@@ -0,0 +1,41 @@
+package sanction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lastclick/lastclick/internal/store"
+)
+
+func TestDecide(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	cases := []struct {
+		name  string
+		sn    store.Sanction
+		block map[Action]error
+	}{
+		{"ban", store.Sanction{Kind: store.SanctionBan},
+			map[Action]error{Connect: ErrBanned, Join: ErrBanned, Pulse: ErrBanned, Economy: ErrBanned}},
+		{"suspension", store.Sanction{Kind: store.SanctionSuspension, ExpiresAt: &future},
+			map[Action]error{Connect: ErrSuspended, Join: ErrSuspended, Pulse: ErrSuspended, Economy: ErrSuspended}},
+		{"expired suspension", store.Sanction{Kind: store.SanctionSuspension, ExpiresAt: &past},
+			map[Action]error{}},
+		{"lifted ban", store.Sanction{Kind: store.SanctionBan, LiftedAt: &past},
+			map[Action]error{}},
+		{"mute", store.Sanction{Kind: store.SanctionMute},
+			map[Action]error{Pulse: ErrMuted}},
+		{"economy freeze", store.Sanction{Kind: store.SanctionEconomyFreeze},
+			map[Action]error{Join: ErrFrozen, Pulse: ErrFrozen, Economy: ErrFrozen}},
+	}
+	for _, tc := range cases {
+		for _, a := range []Action{Connect, Join, Pulse, Economy} {
+			got := Decide([]store.Sanction{tc.sn}, a, now)
+			if got != tc.block[a] {
+				t.Errorf("%s action %d: got %v, want %v", tc.name, a, got, tc.block[a])
+			}
+		}
+	}
+}
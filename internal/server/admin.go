@@ -0,0 +1,588 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lastclick/lastclick/internal/room"
+	"github.com/lastclick/lastclick/internal/store"
+)
+
+// RoomController is the engine surface the admin API needs. It is an
+// interface because the game package imports server.
+type RoomController interface {
+	VoidRoom(ctx context.Context, roomID, reason string) error
+}
+
+// requireAdmin guards operator endpoints with a per-operator bearer token from
+// ADMIN_TOKENS. Player credentials are never accepted here; with no tokens
+// configured the admin API is disabled.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		actor := ""
+		if strings.EqualFold(scheme, "bearer") {
+			for t, a := range s.cfg.AdminTokens {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+					actor = a
+				}
+			}
+		}
+		if actor == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), adminActorKey, actor)))
+	}
+}
+
+func adminActor(ctx context.Context) string {
+	a, _ := ctx.Value(adminActorKey).(string)
+	return a
+}
+
+// AuditLog stores admin actions; *store.AuditStore in production.
+type AuditLog interface {
+	Record(ctx context.Context, e store.AuditEntry) error
+	List(ctx context.Context, f store.AuditFilter) ([]store.AuditEntry, error)
+}
+
+// audit appends an entry for an action that has already happened outside a
+// database transaction. The action cannot be undone, so callers answer 500
+// when this fails: the operator must not take an unaudited action as done.
+func (s *Server) audit(ctx context.Context, action, target, reason string, before, after any) error {
+	if s.auditLog == nil {
+		return nil
+	}
+	e := store.AuditEntry{Actor: adminActor(ctx), Action: action, Target: target, Reason: reason}
+	if before != nil {
+		e.Before, _ = json.Marshal(before)
+	}
+	if after != nil {
+		e.After, _ = json.Marshal(after)
+	}
+	if err := s.auditLog.Record(ctx, e); err != nil {
+		s.logger.Error("audit write failed", "action", action, "target", target, "actor", e.Actor, "err", err)
+		return err
+	}
+	return nil
+}
+
+// decodeAdminBody decodes a JSON body whose "reason" field must be non-empty.
+func decodeAdminBody(r *http.Request, v any, reason *string) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return fmt.Errorf("bad request")
+	}
+	if strings.TrimSpace(*reason) == "" {
+		return fmt.Errorf("reason is required")
+	}
+	return nil
+}
+
+type sanctionResponse struct {
+	ID        int64              `json:"id"`
+	PlayerID  int64              `json:"player_id"`
+	Kind      store.SanctionKind `json:"kind"`
+	Reason    string             `json:"reason"`
+	IssuedBy  string             `json:"issued_by"`
+	ExpiresAt *time.Time         `json:"expires_at,omitempty"`
+	LiftedAt  *time.Time         `json:"lifted_at,omitempty"`
+	LiftedBy  string             `json:"lifted_by,omitempty"`
+	Active    bool               `json:"active"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+func newSanctionResponse(sn *store.Sanction) sanctionResponse {
+	return sanctionResponse{
+		ID:        sn.ID,
+		PlayerID:  sn.PlayerID,
+		Kind:      sn.Kind,
+		Reason:    sn.Reason,
+		IssuedBy:  sn.IssuedBy,
+		ExpiresAt: sn.ExpiresAt,
+		LiftedAt:  sn.LiftedAt,
+		LiftedBy:  sn.LiftedBy,
+		Active:    sn.ActiveAt(time.Now()),
+		CreatedAt: sn.CreatedAt,
+	}
+}
+
+// handleCreateSanction applies a sanction. Body:
+//
+//	{"kind": "ban|suspension|mute|economy_freeze", "reason": "...", "duration_sec": 86400}
+//
+// duration_sec is required for suspensions and optional otherwise (0 = until
+// lifted). Bans and suspensions revoke sessions and drop live sockets at once.
+func (s *Server) handleCreateSanction(w http.ResponseWriter, r *http.Request) {
+	if s.sanctions == nil {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	pid, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "bad player id", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Kind        store.SanctionKind `json:"kind"`
+		Reason      string             `json:"reason"`
+		DurationSec int64              `json:"duration_sec"`
+	}
+	if err := decodeAdminBody(r, &req, &req.Reason); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !req.Kind.Valid() || req.DurationSec < 0 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if req.Kind == store.SanctionSuspension && req.DurationSec == 0 {
+		http.Error(w, "suspension needs duration_sec", http.StatusBadRequest)
+		return
+	}
+	sn := store.Sanction{PlayerID: pid, Kind: req.Kind, Reason: req.Reason, IssuedBy: adminActor(r.Context())}
+	if req.DurationSec > 0 {
+		exp := time.Now().Add(time.Duration(req.DurationSec) * time.Second)
+		sn.ExpiresAt = &exp
+	}
+	created, err := s.sanctions.Create(r.Context(), sn)
+	if err != nil {
+		s.logger.Error("create sanction", "player", pid, "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.sanctionChecker.Invalidate(pid)
+	resp := newSanctionResponse(created)
+	if created.Kind == store.SanctionBan || created.Kind == store.SanctionSuspension {
+		s.disconnectPlayer(r.Context(), pid, string(created.Kind))
+	}
+	if err := s.audit(r.Context(), "sanction.create", playerTarget(pid), req.Reason, nil, resp); err != nil {
+		http.Error(w, "action applied but audit write failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleListSanctions(w http.ResponseWriter, r *http.Request) {
+	if s.sanctions == nil {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	pid, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "bad player id", http.StatusBadRequest)
+		return
+	}
+	list, err := s.sanctions.ForPlayer(r.Context(), pid)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	out := make([]sanctionResponse, 0, len(list))
+	for i := range list {
+		out = append(out, newSanctionResponse(&list[i]))
+	}
+	writeJSON(w, out)
+}
+
+// handleLiftSanction ends a sanction early. Body: {"reason": "..."}.
+func (s *Server) handleLiftSanction(w http.ResponseWriter, r *http.Request) {
+	if s.sanctions == nil {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "bad sanction id", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := decodeAdminBody(r, &req, &req.Reason); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	lifted, err := s.sanctions.Lift(r.Context(), id, adminActor(r.Context()))
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if lifted == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	s.sanctionChecker.Invalidate(lifted.PlayerID)
+	resp := newSanctionResponse(lifted)
+	if err := s.audit(r.Context(), "sanction.lift", playerTarget(lifted.PlayerID), req.Reason,
+		map[string]any{"id": lifted.ID, "kind": lifted.Kind, "active": true}, resp); err != nil {
+		http.Error(w, "action applied but audit write failed", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+// disconnectPlayer revokes every session and closes the live socket.
+func (s *Server) disconnectPlayer(ctx context.Context, playerID int64, reason string) {
+	if s.sessions != nil {
+		if err := s.sessions.RevokePlayer(ctx, playerID); err != nil {
+			s.logger.Error("revoke sessions", "player", playerID, "err", err)
+		}
+	}
+	if s.hub != nil {
+		s.hub.Kick(playerID, reason)
+	}
+}
+
+func playerTarget(id int64) string { return "player:" + strconv.FormatInt(id, 10) }
+
+// --- Connections ---
+
+// handleAdminConnections lists live WebSocket clients with their outbound
+// queue stats, to spot players who are lagging.
+func (s *Server) handleAdminConnections(w http.ResponseWriter, r *http.Request) {
+	if s.hub == nil {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, s.hub.Connections())
+}
+
+// --- Rooms ---
+
+type adminRoomPlayer struct {
+	ID           int64          `json:"id"`
+	Username     string         `json:"username"`
+	Alive        bool           `json:"alive"`
+	Disconnected bool           `json:"disconnected"`
+	PulseCount   int            `json:"pulse_count"`
+	StarsSpent   int64          `json:"stars_spent"`
+	JoinedAt     time.Time      `json:"joined_at"`
+	LastPulseAt  time.Time      `json:"last_pulse_at"`
+	EliminatedAt *time.Time     `json:"eliminated_at,omitempty"`
+	Clock        *ClockEstimate `json:"clock,omitempty"`
+}
+
+type adminRoom struct {
+	ID            string            `json:"id"`
+	RoundID       string            `json:"round_id"`
+	Type          string            `json:"type"`
+	Tier          int               `json:"tier"`
+	State         string            `json:"state"`
+	Pool          int64             `json:"pool"`
+	PlayerCount   int               `json:"player_count"`
+	AliveCount    int               `json:"alive_count"`
+	GlobalTimerMs int64             `json:"global_timer_ms"`
+	MarginRatio   float64           `json:"margin_ratio"`
+	VolatilityMul float64           `json:"volatility_mul"`
+	StartedAt     *time.Time        `json:"started_at,omitempty"`
+	Players       []adminRoomPlayer `json:"players,omitempty"`
+}
+
+func newAdminRoom(r *room.Room, withPlayers bool) adminRoom {
+	out := adminRoom{
+		ID:            r.ID,
+		RoundID:       r.RoundID,
+		Type:          string(r.Type),
+		Tier:          r.Tier.Tier,
+		State:         r.State.String(),
+		Pool:          r.Pool,
+		PlayerCount:   r.PlayerCount(),
+		AliveCount:    r.AliveCount(),
+		GlobalTimerMs: r.GlobalTimer.Milliseconds(),
+		MarginRatio:   r.MarginRatio,
+		VolatilityMul: r.VolatilityMul,
+		StartedAt:     r.StartedAt,
+	}
+	if withPlayers {
+		for _, p := range r.AllPlayers() {
+			out.Players = append(out.Players, adminRoomPlayer{
+				ID:           p.ID,
+				Username:     p.Username,
+				Alive:        p.Alive,
+				Disconnected: p.Disconnected,
+				PulseCount:   p.PulseCount,
+				StarsSpent:   p.StarsSpent,
+				JoinedAt:     p.JoinedAt,
+				LastPulseAt:  p.LastPulseAt,
+				EliminatedAt: p.EliminatedAt,
+			})
+		}
+	}
+	return out
+}
+
+func (s *Server) handleAdminListRooms(w http.ResponseWriter, r *http.Request) {
+	if s.roomManager == nil {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	rooms := s.roomManager.All()
+	out := make([]adminRoom, 0, len(rooms))
+	for _, rm := range rooms {
+		out = append(out, newAdminRoom(rm, false))
+	}
+	writeJSON(w, out)
+}
+
+func (s *Server) handleAdminGetRoom(w http.ResponseWriter, r *http.Request) {
+	if s.roomManager == nil {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	rm, ok := s.roomManager.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	out := newAdminRoom(rm, true)
+	if s.hub != nil {
+		for i := range out.Players {
+			if est, ok := s.hub.ClockEstimate(out.Players[i].ID); ok {
+				out.Players[i].Clock = &est
+			}
+		}
+	}
+	writeJSON(w, out)
+}
+
+// handleAdminVoidRoom terminates the room's current round without settlement.
+// Body: {"reason": "..."}.
+func (s *Server) handleAdminVoidRoom(w http.ResponseWriter, r *http.Request) {
+	if s.roomManager == nil || s.roomCtl == nil {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := decodeAdminBody(r, &req, &req.Reason); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id := r.PathValue("id")
+	rm, ok := s.roomManager.Get(id)
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	before := newAdminRoom(rm, true)
+	switch err := s.roomCtl.VoidRoom(r.Context(), id, req.Reason); {
+	case errors.Is(err, room.ErrRoomNotFound):
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	case errors.Is(err, room.ErrRoundFinished):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		s.logger.Error("void room", "room", id, "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	after := newAdminRoom(rm, false)
+	if err := s.audit(r.Context(), "room.void", "round:"+before.RoundID, req.Reason, before, after); err != nil {
+		http.Error(w, "action applied but audit write failed", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, after)
+}
+
+// --- Balances ---
+
+// handleAdminAdjustBalance credits or debits a player through the ledger.
+// Body: {"stars": -50, "shards": 0, "reason": "..."}.
+func (s *Server) handleAdminAdjustBalance(w http.ResponseWriter, r *http.Request) {
+	if s.players == nil {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	pid, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "bad player id", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Stars  int64  `json:"stars"`
+		Shards int64  `json:"shards"`
+		Reason string `json:"reason"`
+	}
+	if err := decodeAdminBody(r, &req, &req.Reason); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Stars == 0 && req.Shards == 0 {
+		http.Error(w, "nothing to adjust", http.StatusBadRequest)
+		return
+	}
+	_, after, err := s.players.AdjustBalance(r.Context(), pid, req.Stars, req.Shards, store.AuditEntry{
+		Actor:  adminActor(r.Context()),
+		Action: "balance.adjust",
+		Target: playerTarget(pid),
+		Reason: req.Reason,
+	})
+	switch {
+	case errors.Is(err, store.ErrNegativeBalance):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		s.logger.Error("adjust balance", "player", pid, "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	case after == nil:
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]int64{"stars": after.StarsBalance, "shards": after.ShardsBalance})
+}
+
+// --- Seasons ---
+
+type seasonResponse struct {
+	ID        int       `json:"id"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	IsActive  bool      `json:"is_active"`
+}
+
+func newSeasonResponse(se *store.Season) *seasonResponse {
+	if se == nil {
+		return nil
+	}
+	return &seasonResponse{ID: se.ID, StartDate: se.StartDate, EndDate: se.EndDate, IsActive: se.IsActive}
+}
+
+func (s *Server) handleAdminListSeasons(w http.ResponseWriter, r *http.Request) {
+	list, err := s.seasons.List(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	out := make([]*seasonResponse, 0, len(list))
+	for i := range list {
+		out = append(out, newSeasonResponse(&list[i]))
+	}
+	writeJSON(w, out)
+}
+
+// handleAdminCreateSeason starts a new season, deactivating the current one.
+// Body: {"start_date": RFC3339, "end_date": RFC3339, "reason": "..."}.
+func (s *Server) handleAdminCreateSeason(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		StartDate time.Time `json:"start_date"`
+		EndDate   time.Time `json:"end_date"`
+		Reason    string    `json:"reason"`
+	}
+	if err := decodeAdminBody(r, &req, &req.Reason); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !req.EndDate.After(req.StartDate) {
+		http.Error(w, "end_date must be after start_date", http.StatusBadRequest)
+		return
+	}
+	prev, err := s.seasons.Active(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	created, err := s.seasons.Create(r.Context(), req.StartDate, req.EndDate)
+	if err != nil {
+		s.logger.Error("create season", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	resp := newSeasonResponse(created)
+	if err := s.audit(r.Context(), "season.create", fmt.Sprintf("season:%d", created.ID), req.Reason, newSeasonResponse(prev), resp); err != nil {
+		http.Error(w, "action applied but audit write failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, resp)
+}
+
+// --- Tiers ---
+
+func (s *Server) handleAdminListTiers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, room.LiveTiers())
+}
+
+// handleAdminReloadTiers re-reads TIERS_FILE. Running rounds keep their
+// config; rooms pick up the new one at their next reset. Body: {"reason": "..."}.
+func (s *Server) handleAdminReloadTiers(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.TiersFile == "" {
+		http.Error(w, "TIERS_FILE not configured", http.StatusConflict)
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := decodeAdminBody(r, &req, &req.Reason); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tiers, err := room.LoadTiersFile(s.cfg.TiersFile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	before := room.LiveTiers()
+	room.ReplaceTiers(tiers)
+	after := room.LiveTiers()
+	if err := s.audit(r.Context(), "tiers.reload", "tiers", req.Reason, before, after); err != nil {
+		http.Error(w, "action applied but audit write failed", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, after)
+}
+
+// --- Audit ---
+
+func (s *Server) handleAdminAudit(w http.ResponseWriter, r *http.Request) {
+	if s.auditLog == nil {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	f := store.AuditFilter{Actor: q.Get("actor"), Action: q.Get("action"), Target: q.Get("target"), Limit: 50}
+	if b := q.Get("before_id"); b != "" {
+		n, err := strconv.ParseInt(b, 10, 64)
+		if err != nil {
+			http.Error(w, "bad before_id", http.StatusBadRequest)
+			return
+		}
+		f.BeforeID = n
+	}
+	if l := q.Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 && n <= 500 {
+			f.Limit = n
+		}
+	}
+	entries, err := s.auditLog.List(r.Context(), f)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	type auditResponse struct {
+		ID        int64           `json:"id"`
+		Actor     string          `json:"actor"`
+		Action    string          `json:"action"`
+		Target    string          `json:"target"`
+		Reason    string          `json:"reason"`
+		Before    json.RawMessage `json:"before,omitempty"`
+		After     json.RawMessage `json:"after,omitempty"`
+		CreatedAt time.Time       `json:"created_at"`
+	}
+	out := make([]auditResponse, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, auditResponse(e))
+	}
+	writeJSON(w, out)
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lastclick/lastclick/internal/config"
+	"github.com/lastclick/lastclick/internal/room"
+	"github.com/lastclick/lastclick/internal/store"
+)
+
+type fakeAuditLog struct {
+	entries []store.AuditEntry
+	err     error
+}
+
+func (f *fakeAuditLog) Record(_ context.Context, e store.AuditEntry) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.entries = append(f.entries, e)
+	return nil
+}
+
+func (f *fakeAuditLog) List(context.Context, store.AuditFilter) ([]store.AuditEntry, error) {
+	return f.entries, nil
+}
+
+type fakeRoomCtl struct{}
+
+func (fakeRoomCtl) VoidRoom(context.Context, string, string) error { return nil }
+
+func newAdminTestServer(audit AuditLog) *Server {
+	return &Server{
+		cfg:         &config.Config{AdminTokens: map[string]string{"s3cret": "alice"}},
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		auditLog:    audit,
+		roomManager: room.NewManager(),
+		roomCtl:     fakeRoomCtl{},
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	s := newAdminTestServer(nil)
+	var actor string
+	h := s.requireAdmin(func(w http.ResponseWriter, r *http.Request) { actor = adminActor(r.Context()) })
+
+	for _, auth := range []string{"", "Bearer wrong", "tma s3cret", "Bearer s3cret2"} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/rooms", nil)
+		req.Header.Set("Authorization", auth)
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		if rec.Code != http.StatusUnauthorized || actor != "" {
+			t.Errorf("Authorization %q: status %d, actor %q", auth, rec.Code, actor)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/rooms", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	if rec.Code != http.StatusOK || actor != "alice" {
+		t.Fatalf("valid token: status %d, actor %q", rec.Code, actor)
+	}
+}
+
+func TestAdminVoidRoomAudits(t *testing.T) {
+	voidRoom := func(s *Server, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/rooms/"+id+"/void", strings.NewReader(`{"reason":"feed outage"}`))
+		req.SetPathValue("id", id)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		s.requireAdmin(s.handleAdminVoidRoom)(rec, req)
+		return rec
+	}
+
+	audit := &fakeAuditLog{}
+	s := newAdminTestServer(audit)
+	rm, err := s.roomManager.Create(room.RoomBlitz, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := voidRoom(s, rm.ID); rec.Code != http.StatusOK {
+		t.Fatalf("void: status %d: %s", rec.Code, rec.Body)
+	}
+	if len(audit.entries) != 1 {
+		t.Fatalf("want one audit row, got %+v", audit.entries)
+	}
+	e := audit.entries[0]
+	if e.Actor != "alice" || e.Action != "room.void" || e.Target != "round:"+rm.RoundID || e.Reason != "feed outage" || len(e.Before) == 0 {
+		t.Fatalf("audit row = %+v", e)
+	}
+
+	s.auditLog = &fakeAuditLog{err: errors.New("connection reset")}
+	if rec := voidRoom(s, rm.ID); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("failed audit write: status %d, want 500", rec.Code)
+	}
+}
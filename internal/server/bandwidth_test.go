@@ -0,0 +1,173 @@
+package server
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// roundFrames builds the room broadcasts of a synthetic 50-player, 120s
+// survival round, shaped like the engine's payloads.
+func roundFrames() []WSMessage {
+	const players = 50
+	rng := rand.New(rand.NewSource(1))
+	start := int64(1_760_000_000_000)
+	deadline := make(map[int64]int64, players)
+	for id := int64(1); id <= players; id++ {
+		deadline[id] = start + 5000
+	}
+	frame := func(typ string, v any) WSMessage {
+		b, _ := json.Marshal(v)
+		return WSMessage{Type: typ, Payload: b}
+	}
+	deadlines := func() []map[string]any {
+		out := make([]map[string]any, 0, len(deadline))
+		for id := int64(1); id <= players; id++ {
+			if d, ok := deadline[id]; ok {
+				out = append(out, map[string]any{"player_id": id, "pulse_deadline_server_ms": d})
+			}
+		}
+		return out
+	}
+
+	frames := []WSMessage{frame("room_state", map[string]any{
+		"room_id": "3f1c2a9e-8d7b-4c6a-9e1f-2b3c4d5e6f70", "state": "survival", "type": "blitz",
+		"tier": 2, "pool": 5000, "alive": players, "total": players, "timer_ms": 120000,
+		"margin_ratio": 0.0, "volatility_mul": 1.0, "winner_id": 0,
+		"server_time_ms": start, "pulse_deadlines": deadlines(),
+	})}
+	timer := int64(120000)
+	margin := 0.0
+	for sec := int64(1); sec <= 120 && len(deadline) > 1; sec++ {
+		now := start + sec*1000
+		for id := range deadline {
+			if rng.Intn(3) == 0 { // each player pulses about every 3s
+				deadline[id] = now + 5000
+				timer += 150
+				frames = append(frames, frame("pulse_ack", map[string]any{
+					"player_id": id, "extension_ms": 150, "timer_ms": timer,
+					"server_time_ms": now, "pulse_deadline_server_ms": now + 5000,
+				}))
+			}
+		}
+		if sec%10 == 0 { // one elimination every 10s
+			for id := range deadline {
+				delete(deadline, id)
+				frames = append(frames, frame("elimination", map[string]any{"player_id": id, "alive": len(deadline)}))
+				break
+			}
+		}
+		timer -= 1000
+		if sec%5 == 0 {
+			margin += 0.03
+		}
+		frames = append(frames, frame("tick", map[string]any{
+			"timer_ms": timer, "margin_ratio": margin, "volatility_mul": 1 + margin,
+			"alive": len(deadline), "server_time_ms": now, "pulse_deadlines": deadlines(),
+		}))
+	}
+	return frames
+}
+
+// bytesPerClient is what one client in the room receives over the round.
+func bytesPerClient(t *testing.T, p Protocol, frames []WSMessage) (total, ticks int) {
+	t.Helper()
+	var d tickDelta
+	for i, msg := range frames {
+		msg.Seq = uint64(i + 1)
+		if p.Version >= 2 {
+			msg = d.apply(msg)
+		}
+		b, err := p.Codec.Encode(msg)
+		if err != nil {
+			t.Fatalf("%s: encode %s: %v", p.Name, msg.Type, err)
+		}
+		total += len(b)
+		if msg.Type == "tick" || msg.Type == "tick_delta" {
+			ticks += len(b)
+		}
+	}
+	return total, ticks
+}
+
+func TestRoundBandwidth(t *testing.T) {
+	frames := roundFrames()
+	v1, v1Ticks := bytesPerClient(t, protoV1JSON, frames)
+	v2, v2Ticks := bytesPerClient(t, protoV2JSON, frames)
+	mp, mpTicks := bytesPerClient(t, protoV2Msgpack, frames)
+
+	t.Logf("per client per round: v1 json %d B (ticks %d), v2 json %d B (ticks %d), v2 msgpack %d B (ticks %d)",
+		v1, v1Ticks, v2, v2Ticks, mp, mpTicks)
+	t.Logf("50 clients: v1 json %.1f MB, v2 msgpack %.1f MB", float64(v1*50)/1e6, float64(mp*50)/1e6)
+
+	if !(v2Ticks < v1Ticks/2) {
+		t.Errorf("delta ticks %d B not under half of full ticks %d B", v2Ticks, v1Ticks)
+	}
+	if !(mp < v2 && v2 < v1) {
+		t.Errorf("expected msgpack < v2 json < v1 json, got %d, %d, %d", mp, v2, v1)
+	}
+}
+
+func TestTickDeltaApply(t *testing.T) {
+	var d tickDelta
+	tick := func(timer int64, deadlines string) WSMessage {
+		return d.apply(WSMessage{Type: "tick", Payload: json.RawMessage(
+			`{"timer_ms":` + strconv.FormatInt(timer, 10) + `,"alive":2,"pulse_deadlines":` + deadlines + `}`)})
+	}
+	first := tick(1000, `[{"player_id":1,"pulse_deadline_server_ms":10},{"player_id":2,"pulse_deadline_server_ms":20}]`)
+	if first.Type != "tick" {
+		t.Fatalf("first tick should be full, got %s", first.Type)
+	}
+	second := tick(900, `[{"player_id":1,"pulse_deadline_server_ms":10},{"player_id":2,"pulse_deadline_server_ms":25}]`)
+	if second.Type != "tick_delta" {
+		t.Fatalf("second tick type %s", second.Type)
+	}
+	want := `{"pulse_deadlines":[{"player_id":2,"pulse_deadline_server_ms":25}],"timer_ms":900}`
+	if string(second.Payload) != want {
+		t.Fatalf("delta = %s, want %s", second.Payload, want)
+	}
+	d.apply(WSMessage{Type: "room_state", Payload: json.RawMessage(`{}`)})
+	if again := tick(800, `[]`); again.Type != "tick" {
+		t.Fatalf("tick after room_state should be full, got %s", again.Type)
+	}
+}
+
+func TestTickDeltaEncodesEliminations(t *testing.T) {
+	var d tickDelta
+	tick := func(deadlines string) WSMessage {
+		return d.apply(WSMessage{Type: "tick", Payload: json.RawMessage(`{"alive":3,"pulse_deadlines":` + deadlines + `}`)})
+	}
+	tick(`[{"player_id":1,"pulse_deadline_server_ms":10},{"player_id":2,"pulse_deadline_server_ms":20},{"player_id":3,"pulse_deadline_server_ms":30}]`)
+	tick(`[{"player_id":1,"pulse_deadline_server_ms":10},{"player_id":2,"pulse_deadline_server_ms":20},{"player_id":3,"pulse_deadline_server_ms":30}]`)
+
+	// Player 2 is eliminated between two deltas.
+	got := tick(`[{"player_id":1,"pulse_deadline_server_ms":10},{"player_id":3,"pulse_deadline_server_ms":35}]`)
+	want := `{"pulse_deadlines":[{"player_id":3,"pulse_deadline_server_ms":35}],"removed":{"pulse_deadlines":[2]}}`
+	if got.Type != "tick_delta" || string(got.Payload) != want {
+		t.Fatalf("%s %s, want tick_delta %s", got.Type, got.Payload, want)
+	}
+
+	// A field that leaves the tick is removed too.
+	got = d.apply(WSMessage{Type: "tick", Payload: json.RawMessage(`{"alive":1}`)})
+	want = `{"alive":1,"removed":{"pulse_deadlines":null}}`
+	if string(got.Payload) != want {
+		t.Fatalf("delta = %s, want %s", got.Payload, want)
+	}
+}
+
+func TestMsgpackCodecRoundTrip(t *testing.T) {
+	c := msgpackCodec{}
+	in := WSMessage{Type: "join_room", ID: "7", Payload: json.RawMessage(`{"room_id":"abc"}`)}
+	b, err := c.Encode(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Type != in.Type || out.ID != in.ID || string(out.Payload) != string(in.Payload) {
+		t.Fatalf("round trip = %+v", out)
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/coder/websocket"
+
+	"github.com/lastclick/lastclick/internal/msgpack"
+)
+
+// Codec turns WSMessages into WebSocket frames and back.
+type Codec interface {
+	Name() string
+	FrameType() websocket.MessageType
+	Encode(WSMessage) ([]byte, error)
+	Decode([]byte) (WSMessage, error)
+}
+
+// Protocol is what a connection negotiated: a wire version and an encoding.
+// Version 1 is the original JSON protocol. Version 2 adds tick_delta frames.
+type Protocol struct {
+	Name    string
+	Version int
+	Codec   Codec
+}
+
+var (
+	protoV1JSON    = Protocol{Name: "lastclick.v1.json", Version: 1, Codec: jsonCodec{}}
+	protoV2JSON    = Protocol{Name: "lastclick.v2.json", Version: 2, Codec: jsonCodec{}}
+	protoV2Msgpack = Protocol{Name: "lastclick.v2.msgpack", Version: 2, Codec: msgpackCodec{}}
+
+	// protocols lists what the hub offers during the WebSocket handshake.
+	protocols = []Protocol{protoV2Msgpack, protoV2JSON, protoV1JSON}
+)
+
+func subprotocols() []string {
+	out := make([]string, len(protocols))
+	for i, p := range protocols {
+		out[i] = p.Name
+	}
+	return out
+}
+
+// protocolFor maps the negotiated subprotocol to a Protocol. Clients that
+// offer none get v1 JSON, which is what every client spoke before versioning.
+func protocolFor(name string) Protocol {
+	for _, p := range protocols {
+		if p.Name == name {
+			return p
+		}
+	}
+	return protoV1JSON
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                     { return "json" }
+func (jsonCodec) FrameType() websocket.MessageType { return websocket.MessageText }
+
+func (jsonCodec) Encode(msg WSMessage) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+func (jsonCodec) Decode(data []byte) (WSMessage, error) {
+	var msg WSMessage
+	err := json.Unmarshal(data, &msg)
+	return msg, err
+}
+
+// msgpackCodec carries the same envelope as JSON, but as a MessagePack map
+// with the payload inlined as a nested map rather than a JSON string.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string                     { return "msgpack" }
+func (msgpackCodec) FrameType() websocket.MessageType { return websocket.MessageBinary }
+
+func (msgpackCodec) Encode(msg WSMessage) ([]byte, error) {
+	env := map[string]any{"type": msg.Type}
+	if msg.ID != "" {
+		env["id"] = msg.ID
+	}
+	if msg.Seq != 0 {
+		env["seq"] = msg.Seq
+	}
+	if len(msg.Payload) > 0 {
+		payload, err := decodeJSON(msg.Payload)
+		if err != nil {
+			return nil, err
+		}
+		env["payload"] = payload
+	}
+	return msgpack.Marshal(env)
+}
+
+func (msgpackCodec) Decode(data []byte) (WSMessage, error) {
+	v, err := msgpack.Unmarshal(data)
+	if err != nil {
+		return WSMessage{}, err
+	}
+	env, ok := v.(map[string]any)
+	if !ok {
+		return WSMessage{}, fmt.Errorf("msgpack frame is %T, want map", v)
+	}
+	var msg WSMessage
+	msg.Type, _ = env["type"].(string)
+	msg.ID, _ = env["id"].(string)
+	if p, ok := env["payload"]; ok {
+		if msg.Payload, err = json.Marshal(p); err != nil {
+			return WSMessage{}, err
+		}
+	}
+	return msg, nil
+}
+
+// decodeJSON decodes into generic values, keeping numbers exact.
+func decodeJSON(raw []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	err := dec.Decode(&v)
+	return v, err
+}
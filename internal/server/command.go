@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lastclick/lastclick/internal/room"
+	"github.com/lastclick/lastclick/internal/sanction"
+)
+
+// ErrorCode is a stable, machine-readable reason a command was rejected. The
+// Mini App switches on these; messages are for humans and may change.
+type ErrorCode string
+
+const (
+	CodeBadRequest    ErrorCode = "bad_request"
+	CodeUnknownType   ErrorCode = "unknown_type"
+	CodeRoomNotFound  ErrorCode = "room_not_found"
+	CodeRoomFull      ErrorCode = "room_full"
+	CodeAlreadyInRoom ErrorCode = "already_in_room"
+	CodeNotInRoom     ErrorCode = "not_in_room"
+	CodeWrongState    ErrorCode = "wrong_state"
+	CodeEliminated    ErrorCode = "eliminated"
+	CodeRateLimited   ErrorCode = "rate_limited"
+	CodeBanned        ErrorCode = "banned"
+	CodeSuspended     ErrorCode = "suspended"
+	CodeMuted         ErrorCode = "muted"
+	CodeEconomyFrozen ErrorCode = "economy_frozen"
+	CodeInternal      ErrorCode = "internal"
+)
+
+// CommandError rejects a WS command with a code the client can act on.
+type CommandError struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (e *CommandError) Error() string { return e.Message }
+
+// CommandErrorf builds a CommandError with a formatted message.
+func CommandErrorf(code ErrorCode, format string, args ...any) *CommandError {
+	return &CommandError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// codeFor maps domain sentinels to error codes, so callers can return them
+// unwrapped. Anything unrecognised is internal and its message is not echoed.
+func codeFor(err error) (ErrorCode, string) {
+	var ce *CommandError
+	switch {
+	case errors.As(err, &ce):
+		return ce.Code, ce.Message
+	case errors.Is(err, room.ErrRoomNotFound):
+		return CodeRoomNotFound, err.Error()
+	case errors.Is(err, room.ErrRoomFull):
+		return CodeRoomFull, err.Error()
+	case errors.Is(err, room.ErrAlreadyJoined):
+		return CodeAlreadyInRoom, err.Error()
+	case errors.Is(err, room.ErrJoinClosed), errors.Is(err, room.ErrRoundFinished):
+		return CodeWrongState, err.Error()
+	case errors.Is(err, sanction.ErrBanned):
+		return CodeBanned, err.Error()
+	case errors.Is(err, sanction.ErrSuspended):
+		return CodeSuspended, err.Error()
+	case errors.Is(err, sanction.ErrMuted):
+		return CodeMuted, err.Error()
+	case errors.Is(err, sanction.ErrFrozen):
+		return CodeEconomyFrozen, err.Error()
+	default:
+		return CodeInternal, "internal error"
+	}
+}
+
+type ackPayload struct {
+	Type   string `json:"type"`
+	Result any    `json:"result,omitempty"`
+}
+
+type errorPayload struct {
+	Type    string    `json:"type"`
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// Ack confirms req was accepted, echoing its ID and type. result is optional.
+func (h *Hub) Ack(clientID int64, req WSMessage, result any) {
+	payload, _ := json.Marshal(ackPayload{Type: req.Type, Result: result})
+	h.SendTo(clientID, WSMessage{Type: "ack", ID: req.ID, Payload: payload})
+}
+
+// Reject tells the client why req failed, echoing its ID and type.
+func (h *Hub) Reject(clientID int64, req WSMessage, err error) {
+	code, msg := codeFor(err)
+	if code == CodeInternal {
+		h.logger.Error("ws command", "type", req.Type, "player", clientID, "err", err)
+	}
+	payload, _ := json.Marshal(errorPayload{Type: req.Type, Code: code, Message: msg})
+	h.SendTo(clientID, WSMessage{Type: "error", ID: req.ID, Payload: payload})
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lastclick/lastclick/internal/room"
+	"github.com/lastclick/lastclick/internal/sanction"
+	"github.com/lastclick/lastclick/internal/store"
+)
+
+func TestCodeFor(t *testing.T) {
+	cases := []struct {
+		err  error
+		want ErrorCode
+	}{
+		{CommandErrorf(CodeRateLimited, "slow down"), CodeRateLimited},
+		{fmt.Errorf("join: %w", room.ErrRoomFull), CodeRoomFull},
+		{room.ErrJoinClosed, CodeWrongState},
+		{room.ErrAlreadyJoined, CodeAlreadyInRoom},
+		{room.ErrRoomNotFound, CodeRoomNotFound},
+		{fmt.Errorf("debit: %w", store.ErrNegativeBalance), CodeInternal},
+		{sanction.ErrMuted, CodeMuted},
+		{errors.New("connection reset"), CodeInternal},
+	}
+	for _, c := range cases {
+		if got, _ := codeFor(c.err); got != c.want {
+			t.Errorf("codeFor(%v) = %q, want %q", c.err, got, c.want)
+		}
+	}
+	if _, msg := codeFor(errors.New("dial tcp 10.0.0.1: refused")); msg != "internal error" {
+		t.Errorf("internal error leaked message %q", msg)
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// tickKeyframeEvery sends every Nth tick whole, so a client whose delta base
+// went wrong recovers within a few seconds.
+const tickKeyframeEvery = 10
+
+// tickDelta rewrites a v2 client's tick frames as tick_delta frames carrying
+// only the fields that changed since the last tick that client received.
+// It lives on the client, so the base is always what that client saw.
+type tickDelta struct {
+	last  map[string]any
+	count int
+}
+
+func (d *tickDelta) apply(msg WSMessage) WSMessage {
+	switch msg.Type {
+	case "room_state":
+		// New room or resumed snapshot: start again from a full tick.
+		d.last, d.count = nil, 0
+		return msg
+	case "tick":
+	default:
+		return msg
+	}
+
+	v, err := decodeJSON(msg.Payload)
+	cur, ok := v.(map[string]any)
+	if err != nil || !ok {
+		return msg
+	}
+	prev := d.last
+	d.last = cur
+	d.count++
+	if prev == nil || d.count%tickKeyframeEvery == 0 {
+		return msg
+	}
+	payload, err := json.Marshal(diffFields(prev, cur))
+	if err != nil {
+		return msg
+	}
+	msg.Type = "tick_delta"
+	msg.Payload = payload
+	return msg
+}
+
+// deltaRemoved is the tick_delta key listing what left the tick since the
+// last one: per-player list fields map to the player_ids dropped from them
+// (eliminated players leave pulse_deadlines), and fields gone altogether map
+// to null.
+const deltaRemoved = "removed"
+
+// diffFields returns the fields of cur that differ from prev. Lists of
+// objects with a player_id are diffed per player: only new or changed
+// entries are kept, and departed players are listed under deltaRemoved.
+func diffFields(prev, cur map[string]any) map[string]any {
+	out := make(map[string]any)
+	removed := make(map[string]any)
+	for k, v := range cur {
+		pv, ok := prev[k]
+		if !ok {
+			out[k] = v
+			continue
+		}
+		if list, ok := v.([]any); ok {
+			if changed, gone := diffPlayerList(pv, list); changed != nil {
+				if len(changed) > 0 {
+					out[k] = changed
+				}
+				if len(gone) > 0 {
+					removed[k] = gone
+				}
+				continue
+			}
+		}
+		if !reflect.DeepEqual(pv, v) {
+			out[k] = v
+		}
+	}
+	for k := range prev {
+		if _, ok := cur[k]; !ok {
+			removed[k] = nil
+		}
+	}
+	if len(removed) > 0 {
+		out[deltaRemoved] = removed
+	}
+	return out
+}
+
+// diffPlayerList returns the entries of cur that are new or changed versus
+// prev and the player_ids in prev that cur no longer has, or nil when the
+// lists are not keyed by player_id.
+func diffPlayerList(prev any, cur []any) (changed, gone []any) {
+	old := make(map[any]any)
+	var order []any
+	if pl, ok := prev.([]any); ok {
+		for _, e := range pl {
+			if m, ok := e.(map[string]any); ok && m["player_id"] != nil {
+				old[m["player_id"]] = m
+				order = append(order, m["player_id"])
+			}
+		}
+	}
+	changed = []any{}
+	seen := make(map[any]bool, len(cur))
+	for _, e := range cur {
+		m, ok := e.(map[string]any)
+		if !ok || m["player_id"] == nil {
+			return nil, nil
+		}
+		seen[m["player_id"]] = true
+		if !reflect.DeepEqual(old[m["player_id"]], m) {
+			changed = append(changed, m)
+		}
+	}
+	for _, id := range order {
+		if !seen[id] {
+			gone = append(gone, id)
+		}
+	}
+	return changed, gone
+}
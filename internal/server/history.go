@@ -0,0 +1,201 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lastclick/lastclick/internal/store"
+)
+
+const (
+	historyPageSize = 50
+	historyMaxPage  = 200
+	historyCSVMax   = 10_000
+)
+
+type historyEntry struct {
+	ID        int64        `json:"id"`
+	Type      store.TxType `json:"type"`
+	Amount    int64        `json:"amount"`
+	Currency  string       `json:"currency"`
+	Balance   int64        `json:"balance"`
+	RoomID    *string      `json:"room_id,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// handleMyTransactions serves GET /api/player/me/transactions.
+//
+// Query parameters:
+//
+//	type    comma-separated TxType list (entry, pulse, payout, …)
+//	room_id round UUID
+//	from/to RFC 3339 range, from inclusive, to exclusive
+//	cursor  next_cursor from the previous page
+//	limit   page size, max 200
+//	format  "csv" to download every matching row instead of a JSON page
+func (s *Server) handleMyTransactions(w http.ResponseWriter, r *http.Request) {
+	playerID, _ := PlayerIDFromContext(r.Context())
+	f, err := parseHistoryFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.PlayerID = playerID
+
+	if r.URL.Query().Get("format") == "csv" {
+		s.writeHistoryCSV(w, r, f)
+		return
+	}
+
+	entries, err := s.txs.History(r.Context(), f)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	resp := struct {
+		Transactions []historyEntry `json:"transactions"`
+		NextCursor   string         `json:"next_cursor,omitempty"`
+	}{Transactions: make([]historyEntry, 0, len(entries))}
+	for _, e := range entries {
+		resp.Transactions = append(resp.Transactions, newHistoryEntry(e))
+	}
+	if len(entries) == f.Limit {
+		last := entries[len(entries)-1]
+		resp.NextCursor = encodeHistoryCursor(store.HistoryCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) writeHistoryCSV(w http.ResponseWriter, r *http.Request, f store.HistoryFilter) {
+	f.Limit = historyMaxPage
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="transactions.csv"`)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"id", "created_at", "type", "amount", "currency", "balance", "room_id"})
+	written := 0
+	for written < historyCSVMax {
+		entries, err := s.txs.History(r.Context(), f)
+		if err != nil {
+			// Headers are already sent; log and truncate.
+			s.logger.Error("transaction csv export", "player", f.PlayerID, "err", err)
+			break
+		}
+		for _, e := range entries {
+			roomID := ""
+			if e.RoomID != nil {
+				roomID = *e.RoomID
+			}
+			_ = cw.Write([]string{
+				strconv.FormatInt(e.ID, 10),
+				e.CreatedAt.UTC().Format(time.RFC3339Nano),
+				string(e.Type),
+				strconv.FormatInt(e.Amount, 10),
+				e.Currency,
+				strconv.FormatInt(e.Balance, 10),
+				roomID,
+			})
+		}
+		written += len(entries)
+		if len(entries) < f.Limit {
+			break
+		}
+		last := entries[len(entries)-1]
+		f.After = &store.HistoryCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	cw.Flush()
+}
+
+func newHistoryEntry(e store.HistoryEntry) historyEntry {
+	return historyEntry{
+		ID:        e.ID,
+		Type:      e.Type,
+		Amount:    e.Amount,
+		Currency:  e.Currency,
+		Balance:   e.Balance,
+		RoomID:    e.RoomID,
+		CreatedAt: e.CreatedAt,
+	}
+}
+
+func parseHistoryFilter(r *http.Request) (store.HistoryFilter, error) {
+	q := r.URL.Query()
+	f := store.HistoryFilter{Limit: historyPageSize}
+
+	if types := q.Get("type"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			tx := store.TxType(strings.TrimSpace(t))
+			if !tx.Valid() {
+				return f, fmt.Errorf("unknown transaction type %q", t)
+			}
+			f.Types = append(f.Types, tx)
+		}
+	}
+	if roomID := q.Get("room_id"); roomID != "" {
+		if _, err := uuid.Parse(roomID); err != nil {
+			return f, fmt.Errorf("bad room_id")
+		}
+		f.RoomID = roomID
+	}
+	if from := q.Get("from"); from != "" {
+		ts, err := time.Parse(time.RFC3339Nano, from)
+		if err != nil {
+			return f, fmt.Errorf("bad from")
+		}
+		f.From = ts
+	}
+	if to := q.Get("to"); to != "" {
+		ts, err := time.Parse(time.RFC3339Nano, to)
+		if err != nil {
+			return f, fmt.Errorf("bad to")
+		}
+		f.To = ts
+	}
+	if c := q.Get("cursor"); c != "" {
+		cur, err := decodeHistoryCursor(c)
+		if err != nil {
+			return f, fmt.Errorf("bad cursor")
+		}
+		f.After = &cur
+	}
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > historyMaxPage {
+			return f, fmt.Errorf("limit must be 1-%d", historyMaxPage)
+		}
+		f.Limit = n
+	}
+	return f, nil
+}
+
+// Cursors are opaque to clients: base64url("<created_at unix nanos>:<id>").
+func encodeHistoryCursor(c store.HistoryCursor) string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeHistoryCursor(s string) (store.HistoryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return store.HistoryCursor{}, err
+	}
+	tsStr, idStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return store.HistoryCursor{}, fmt.Errorf("malformed cursor")
+	}
+	ns, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return store.HistoryCursor{}, err
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return store.HistoryCursor{}, err
+	}
+	return store.HistoryCursor{CreatedAt: time.Unix(0, ns), ID: id}, nil
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lastclick/lastclick/internal/store"
+)
+
+func TestParseHistoryFilter(t *testing.T) {
+	cursor := encodeHistoryCursor(store.HistoryCursor{CreatedAt: time.Unix(0, 1700000000123456789), ID: 42})
+	cases := []struct {
+		query   string
+		wantErr string
+		check   func(store.HistoryFilter) bool
+	}{
+		{"", "", func(f store.HistoryFilter) bool { return f.Limit == historyPageSize && f.After == nil }},
+		{"type=payout,%20shard_grant", "", func(f store.HistoryFilter) bool {
+			return len(f.Types) == 2 && f.Types[0] == store.TxPayout
+		}},
+		{"type=payout,bonus", "unknown transaction type", nil},
+		{"room_id=not-a-uuid", "bad room_id", nil},
+		{"room_id=8f14e45f-ceea-4672-8c6a-2c3e8a1b1f11", "", func(f store.HistoryFilter) bool {
+			return f.RoomID == "8f14e45f-ceea-4672-8c6a-2c3e8a1b1f11"
+		}},
+		{"from=2026-01-02T03:04:05Z&to=2026-02-01T00:00:00.5Z", "", func(f store.HistoryFilter) bool {
+			return f.From.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) &&
+				f.To.Equal(time.Date(2026, 2, 1, 0, 0, 0, 5e8, time.UTC))
+		}},
+		{"from=yesterday", "bad from", nil},
+		{"to=2026-13-01T00:00:00Z", "bad to", nil},
+		{"limit=1", "", func(f store.HistoryFilter) bool { return f.Limit == 1 }},
+		{"limit=200", "", func(f store.HistoryFilter) bool { return f.Limit == historyMaxPage }},
+		{"limit=0", "limit must be", nil},
+		{"limit=201", "limit must be", nil},
+		{"limit=ten", "limit must be", nil},
+		{"cursor=" + cursor, "", func(f store.HistoryFilter) bool { return f.After != nil && f.After.ID == 42 }},
+		{"cursor=@@", "bad cursor", nil},
+	}
+	for _, c := range cases {
+		f, err := parseHistoryFilter(httptest.NewRequest("GET", "/api/me/history?"+c.query, nil))
+		switch {
+		case c.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("%q: err %v, want %q", c.query, err, c.wantErr)
+			}
+		case err != nil:
+			t.Errorf("%q: %v", c.query, err)
+		case !c.check(f):
+			t.Errorf("%q: filter %+v", c.query, f)
+		}
+	}
+}
+
+func TestHistoryCursorRoundTrip(t *testing.T) {
+	in := store.HistoryCursor{CreatedAt: time.Unix(0, 1700000000123456789), ID: 987654321}
+	out, err := decodeHistoryCursor(encodeHistoryCursor(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.CreatedAt.Equal(in.CreatedAt) || out.ID != in.ID {
+		t.Fatalf("round trip: got %+v, want %+v", out, in)
+	}
+
+	enc := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	for _, bad := range []string{"not base64!", "abc=", enc("1700000000"), enc("x:1"), enc("1700000000:y"), enc(":")} {
+		if _, err := decodeHistoryCursor(bad); err == nil {
+			t.Errorf("decodeHistoryCursor(%q) accepted", bad)
+		}
+	}
+}
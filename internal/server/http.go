@@ -2,15 +2,22 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lastclick/lastclick/internal/auth"
 	"github.com/lastclick/lastclick/internal/config"
 	"github.com/lastclick/lastclick/internal/leaderboard"
+	"github.com/lastclick/lastclick/internal/room"
+	"github.com/lastclick/lastclick/internal/sanction"
 	"github.com/lastclick/lastclick/internal/squad"
 	"github.com/lastclick/lastclick/internal/store"
 	"github.com/redis/go-redis/v9"
@@ -27,7 +34,21 @@ type Server struct {
 	squadSvc    *squad.Service
 	leaderboard *leaderboard.Service
 	seasons     *store.SeasonStore
+	rounds      *store.RoundStore
+	txs         *store.TransactionStore
+	settlements *store.SettlementStore
+	sessions    *SessionManager
+	registrar   *Registrar
+	verifier    auth.Verifier
+
+	sanctions       *store.SanctionStore
+	sanctionChecker *sanction.Checker
+
+	auditLog    AuditLog
+	roomManager *room.Manager
+	roomCtl     RoomController
 	metrics     *Metrics
+	limits      *RouteLimiter
 }
 
 func New(cfg *config.Config, db *pgxpool.Pool, rdb *redis.Client, hub *Hub, logger *slog.Logger) *Server {
@@ -40,12 +61,24 @@ func New(cfg *config.Config, db *pgxpool.Pool, rdb *redis.Client, hub *Hub, logg
 		mux:         http.NewServeMux(),
 		leaderboard: leaderboard.NewService(rdb),
 		seasons:     store.NewSeasonStore(db),
+		rounds:      store.NewRoundStore(db),
+		txs:         store.NewTransactionStore(db),
+		auditLog:    store.NewAuditStore(db),
+		verifier:    auth.NewHMACVerifier(cfg.BotToken, auth.DefaultWindow),
 		metrics:     NewMetrics(),
 	}
+	s.limits = NewRouteLimiter(NewRateLimiter(), DefaultRouteLimits, cfg.TrustedProxies, logger)
+	s.limits.metrics = s.metrics
 	s.routes()
 	return s
 }
 
+// Metrics returns the server's instrumentation so the hub, engine and
+// settlement worker can record into the same /metrics output.
+func (s *Server) Metrics() *Metrics {
+	return s.metrics
+}
+
 func (s *Server) SetPlayerStore(ps *store.PlayerStore) {
 	s.players = ps
 }
@@ -54,19 +87,82 @@ func (s *Server) SetSquadService(svc *squad.Service) {
 	s.squadSvc = svc
 }
 
+func (s *Server) SetSessionManager(sm *SessionManager) {
+	s.sessions = sm
+}
+
+// SetSanctions enables sanction enforcement on the authenticated API and the
+// admin endpoints that apply and lift sanctions.
+func (s *Server) SetSanctions(st *store.SanctionStore, c *sanction.Checker) {
+	s.sanctions = st
+	s.sanctionChecker = c
+}
+
+// SetRoomAdmin enables room inspection and voiding on the admin API.
+func (s *Server) SetRoomAdmin(rooms *room.Manager, ctl RoomController) {
+	s.roomManager = rooms
+	s.roomCtl = ctl
+}
+
+// SetVerifier replaces the default bot-token HMAC check for "tma" credentials.
+func (s *Server) SetVerifier(v auth.Verifier) {
+	s.verifier = v
+}
+
+func (s *Server) SetRegistrar(g *Registrar) {
+	s.registrar = g
+}
+
+// SetRateLimitStore replaces the per-process buckets, e.g. with a
+// RedisLimiter so limits hold across instances.
+func (s *Server) SetRateLimitStore(ls LimitStore) {
+	s.limits.store = ls
+}
+
+func (s *Server) SetSettlementStore(ss *store.SettlementStore) {
+	s.settlements = ss
+}
+
 func (s *Server) routes() {
 	s.mux.HandleFunc("GET /health", s.handleHealth)
 	s.mux.HandleFunc("GET /metrics", s.metrics.ServeHTTP)
 	s.mux.Handle("GET /ws", s.hub)
 
-	// Squad endpoints
-	s.mux.HandleFunc("POST /api/squads", s.handleCreateSquad)
-	s.mux.HandleFunc("POST /api/squads/join", s.handleJoinSquad)
-	s.mux.HandleFunc("POST /api/squads/leave", s.handleLeaveSquad)
+	// Admin endpoints (operator tokens from ADMIN_TOKENS; every mutation is audited)
+	s.mux.HandleFunc("GET /admin/audit", s.requireAdmin(s.handleAdminAudit))
+	s.mux.HandleFunc("GET /admin/settlements/unsettled", s.requireAdmin(s.handleUnsettledRounds))
+	s.mux.HandleFunc("GET /admin/connections", s.requireAdmin(s.handleAdminConnections))
+	s.mux.HandleFunc("GET /admin/rooms", s.requireAdmin(s.handleAdminListRooms))
+	s.mux.HandleFunc("GET /admin/rooms/{id}", s.requireAdmin(s.handleAdminGetRoom))
+	s.mux.HandleFunc("POST /admin/rooms/{id}/void", s.requireAdmin(s.handleAdminVoidRoom))
+	s.mux.HandleFunc("POST /admin/players/{id}/balance", s.requireAdmin(s.handleAdminAdjustBalance))
+	s.mux.HandleFunc("GET /admin/players/{id}/sanctions", s.requireAdmin(s.handleListSanctions))
+	s.mux.HandleFunc("POST /admin/players/{id}/sanctions", s.requireAdmin(s.handleCreateSanction))
+	s.mux.HandleFunc("DELETE /admin/sanctions/{id}", s.requireAdmin(s.handleLiftSanction))
+	s.mux.HandleFunc("GET /admin/seasons", s.requireAdmin(s.handleAdminListSeasons))
+	s.mux.HandleFunc("POST /admin/seasons", s.requireAdmin(s.handleAdminCreateSeason))
+	s.mux.HandleFunc("GET /admin/tiers", s.requireAdmin(s.handleAdminListTiers))
+	s.mux.HandleFunc("POST /admin/tiers/reload", s.requireAdmin(s.handleAdminReloadTiers))
+
+	// Session endpoints
+	s.mux.HandleFunc("POST /api/auth/session", s.handleCreateSession)
+	s.mux.HandleFunc("POST /api/auth/refresh", s.handleRefreshSession)
+	s.mux.HandleFunc("POST /api/auth/logout", s.requireAuth(s.handleLogout))
+
+	// Squad endpoints (mutations act on the authenticated player only)
+	s.mux.HandleFunc("POST /api/squads", s.requireAuth(s.handleCreateSquad))
+	s.mux.HandleFunc("POST /api/squads/join", s.requireAuth(s.handleJoinSquad))
+	s.mux.HandleFunc("POST /api/squads/leave", s.requireAuth(s.handleLeaveSquad))
 	s.mux.HandleFunc("GET /api/squads/{id}", s.handleGetSquad)
 
-	// Player endpoint
-	s.mux.HandleFunc("GET /api/player/{id}", s.handleGetPlayer)
+	// Player endpoints
+	s.mux.HandleFunc("GET /api/player/me", s.requireAuth(s.handleGetMe))
+	s.mux.HandleFunc("GET /api/player/me/transactions", s.requireAuth(s.handleMyTransactions))
+	s.mux.HandleFunc("GET /api/player/{id}", s.optionalAuth(s.handleGetPlayer))
+
+	// Round history endpoints
+	s.mux.HandleFunc("GET /api/rounds", s.handleListRounds)
+	s.mux.HandleFunc("GET /api/rounds/{id}", s.handleGetRound)
 
 	// Leaderboard endpoints
 	s.mux.HandleFunc("GET /api/leaderboard/players", s.handlePlayerLeaderboard)
@@ -77,6 +173,32 @@ func (s *Server) routes() {
 	s.mux.Handle("GET /", http.FileServer(http.Dir("web")))
 }
 
+// publicPlayer is what other players may see. Balances and the hidden
+// lifetime rating are only returned to the player themselves.
+type publicPlayer struct {
+	ID            int64
+	Username      string
+	Elo           int
+	EfficiencyAvg float64
+	SquadID       *string
+	PrestigeMult  float64
+	PhotoURL      string
+	CreatedAt     time.Time
+}
+
+func newPublicPlayer(p *store.Player) publicPlayer {
+	return publicPlayer{
+		ID:            p.ID,
+		Username:      p.Username,
+		Elo:           p.Elo,
+		EfficiencyAvg: p.EfficiencyAvg,
+		SquadID:       p.SquadID,
+		PrestigeMult:  p.PrestigeMult,
+		PhotoURL:      p.PhotoURL,
+		CreatedAt:     p.CreatedAt,
+	}
+}
+
 func (s *Server) handleGetPlayer(w http.ResponseWriter, r *http.Request) {
 	if s.players == nil {
 		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
@@ -97,9 +219,222 @@ func (s *Server) handleGetPlayer(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "not found", http.StatusNotFound)
 		return
 	}
+	if self, ok := PlayerIDFromContext(r.Context()); ok && self == pid {
+		writeJSON(w, player)
+		return
+	}
+	writeJSON(w, newPublicPlayer(player))
+}
+
+func (s *Server) handleGetMe(w http.ResponseWriter, r *http.Request) {
+	if s.players == nil {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	playerID, _ := PlayerIDFromContext(r.Context())
+	player, err := s.players.Get(r.Context(), playerID)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if player == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
 	writeJSON(w, player)
 }
 
+// handleUnsettledRounds lists rounds still waiting in the settlement outbox,
+// with attempt counts and the last error, so stuck payouts are visible.
+func (s *Server) handleUnsettledRounds(w http.ResponseWriter, r *http.Request) {
+	if s.settlements == nil {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	limit := 100
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 && n <= 1000 {
+			limit = n
+		}
+	}
+	pending, err := s.settlements.Unsettled(r.Context(), limit)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	type unsettledRound struct {
+		ID            int64     `json:"id"`
+		RoomID        string    `json:"room_id"`
+		Type          string    `json:"type"`
+		Tier          int       `json:"tier"`
+		Pool          int64     `json:"pool"`
+		Players       int       `json:"players"`
+		Attempts      int       `json:"attempts"`
+		LastError     string    `json:"last_error,omitempty"`
+		NextAttemptAt time.Time `json:"next_attempt_at"`
+		CreatedAt     time.Time `json:"created_at"`
+	}
+	out := make([]unsettledRound, 0, len(pending))
+	for _, rs := range pending {
+		out = append(out, unsettledRound{
+			ID:            rs.ID,
+			RoomID:        rs.RoomID,
+			Type:          rs.RoomType,
+			Tier:          rs.Tier,
+			Pool:          rs.Pool,
+			Players:       len(rs.PlayerIDs),
+			Attempts:      rs.Attempts,
+			LastError:     rs.LastError,
+			NextAttemptAt: rs.NextAttemptAt,
+			CreatedAt:     rs.CreatedAt,
+		})
+	}
+	writeJSON(w, out)
+}
+
+type roundResponse struct {
+	ID          string     `json:"id"`
+	RoomID      string     `json:"room_id"`
+	Type        string     `json:"type"`
+	Tier        int        `json:"tier"`
+	EntryCost   int64      `json:"entry_cost"`
+	Pool        int64      `json:"pool"`
+	State       string     `json:"state"`
+	WinnerID    *int64     `json:"winner_id,omitempty"`
+	PlayerCount int        `json:"player_count"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	EndedAt     *time.Time `json:"ended_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func newRoundResponse(rd store.Round) roundResponse {
+	return roundResponse{
+		ID:          rd.ID,
+		RoomID:      rd.SlotID,
+		Type:        rd.Type,
+		Tier:        rd.Tier,
+		EntryCost:   rd.EntryCost,
+		Pool:        rd.Pool,
+		State:       rd.State,
+		WinnerID:    rd.WinnerID,
+		PlayerCount: rd.PlayerCount,
+		StartedAt:   rd.StartedAt,
+		EndedAt:     rd.EndedAt,
+		CreatedAt:   rd.CreatedAt,
+	}
+}
+
+// handleListRounds lists rounds newest first. Filters: type, tier, state and
+// before (RFC 3339 created_at); pagination: limit (max 100) and cursor, the
+// next_cursor of the previous page.
+func (s *Server) handleListRounds(w http.ResponseWriter, r *http.Request) {
+	f, err := parseRoundFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rounds, err := s.rounds.List(r.Context(), f)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	resp := struct {
+		Rounds     []roundResponse `json:"rounds"`
+		NextCursor string          `json:"next_cursor,omitempty"`
+	}{Rounds: make([]roundResponse, 0, len(rounds))}
+	for _, rd := range rounds {
+		resp.Rounds = append(resp.Rounds, newRoundResponse(rd))
+	}
+	if len(rounds) == f.Limit {
+		last := rounds[len(rounds)-1]
+		resp.NextCursor = encodeRoundCursor(store.RoundCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	writeJSON(w, resp)
+}
+
+func parseRoundFilter(r *http.Request) (store.RoundFilter, error) {
+	q := r.URL.Query()
+	f := store.RoundFilter{
+		Type:  q.Get("type"),
+		State: q.Get("state"),
+		Limit: 50,
+	}
+	if t := q.Get("tier"); t != "" {
+		n, err := strconv.Atoi(t)
+		if err != nil {
+			return f, fmt.Errorf("bad tier")
+		}
+		f.Tier = n
+	}
+	if l := q.Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 && n <= 100 {
+			f.Limit = n
+		}
+	}
+	if b := q.Get("before"); b != "" {
+		ts, err := time.Parse(time.RFC3339Nano, b)
+		if err != nil {
+			return f, fmt.Errorf("bad before")
+		}
+		f.Before = ts
+	}
+	if c := q.Get("cursor"); c != "" {
+		cur, err := decodeRoundCursor(c)
+		if err != nil {
+			return f, fmt.Errorf("bad cursor")
+		}
+		f.After = &cur
+	}
+	return f, nil
+}
+
+// Round cursors are opaque to clients: base64url("<created_at unix nanos>:<id>").
+func encodeRoundCursor(c store.RoundCursor) string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeRoundCursor(s string) (store.RoundCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return store.RoundCursor{}, err
+	}
+	tsStr, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return store.RoundCursor{}, fmt.Errorf("malformed cursor")
+	}
+	ns, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return store.RoundCursor{}, err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return store.RoundCursor{}, err
+	}
+	return store.RoundCursor{CreatedAt: time.Unix(0, ns), ID: id}, nil
+}
+
+func (s *Server) handleGetRound(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := uuid.Parse(id); err != nil {
+		http.Error(w, "bad round id", http.StatusBadRequest)
+		return
+	}
+	d, err := s.rounds.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if d == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, struct {
+		roundResponse
+		Placements []int64 `json:"placements"`
+		Settled    bool    `json:"settled"`
+	}{newRoundResponse(d.Round), d.Placements, d.Settled})
+}
+
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
 	defer cancel()
@@ -137,14 +472,14 @@ func (s *Server) handleCreateSquad(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var req struct {
-		Name      string `json:"name"`
-		FounderID int64  `json:"founder_id"`
+		Name string `json:"name"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
-	sq, err := s.squadSvc.Create(r.Context(), req.Name, req.FounderID)
+	playerID, _ := PlayerIDFromContext(r.Context())
+	sq, err := s.squadSvc.Create(r.Context(), req.Name, playerID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -158,14 +493,14 @@ func (s *Server) handleJoinSquad(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var req struct {
-		PlayerID int64  `json:"player_id"`
-		SquadID  string `json:"squad_id"`
+		SquadID string `json:"squad_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
-	if err := s.squadSvc.Join(r.Context(), req.PlayerID, req.SquadID); err != nil {
+	playerID, _ := PlayerIDFromContext(r.Context())
+	if err := s.squadSvc.Join(r.Context(), playerID, req.SquadID); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -177,14 +512,8 @@ func (s *Server) handleLeaveSquad(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
 		return
 	}
-	var req struct {
-		PlayerID int64 `json:"player_id"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
-	}
-	if err := s.squadSvc.Leave(r.Context(), req.PlayerID); err != nil {
+	playerID, _ := PlayerIDFromContext(r.Context())
+	if err := s.squadSvc.Leave(r.Context(), playerID); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -274,11 +603,10 @@ func (s *Server) handlePlayerRank(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) Handler() http.Handler {
-	limiter := NewRateLimiter(30, 60)
 	return ChainMiddleware(s.mux,
 		RecoveryMiddleware(s.logger),
 		LoggingMiddleware(s.logger),
-		RateLimitMiddleware(limiter, s.logger),
+		RateLimitMiddleware(s.limits),
 	)
 }
 
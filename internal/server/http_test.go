@@ -0,0 +1,41 @@
+package server
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lastclick/lastclick/internal/store"
+)
+
+func TestRoundCursorRoundTrip(t *testing.T) {
+	in := store.RoundCursor{CreatedAt: time.Unix(0, 1700000000123456789), ID: "8f14e45f-ceea-4672-8c6a-2c3e8a1b1f11"}
+	out, err := decodeRoundCursor(encodeRoundCursor(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.CreatedAt.Equal(in.CreatedAt) || out.ID != in.ID {
+		t.Fatalf("round trip: got %+v, want %+v", out, in)
+	}
+
+	enc := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	for _, bad := range []string{"@@", enc("1700000000"), enc("x:" + in.ID), enc("1700000000:42")} {
+		if _, err := decodeRoundCursor(bad); err == nil {
+			t.Errorf("decodeRoundCursor(%q) accepted", bad)
+		}
+	}
+}
+
+func TestListRoundsRejectsBadInput(t *testing.T) {
+	s := &Server{}
+	for _, q := range []string{"cursor=@@", "cursor=" + base64.RawURLEncoding.EncodeToString([]byte("1:not-a-uuid")), "before=yesterday", "tier=two"} {
+		rec := httptest.NewRecorder()
+		s.handleListRounds(rec, httptest.NewRequest(http.MethodGet, "/api/rounds?"+q, nil))
+		if rec.Code != http.StatusBadRequest || !strings.HasPrefix(rec.Body.String(), "bad ") {
+			t.Errorf("%s: status %d body %q, want 400", q, rec.Code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lastclick/lastclick/internal/auth"
+	"github.com/lastclick/lastclick/internal/sanction"
+)
+
+var errUnauthenticated = errors.New("unauthenticated")
+
+type ctxKey int
+
+const (
+	playerIDKey ctxKey = iota
+	sessionIDKey
+	adminActorKey
+)
+
+// identity is the caller resolved from a request. SessionID is empty when the
+// caller authenticated with raw initData instead of a session token; User is
+// set only in that case.
+type identity struct {
+	PlayerID  int64
+	SessionID string
+	User      *auth.User
+}
+
+func withIdentity(ctx context.Context, id identity) context.Context {
+	ctx = context.WithValue(ctx, playerIDKey, id.PlayerID)
+	if id.SessionID != "" {
+		ctx = context.WithValue(ctx, sessionIDKey, id.SessionID)
+	}
+	return ctx
+}
+
+// PlayerIDFromContext returns the authenticated player set by requireAuth or optionalAuth.
+func PlayerIDFromContext(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(playerIDKey).(int64)
+	return id, ok
+}
+
+// requireAuth rejects requests without a valid identity and puts the player ID
+// in the request context. Handlers must act only on that identity.
+func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := s.requestIdentity(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer, tma")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !s.limits.allowPlayer(w, r, id.PlayerID) {
+			return
+		}
+		if err := s.sanctionChecker.Check(r.Context(), id.PlayerID, sanction.Connect); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		next(w, r.WithContext(withIdentity(r.Context(), id)))
+	}
+}
+
+// optionalAuth sets the player ID in the context when the request carries a
+// valid identity, and passes anonymous requests through unchanged.
+func (s *Server) optionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if id, err := s.requestIdentity(r); err == nil {
+			if !s.limits.allowPlayer(w, r, id.PlayerID) {
+				return
+			}
+			r = r.WithContext(withIdentity(r.Context(), id))
+		}
+		next(w, r)
+	}
+}
+
+// requestIdentity resolves the caller from either a session access token
+// ("Authorization: Bearer <token>") or Telegram initData sent as
+// "Authorization: tma <initData>", the scheme the Mini App SDK uses.
+// In development, ?user_id= is accepted like on the WebSocket endpoint.
+func (s *Server) requestIdentity(r *http.Request) (identity, error) {
+	scheme, cred, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	switch {
+	case strings.EqualFold(scheme, "bearer") && cred != "" && s.sessions != nil:
+		claims, err := s.sessions.Verify(r.Context(), cred)
+		if err != nil {
+			return identity{}, errUnauthenticated
+		}
+		return identity{PlayerID: claims.PlayerID, SessionID: claims.SessionID}, nil
+	case strings.EqualFold(scheme, "tma") && cred != "":
+		user, err := s.initDataUser(cred)
+		if err != nil {
+			return identity{}, err
+		}
+		return identity{PlayerID: user.ID, User: user}, nil
+	}
+	if s.cfg.Env == "development" {
+		if uid := r.URL.Query().Get("user_id"); uid != "" {
+			id, err := strconv.ParseInt(uid, 10, 64)
+			if err != nil {
+				return identity{}, errUnauthenticated
+			}
+			return identity{PlayerID: id}, nil
+		}
+	}
+	return identity{}, errUnauthenticated
+}
+
+func (s *Server) initDataUser(initData string) (*auth.User, error) {
+	if err := s.verifier.Verify(initData); err != nil {
+		return nil, errUnauthenticated
+	}
+	user, err := auth.ParseUser(initData)
+	if err != nil {
+		return nil, errUnauthenticated
+	}
+	return user, nil
+}
@@ -4,49 +4,240 @@ import (
 	"encoding/json"
 	"net/http"
 	"runtime"
-	"sync/atomic"
+	"strconv"
 	"time"
+
+	"github.com/lastclick/lastclick/internal/metrics"
 )
 
-// Metrics collects basic application metrics (no Prometheus dep needed for MVP).
+// Metrics is the application's instrumentation, served in Prometheus text
+// format at /metrics. All methods are safe on a nil *Metrics, so components
+// built without metrics (tests, simulations) need no guards.
 type Metrics struct {
-	wsConnections    atomic.Int64
-	activeRooms      atomic.Int64
-	totalPulses      atomic.Int64
-	totalRoomsPlayed atomic.Int64
-	startTime        time.Time
+	reg       *metrics.Registry
+	startTime time.Time
+
+	wsConnections *metrics.GaugeVec
+	wsConnects    *metrics.CounterVec
+	wsSentBytes   *metrics.CounterVec
+	wsCoalesced   *metrics.CounterVec
+	wsSlowClose   *metrics.CounterVec
+	wsRateLimited *metrics.CounterVec
+	wsReplaced    *metrics.CounterVec
+	httpLimited   *metrics.CounterVec
+	activeRooms   *metrics.GaugeVec
+	pulses        *metrics.CounterVec
+	roundsPlayed  *metrics.CounterVec
+	roundsVoided  *metrics.CounterVec
+	settlements   *metrics.CounterVec
+
+	tickLag       *metrics.HistogramVec
+	broadcast     *metrics.HistogramVec
+	pulseAck      *metrics.HistogramVec
+	roundDuration *metrics.HistogramVec
+	settleTime    *metrics.HistogramVec
 }
 
 func NewMetrics() *Metrics {
-	return &Metrics{startTime: time.Now()}
+	reg := metrics.NewRegistry()
+	m := &Metrics{reg: reg, startTime: time.Now()}
+
+	m.wsConnections = metrics.NewGaugeVec(reg, "lastclick_ws_connections", "Open WebSocket connections.")
+	m.wsConnects = metrics.NewCounterVec(reg, "lastclick_ws_connects_total", "WebSocket connections accepted.")
+	m.wsSentBytes = metrics.NewCounterVec(reg, "lastclick_ws_sent_bytes_total", "Bytes written to WebSocket clients.", "protocol", "message")
+	m.wsCoalesced = metrics.NewCounterVec(reg, "lastclick_ws_coalesced_total", "Queued messages replaced by a newer one of the same type.", "message")
+	m.wsSlowClose = metrics.NewCounterVec(reg, "lastclick_ws_slow_disconnects_total", "Clients disconnected for falling behind.", "reason")
+	m.wsRateLimited = metrics.NewCounterVec(reg, "lastclick_ws_rate_limited_total", "Inbound messages dropped by the per-connection rate limit.")
+	m.wsReplaced = metrics.NewCounterVec(reg, "lastclick_ws_sessions_replaced_total", "Connections closed because the player connected again elsewhere.")
+	m.httpLimited = metrics.NewCounterVec(reg, "lastclick_http_rate_limited_total", "HTTP requests refused by a route rate limit.", "class", "scope")
+	m.activeRooms = metrics.NewGaugeVec(reg, "lastclick_rooms_active", "Rounds currently running.", "type", "tier")
+	m.pulses = metrics.NewCounterVec(reg, "lastclick_pulses_total", "Pulses accepted by a room loop.", "type", "tier")
+	m.roundsPlayed = metrics.NewCounterVec(reg, "lastclick_rounds_played_total", "Rounds that finished normally.", "type", "tier")
+	m.roundsVoided = metrics.NewCounterVec(reg, "lastclick_rounds_voided_total", "Rounds voided by an operator.", "type", "tier")
+	m.settlements = metrics.NewCounterVec(reg, "lastclick_settlements_total", "Settlement attempts by result.", "result")
+
+	m.tickLag = metrics.NewHistogramVec(reg, "lastclick_tick_lag_seconds",
+		"Delay between a scheduled tick and the room loop handling it.",
+		[]float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25}, "type", "tier")
+	m.broadcast = metrics.NewHistogramVec(reg, "lastclick_broadcast_seconds",
+		"Time to fan a message out to every client in a room.",
+		[]float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05}, "message")
+	m.pulseAck = metrics.NewHistogramVec(reg, "lastclick_pulse_ack_seconds",
+		"Time from receiving a pulse to broadcasting its ack.",
+		metrics.DefaultLatencyBuckets, "type", "tier")
+	m.roundDuration = metrics.NewHistogramVec(reg, "lastclick_round_duration_seconds",
+		"Round length from start to finish.",
+		[]float64{10, 30, 60, 90, 120, 180, 300}, "type", "tier")
+	m.settleTime = metrics.NewHistogramVec(reg, "lastclick_settlement_seconds",
+		"Time to settle one round in the database.",
+		metrics.DefaultLatencyBuckets, "result")
+
+	metrics.NewGaugeFunc(reg, "lastclick_uptime_seconds", "Seconds since process start.", func() float64 {
+		return time.Since(m.startTime).Seconds()
+	})
+	metrics.NewGaugeFunc(reg, "go_goroutines", "Number of goroutines.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	metrics.NewGaugeFunc(reg, "go_memstats_heap_alloc_bytes", "Heap bytes allocated and in use.", func() float64 {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		return float64(mem.HeapAlloc)
+	})
+	return m
+}
+
+func tierLabel(tier int) string { return strconv.Itoa(tier) }
+
+func (m *Metrics) WSConnected() {
+	if m == nil {
+		return
+	}
+	m.wsConnections.With().Inc()
+	m.wsConnects.With().Inc()
+}
+
+func (m *Metrics) WSDisconnected() {
+	if m == nil {
+		return
+	}
+	m.wsConnections.With().Dec()
 }
 
-func (m *Metrics) IncrWSConn()      { m.wsConnections.Add(1) }
-func (m *Metrics) DecrWSConn()      { m.wsConnections.Add(-1) }
-func (m *Metrics) IncrRooms()       { m.activeRooms.Add(1) }
-func (m *Metrics) DecrRooms()       { m.activeRooms.Add(-1) }
-func (m *Metrics) IncrPulse()       { m.totalPulses.Add(1) }
-func (m *Metrics) IncrRoomsPlayed() { m.totalRoomsPlayed.Add(1) }
+// WSSent records one frame written to a client, by negotiated protocol.
+func (m *Metrics) WSSent(protocol, msgType string, n int) {
+	if m == nil {
+		return
+	}
+	m.wsSentBytes.With(protocol, msgType).Add(int64(n))
+}
 
-// ServeHTTP exposes metrics as JSON at /metrics.
+func (m *Metrics) WSCoalesced(msgType string) {
+	if m == nil {
+		return
+	}
+	m.wsCoalesced.With(msgType).Inc()
+}
+
+func (m *Metrics) WSSlowDisconnect(reason string) {
+	if m == nil {
+		return
+	}
+	m.wsSlowClose.With(reason).Inc()
+}
+
+func (m *Metrics) WSRateLimited() {
+	if m == nil {
+		return
+	}
+	m.wsRateLimited.With().Inc()
+}
+
+func (m *Metrics) WSSessionReplaced() {
+	if m == nil {
+		return
+	}
+	m.wsReplaced.With().Inc()
+}
+
+func (m *Metrics) HTTPRateLimited(class, scope string) {
+	if m == nil {
+		return
+	}
+	m.httpLimited.With(class, scope).Inc()
+}
+
+func (m *Metrics) RoomStarted(roomType string, tier int) {
+	if m == nil {
+		return
+	}
+	m.activeRooms.With(roomType, tierLabel(tier)).Inc()
+}
+
+// RoomStopped is called whenever a room loop exits, however the round ended.
+func (m *Metrics) RoomStopped(roomType string, tier int) {
+	if m == nil {
+		return
+	}
+	m.activeRooms.With(roomType, tierLabel(tier)).Dec()
+}
+
+func (m *Metrics) RoundFinished(roomType string, tier int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.roundsPlayed.With(roomType, tierLabel(tier)).Inc()
+	m.roundDuration.With(roomType, tierLabel(tier)).Observe(d.Seconds())
+}
+
+func (m *Metrics) RoundVoided(roomType string, tier int) {
+	if m == nil {
+		return
+	}
+	m.roundsVoided.With(roomType, tierLabel(tier)).Inc()
+}
+
+// Pulse records an accepted pulse and how long it took to ack.
+func (m *Metrics) Pulse(roomType string, tier int, ackLatency time.Duration) {
+	if m == nil {
+		return
+	}
+	m.pulses.With(roomType, tierLabel(tier)).Inc()
+	m.pulseAck.With(roomType, tierLabel(tier)).Observe(ackLatency.Seconds())
+}
+
+func (m *Metrics) TickLag(roomType string, tier int, lag time.Duration) {
+	if m == nil {
+		return
+	}
+	m.tickLag.With(roomType, tierLabel(tier)).Observe(lag.Seconds())
+}
+
+func (m *Metrics) Broadcast(msgType string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.broadcast.With(msgType).Observe(d.Seconds())
+}
+
+func (m *Metrics) Settlement(ok bool, d time.Duration) {
+	if m == nil {
+		return
+	}
+	result := "settled"
+	if !ok {
+		result = "failed"
+	}
+	m.settlements.With(result).Inc()
+	m.settleTime.With(result).Observe(d.Seconds())
+}
+
+// ServeHTTP serves the Prometheus text format. ?format=json keeps the small
+// summary the landing page polls.
 func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	var mem runtime.MemStats
-	runtime.ReadMemStats(&mem)
+	if r.URL.Query().Get("format") == "json" {
+		m.serveJSON(w)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.reg.WriteText(w)
+}
+
+func (m *Metrics) serveJSON(w http.ResponseWriter) {
+	var rooms, pulses, played int64
+	m.activeRooms.Each(func(_ []string, g *metrics.Gauge) { rooms += g.Value() })
+	m.pulses.Each(func(_ []string, c *metrics.Counter) { pulses += c.Value() })
+	m.roundsPlayed.Each(func(_ []string, c *metrics.Counter) { played += c.Value() })
 
 	data := map[string]any{
 		"uptime_seconds": int(time.Since(m.startTime).Seconds()),
-		"ws_connections": m.wsConnections.Load(),
-		"active_rooms":   m.activeRooms.Load(),
-		"total_pulses":   m.totalPulses.Load(),
-		"total_rooms":    m.totalRoomsPlayed.Load(),
+		"ws_connections": m.wsConnections.With().Value(),
+		"active_rooms":   rooms,
+		"total_pulses":   pulses,
+		"total_rooms":    played,
 		"goroutines":     runtime.NumGoroutine(),
-		"heap_alloc_mb":  mem.HeapAlloc / 1024 / 1024,
-		"sys_mb":         mem.Sys / 1024 / 1024,
 	}
-
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
 	}
 }
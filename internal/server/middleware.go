@@ -3,92 +3,9 @@ package server
 import (
 	"log/slog"
 	"net/http"
-	"sync"
 	"time"
 )
 
-// RateLimiter implements a simple per-IP token bucket rate limiter.
-type RateLimiter struct {
-	mu      sync.Mutex
-	buckets map[string]*bucket
-	rate    float64
-	burst   int
-}
-
-type bucket struct {
-	tokens   float64
-	lastTime time.Time
-}
-
-func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
-	rl := &RateLimiter{
-		buckets: make(map[string]*bucket),
-		rate:    requestsPerSecond,
-		burst:   burst,
-	}
-	// Periodic cleanup of stale buckets
-	go func() {
-		for range time.Tick(5 * time.Minute) {
-			rl.cleanup()
-		}
-	}()
-	return rl
-}
-
-func (rl *RateLimiter) Allow(key string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	now := time.Now()
-	b, ok := rl.buckets[key]
-	if !ok {
-		b = &bucket{tokens: float64(rl.burst), lastTime: now}
-		rl.buckets[key] = b
-	}
-
-	elapsed := now.Sub(b.lastTime).Seconds()
-	b.tokens += elapsed * rl.rate
-	if b.tokens > float64(rl.burst) {
-		b.tokens = float64(rl.burst)
-	}
-	b.lastTime = now
-
-	if b.tokens < 1 {
-		return false
-	}
-	b.tokens--
-	return true
-}
-
-func (rl *RateLimiter) cleanup() {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-	cutoff := time.Now().Add(-10 * time.Minute)
-	for k, b := range rl.buckets {
-		if b.lastTime.Before(cutoff) {
-			delete(rl.buckets, k)
-		}
-	}
-}
-
-// RateLimitMiddleware wraps an HTTP handler with per-IP rate limiting.
-func RateLimitMiddleware(limiter *RateLimiter, logger *slog.Logger) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ip := r.RemoteAddr
-			if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-				ip = forwarded
-			}
-			if !limiter.Allow(ip) {
-				logger.Warn("rate limited", "ip", ip, "path", r.URL.Path)
-				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
-				return
-			}
-			next.ServeHTTP(w, r)
-		})
-	}
-}
-
 // LoggingMiddleware logs request method, path, status, and duration.
 func LoggingMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// Outbound backpressure. Nothing a client needs is dropped: messages that
+// carry complete state are coalesced, everything else (eliminations, acks,
+// round results) is kept in order, and a client that cannot keep up is
+// disconnected so it reconnects and resumes from the replay buffer. Critical
+// direct messages have no seq to resume from, so they outlive the
+// connection and are handed to the player's next one (see Hub.keepCritical).
+const (
+	outboxSoftLimit = 64              // queue depth at which a client counts as behind
+	outboxHardLimit = 512             // queue depth that disconnects at once
+	maxBehind       = 5 * time.Second // how long a client may stay over the soft limit
+	writeTimeout    = 10 * time.Second
+)
+
+// coalescable messages are full snapshots: a newer one makes a queued older
+// one of the same type redundant.
+var coalescable = map[string]bool{
+	"tick":       true,
+	"room_state": true,
+	"room_list":  true,
+}
+
+// critical messages are direct (unsequenced) messages a player must get even
+// if the connection they were queued on is lost.
+var critical = map[string]bool{
+	"round_result": true,
+}
+
+// OutboxStats describes one client's outbound queue.
+type OutboxStats struct {
+	Queued    int   `json:"queued"`
+	MaxQueued int   `json:"max_queued"`
+	Sent      int64 `json:"sent"`
+	Coalesced int64 `json:"coalesced"`
+}
+
+type outbox struct {
+	mu          sync.Mutex
+	queue       []WSMessage
+	ready       chan struct{} // signalled when there is something to write
+	closed      bool
+	slow        bool // marked for disconnect; further pushes are ignored
+	behindSince time.Time
+	stats       OutboxStats
+}
+
+func newOutbox() *outbox {
+	return &outbox{ready: make(chan struct{}, 1)}
+}
+
+// slowReason values say why push gave up on a client.
+const (
+	slowQueueFull = "queue_full"
+	slowBehind    = "behind"
+)
+
+// push queues msg. coalesced reports whether it replaced an older message;
+// slow is non-empty the first time the client falls too far behind, and the
+// caller must then disconnect it.
+func (o *outbox) push(msg WSMessage, now time.Time) (coalesced bool, slow string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed || o.slow {
+		if o.slow && critical[msg.Type] {
+			o.queue = append(o.queue, msg)
+		}
+		return false, ""
+	}
+	if coalescable[msg.Type] {
+		// Drop the older copy and append the new one at the end, so seqs
+		// stay in increasing order on the wire.
+		for i, q := range o.queue {
+			if q.Type == msg.Type {
+				o.queue = append(o.queue[:i], o.queue[i+1:]...)
+				o.stats.Coalesced++
+				coalesced = true
+				break
+			}
+		}
+	}
+	o.queue = append(o.queue, msg)
+	depth := len(o.queue)
+	o.stats.MaxQueued = max(o.stats.MaxQueued, depth)
+
+	switch {
+	case depth > outboxHardLimit:
+		slow = slowQueueFull
+	case depth > outboxSoftLimit:
+		if o.behindSince.IsZero() {
+			o.behindSince = now
+		} else if now.Sub(o.behindSince) > maxBehind {
+			slow = slowBehind
+		}
+	default:
+		o.behindSince = time.Time{}
+	}
+	if slow != "" {
+		// Everything else is recovered by resume; keep what is not.
+		o.slow = true
+		o.queue = criticalOnly(o.queue)
+	}
+
+	select {
+	case o.ready <- struct{}{}:
+	default:
+	}
+	return coalesced, slow
+}
+
+// drain moves everything queued into buf. closed is true once the outbox has
+// been closed and nothing remains to write.
+func (o *outbox) drain(buf []WSMessage) (msgs []WSMessage, closed bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	buf = append(buf, o.queue...)
+	clear(o.queue)
+	o.queue = o.queue[:0]
+	o.behindSince = time.Time{}
+	return buf, o.closed
+}
+
+// takeCritical removes and returns the critical messages still queued.
+func (o *outbox) takeCritical() []WSMessage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	out := criticalOnly(o.queue)
+	o.queue = o.queue[:0]
+	return out
+}
+
+func criticalOnly(msgs []WSMessage) []WSMessage {
+	var out []WSMessage
+	for _, m := range msgs {
+		if critical[m.Type] {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func (o *outbox) close() {
+	o.mu.Lock()
+	o.closed = true
+	o.mu.Unlock()
+	select {
+	case o.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (o *outbox) isClosed() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.closed
+}
+
+func (o *outbox) sent() {
+	o.mu.Lock()
+	o.stats.Sent++
+	o.mu.Unlock()
+}
+
+func (o *outbox) snapshot() OutboxStats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	s := o.stats
+	s.Queued = len(o.queue)
+	return s
+}
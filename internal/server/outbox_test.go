@@ -0,0 +1,119 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func types(msgs []WSMessage) []string {
+	out := make([]string, len(msgs))
+	for i, m := range msgs {
+		out[i] = m.Type
+	}
+	return out
+}
+
+func TestOutboxCoalescesSnapshotsKeepsEvents(t *testing.T) {
+	o := newOutbox()
+	now := time.Now()
+	for i, typ := range []string{"tick", "elimination", "tick", "room_state", "pulse_ack", "tick", "room_state"} {
+		o.push(WSMessage{Type: typ, Seq: uint64(i + 1)}, now)
+	}
+	msgs, closed := o.drain(nil)
+	if closed {
+		t.Fatal("outbox reported closed")
+	}
+	got := types(msgs)
+	want := []string{"elimination", "pulse_ack", "tick", "room_state"}
+	if len(got) != len(want) {
+		t.Fatalf("queue = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("queue = %v, want %v", got, want)
+		}
+	}
+	for i := 1; i < len(msgs); i++ {
+		if msgs[i].Seq <= msgs[i-1].Seq {
+			t.Fatalf("seqs out of order: %v", msgs)
+		}
+	}
+	if s := o.snapshot(); s.Coalesced != 3 || s.Queued != 0 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestOutboxHardLimit(t *testing.T) {
+	o := newOutbox()
+	now := time.Now()
+	var slow string
+	for i := 0; i <= outboxHardLimit && slow == ""; i++ {
+		_, slow = o.push(WSMessage{Type: "elimination"}, now)
+	}
+	if slow != slowQueueFull {
+		t.Fatalf("slow = %q, want %q", slow, slowQueueFull)
+	}
+	if _, again := o.push(WSMessage{Type: "elimination"}, now); again != "" {
+		t.Fatal("slow reported twice")
+	}
+}
+
+func TestOutboxBehindTooLong(t *testing.T) {
+	o := newOutbox()
+	start := time.Now()
+	for i := 0; i <= outboxSoftLimit; i++ {
+		if _, slow := o.push(WSMessage{Type: "pulse_ack"}, start); slow != "" {
+			t.Fatalf("disconnected at depth %d", i+1)
+		}
+	}
+	// Catching up resets the clock.
+	o.drain(nil)
+	for i := 0; i <= outboxSoftLimit; i++ {
+		o.push(WSMessage{Type: "pulse_ack"}, start.Add(maxBehind))
+	}
+	if _, slow := o.push(WSMessage{Type: "pulse_ack"}, start.Add(maxBehind+time.Second)); slow != "" {
+		t.Fatal("disconnected although the client caught up in between")
+	}
+	if _, slow := o.push(WSMessage{Type: "pulse_ack"}, start.Add(2*maxBehind+time.Second)); slow != slowBehind {
+		t.Fatalf("slow = %q, want %q", slow, slowBehind)
+	}
+}
+
+func TestOutboxKeepsRoundResultPastHardLimit(t *testing.T) {
+	o := newOutbox()
+	now := time.Now()
+	o.push(WSMessage{Type: "round_result"}, now)
+	var slow string
+	for i := 0; i <= outboxHardLimit && slow == ""; i++ {
+		_, slow = o.push(WSMessage{Type: "elimination", Seq: uint64(i + 1)}, now)
+	}
+	if slow != slowQueueFull {
+		t.Fatalf("slow = %q, want %q", slow, slowQueueFull)
+	}
+	// Results sent after the client was marked slow are kept too.
+	o.push(WSMessage{Type: "round_result"}, now)
+	o.push(WSMessage{Type: "elimination"}, now)
+
+	got := types(o.takeCritical())
+	if len(got) != 2 || got[0] != "round_result" || got[1] != "round_result" {
+		t.Fatalf("critical = %v, want two round_results", got)
+	}
+	if s := o.snapshot(); s.Queued != 0 {
+		t.Fatalf("queued = %d after takeCritical", s.Queued)
+	}
+}
+
+func TestHubDeliversRoundResultOnNextConnection(t *testing.T) {
+	hub := NewHub("123:test", true, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	hub.SendTo(7, WSMessage{Type: "round_result"})
+	hub.SendTo(7, WSMessage{Type: "pulse_ack"})
+
+	c := &Client{ID: 7, out: newOutbox()}
+	hub.register(c)
+	msgs, _ := c.out.drain(nil)
+	if got := types(msgs); len(got) != 1 || got[0] != "round_result" {
+		t.Fatalf("delivered %v, want [round_result]", got)
+	}
+}
@@ -0,0 +1,300 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket: Rate tokens per second, holding at most Burst.
+// The zero Limit means unlimited.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Decision is the outcome of taking one token from a bucket.
+type Decision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // until a token is available; zero when allowed
+	Reset      time.Duration // until the bucket is full again
+}
+
+func decide(l Limit, allowed bool, tokens float64) Decision {
+	d := Decision{
+		Allowed:   allowed,
+		Limit:     l.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(l.Burst) - tokens) / l.Rate * float64(time.Second)),
+	}
+	if !allowed {
+		d.RetryAfter = time.Duration((1 - tokens) / l.Rate * float64(time.Second))
+	}
+	return d
+}
+
+// LimitStore holds token buckets by key. RateLimiter keeps them in process;
+// RedisLimiter shares them between instances.
+type LimitStore interface {
+	Take(ctx context.Context, key string, l Limit) (Decision, error)
+}
+
+// RateLimiter is an in-process LimitStore.
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens   float64
+	lastTime time.Time
+}
+
+func NewRateLimiter() *RateLimiter {
+	rl := &RateLimiter{
+		buckets: make(map[string]*bucket),
+	}
+	// Periodic cleanup of stale buckets
+	go func() {
+		for range time.Tick(5 * time.Minute) {
+			rl.cleanup()
+		}
+	}()
+	return rl
+}
+
+func (rl *RateLimiter) Take(_ context.Context, key string, l Limit) (Decision, error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = newBucket(l.Burst, now)
+		rl.buckets[key] = b
+	}
+	allowed := b.take(now, l.Rate, l.Burst)
+	return decide(l, allowed, b.tokens), nil
+}
+
+func newBucket(burst int, now time.Time) *bucket {
+	return &bucket{tokens: float64(burst), lastTime: now}
+}
+
+// take refills the bucket for the time since its last use and spends one
+// token if there is one. Not safe for concurrent use.
+func (b *bucket) take(now time.Time, rate float64, burst int) bool {
+	elapsed := now.Sub(b.lastTime).Seconds()
+	b.tokens += elapsed * rate
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.lastTime = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (rl *RateLimiter) cleanup() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	cutoff := time.Now().Add(-10 * time.Minute)
+	for k, b := range rl.buckets {
+		if b.lastTime.Before(cutoff) {
+			delete(rl.buckets, k)
+		}
+	}
+}
+
+// RouteLimit is the budget for requests whose path starts with Prefix.
+// PerIP applies to every request, PerPlayer additionally to authenticated ones.
+type RouteLimit struct {
+	Class     string
+	Prefix    string
+	PerIP     Limit
+	PerPlayer Limit
+}
+
+// DefaultRouteLimits go from most to least specific; the first prefix that
+// matches wins. Payments are tight because each one calls Telegram; auth is
+// tight because it verifies signatures; leaderboards are cheap Redis reads.
+var DefaultRouteLimits = []RouteLimit{
+	{Class: "auth", Prefix: "/api/auth/", PerIP: Limit{Rate: 1, Burst: 10}, PerPlayer: Limit{Rate: 0.5, Burst: 5}},
+	{Class: "payments", Prefix: "/api/payments/", PerIP: Limit{Rate: 1, Burst: 5}, PerPlayer: Limit{Rate: 0.2, Burst: 3}},
+	{Class: "leaderboard", Prefix: "/api/leaderboard/", PerIP: Limit{Rate: 20, Burst: 60}, PerPlayer: Limit{Rate: 5, Burst: 20}},
+	{Class: "ws", Prefix: "/ws", PerIP: Limit{Rate: 1, Burst: 10}},
+	{Class: "admin", Prefix: "/admin/", PerIP: Limit{Rate: 5, Burst: 20}},
+	{Class: "default", Prefix: "/", PerIP: Limit{Rate: 30, Burst: 60}, PerPlayer: Limit{Rate: 10, Burst: 30}},
+}
+
+// TrustedProxies are the peers allowed to tell us the client address through
+// X-Forwarded-For, normally just the local nginx.
+type TrustedProxies []netip.Prefix
+
+func (t TrustedProxies) trusts(addr netip.Addr) bool {
+	for _, p := range t {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of whoever sent the request. Forwarding
+// headers are only believed when the connection comes from a trusted proxy,
+// and then X-Forwarded-For is read right to left, skipping further trusted
+// hops, so a client cannot pick its own address by prepending entries.
+func (t TrustedProxies) ClientIP(r *http.Request) netip.Addr {
+	peer := parseAddr(r.RemoteAddr)
+	if !peer.IsValid() || !t.trusts(peer) {
+		return peer
+	}
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	if len(hops) == 0 {
+		if real := parseAddr(r.Header.Get("X-Real-IP")); real.IsValid() {
+			return real
+		}
+		return peer
+	}
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr := parseAddr(hops[i])
+		if !addr.IsValid() {
+			break
+		}
+		client = addr
+		if !t.trusts(addr) {
+			break
+		}
+	}
+	return client
+}
+
+func parseAddr(s string) netip.Addr {
+	s = strings.TrimSpace(s)
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// ipKey buckets IPv6 clients by /64, the smallest block a host is given.
+func ipKey(addr netip.Addr) string {
+	if !addr.IsValid() {
+		return "unknown"
+	}
+	if addr.Is6() {
+		p, _ := addr.Prefix(64)
+		return p.String()
+	}
+	return addr.String()
+}
+
+// RouteLimiter enforces route limits per client IP and per player.
+type RouteLimiter struct {
+	store   LimitStore
+	routes  []RouteLimit
+	trusted TrustedProxies
+	metrics *Metrics
+	logger  *slog.Logger
+}
+
+func NewRouteLimiter(store LimitStore, routes []RouteLimit, trusted TrustedProxies, logger *slog.Logger) *RouteLimiter {
+	return &RouteLimiter{store: store, routes: routes, trusted: trusted, logger: logger}
+}
+
+func (l *RouteLimiter) route(path string) (RouteLimit, bool) {
+	for _, rt := range l.routes {
+		if strings.HasPrefix(path, rt.Prefix) {
+			return rt, true
+		}
+	}
+	return RouteLimit{}, false
+}
+
+// allowIP takes a token from the caller's per-IP bucket for this route.
+func (l *RouteLimiter) allowIP(w http.ResponseWriter, r *http.Request) bool {
+	rt, ok := l.route(r.URL.Path)
+	if !ok {
+		return true
+	}
+	return l.allow(w, r, rt, rt.PerIP, "ip", ipKey(l.trusted.ClientIP(r)))
+}
+
+// allowPlayer takes a token from the player's bucket for this route. Safe on
+// a nil *RouteLimiter.
+func (l *RouteLimiter) allowPlayer(w http.ResponseWriter, r *http.Request, playerID int64) bool {
+	if l == nil {
+		return true
+	}
+	rt, ok := l.route(r.URL.Path)
+	if !ok {
+		return true
+	}
+	return l.allow(w, r, rt, rt.PerPlayer, "player", strconv.FormatInt(playerID, 10))
+}
+
+// allow sets the RateLimit-* headers from the bucket, and on refusal answers
+// 429 with Retry-After. A player's bucket is checked after the IP's, so
+// authenticated responses describe the per-player budget.
+func (l *RouteLimiter) allow(w http.ResponseWriter, r *http.Request, rt RouteLimit, lim Limit, scope, id string) bool {
+	if lim.unlimited() {
+		return true
+	}
+	d, err := l.store.Take(r.Context(), "rl:"+rt.Class+":"+scope+":"+id, lim)
+	if err != nil {
+		l.logger.Error("rate limit store", "err", err)
+		return true
+	}
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(max(d.Remaining, 0)))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+	if d.Allowed {
+		return true
+	}
+	l.metrics.HTTPRateLimited(rt.Class, scope)
+	l.logger.Warn("rate limited", "class", rt.Class, "scope", scope, "key", id, "path", r.URL.Path)
+	h.Set("Retry-After", strconv.Itoa(max(ceilSeconds(d.RetryAfter), 1)))
+	http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+	return false
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// RateLimitMiddleware applies the per-IP route limits.
+func RateLimitMiddleware(limiter *RouteLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !limiter.allowIP(w, r) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript refills and spends from a hash {t: tokens, ts: ms}.
+// It reads the clock from Redis so instances with skewed clocks agree, and
+// returns tokens as a string because Lua numbers are truncated in replies.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+local b = redis.call('HMGET', KEYS[1], 't', 'ts')
+local tokens = tonumber(b[1])
+local ts = tonumber(b[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 't', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisLimiter is a LimitStore shared by every instance. While Redis is
+// unreachable it falls back to per-process buckets rather than failing
+// requests or letting them through unlimited.
+type RedisLimiter struct {
+	rdb      *redis.Client
+	fallback *RateLimiter
+	logger   *slog.Logger
+	warned   atomic.Int64 // unix seconds of the last fallback warning
+}
+
+func NewRedisLimiter(rdb *redis.Client, logger *slog.Logger) *RedisLimiter {
+	return &RedisLimiter{rdb: rdb, fallback: NewRateLimiter(), logger: logger}
+}
+
+func (rl *RedisLimiter) Take(ctx context.Context, key string, l Limit) (Decision, error) {
+	res, err := tokenBucketScript.Run(ctx, rl.rdb, []string{key}, l.Rate, l.Burst).Slice()
+	if err == nil {
+		var d Decision
+		if d, err = parseBucketReply(l, res); err == nil {
+			return d, nil
+		}
+	}
+	if now := time.Now().Unix(); rl.warned.Swap(now) < now-10 {
+		rl.logger.Warn("redis rate limit unavailable, using local buckets", "err", err)
+	}
+	return rl.fallback.Take(ctx, key, l)
+}
+
+func parseBucketReply(l Limit, res []any) (Decision, error) {
+	if len(res) != 2 {
+		return Decision{}, fmt.Errorf("token bucket: unexpected reply %v", res)
+	}
+	allowed, ok := res[0].(int64)
+	if !ok {
+		return Decision{}, fmt.Errorf("token bucket: bad allowed %v", res[0])
+	}
+	s, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return Decision{}, fmt.Errorf("token bucket: bad tokens %q", s)
+	}
+	return decide(l, allowed == 1, tokens), nil
+}
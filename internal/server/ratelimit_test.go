@@ -0,0 +1,90 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := TrustedProxies{netip.MustParsePrefix("127.0.0.1/32"), netip.MustParsePrefix("10.0.0.0/8")}
+	cases := []struct {
+		name   string
+		remote string
+		xff    string
+		want   string
+	}{
+		{"direct client", "203.0.113.5:4000", "", "203.0.113.5"},
+		{"untrusted peer ignores header", "203.0.113.5:4000", "1.2.3.4", "203.0.113.5"},
+		{"nginx", "127.0.0.1:5555", "198.51.100.7", "198.51.100.7"},
+		{"spoofed prefix", "127.0.0.1:5555", "1.2.3.4, 198.51.100.7", "198.51.100.7"},
+		{"trusted hops skipped", "127.0.0.1:5555", "198.51.100.7, 10.1.2.3", "198.51.100.7"},
+		{"garbage stops the walk", "127.0.0.1:5555", "1.2.3.4, nonsense, 10.1.2.3", "10.1.2.3"},
+		{"ipv6 peer", "[2001:db8::1]:443", "", "2001:db8::1"},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tc.remote
+		if tc.xff != "" {
+			r.Header.Set("X-Forwarded-For", tc.xff)
+		}
+		if got := trusted.ClientIP(r).String(); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIPKeyGroupsIPv6By64(t *testing.T) {
+	a := ipKey(netip.MustParseAddr("2001:db8:1:2::1"))
+	b := ipKey(netip.MustParseAddr("2001:db8:1:2:ffff::9"))
+	if a != b || a != "2001:db8:1:2::/64" {
+		t.Errorf("got %s and %s", a, b)
+	}
+}
+
+func TestRouteLimiterHeadersAndRetryAfter(t *testing.T) {
+	routes := []RouteLimit{
+		{Class: "payments", Prefix: "/api/payments/", PerIP: Limit{Rate: 1, Burst: 2}, PerPlayer: Limit{Rate: 1, Burst: 1}},
+		{Class: "default", Prefix: "/", PerIP: Limit{Rate: 100, Burst: 100}},
+	}
+	l := NewRouteLimiter(NewRateLimiter(), routes, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h := RateLimitMiddleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(path, ip string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, nil)
+		r.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		w := do("/api/payments/invoice", "198.51.100.1")
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != wantRemaining {
+			t.Fatalf("request %d: %d remaining=%q", i, w.Code, w.Header().Get("RateLimit-Remaining"))
+		}
+	}
+	w := do("/api/payments/invoice", "198.51.100.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("over limit: %d retry-after=%q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := do("/api/payments/invoice", "198.51.100.2"); w.Code != http.StatusOK {
+		t.Errorf("other IP limited: %d", w.Code)
+	}
+	if w := do("/api/rounds", "198.51.100.1"); w.Code != http.StatusOK {
+		t.Errorf("other route limited: %d", w.Code)
+	}
+
+	// The per-player budget holds across addresses.
+	for i, ip := range []string{"198.51.100.3", "198.51.100.4"} {
+		r := httptest.NewRequest(http.MethodPost, "/api/payments/invoice", nil)
+		r.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		if got, want := l.allowPlayer(w, r, 42), i == 0; got != want {
+			t.Errorf("player request %d from %s: allowed=%v", i, ip, got)
+		}
+	}
+}
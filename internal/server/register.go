@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/lastclick/lastclick/internal/auth"
+	"github.com/lastclick/lastclick/internal/store"
+)
+
+// Registrar creates or refreshes a player's row from their Telegram profile
+// whenever they authenticate, so balances and rosters always have a player to
+// point at. New players receive the starter grant.
+type Registrar struct {
+	players *store.PlayerStore
+	starter store.StarterGrant
+	logger  *slog.Logger
+}
+
+func NewRegistrar(players *store.PlayerStore, starter store.StarterGrant, logger *slog.Logger) *Registrar {
+	return &Registrar{players: players, starter: starter, logger: logger}
+}
+
+// Register upserts the player described by u and returns the stored row.
+func (g *Registrar) Register(ctx context.Context, u *auth.User) (*store.Player, error) {
+	p, created, err := g.players.UpsertProfile(ctx, store.Profile{
+		ID:           u.ID,
+		Username:     u.DisplayName(),
+		FirstName:    u.FirstName,
+		LastName:     u.LastName,
+		LanguageCode: u.LanguageCode,
+		IsPremium:    u.IsPremium,
+		PhotoURL:     u.PhotoURL,
+	}, g.starter)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		g.logger.Info("player registered", "player", p.ID, "stars", g.starter.Stars, "shards", g.starter.Shards)
+	}
+	return p, nil
+}
+
+// Username returns the stored display name, or "" when the player is unknown.
+func (g *Registrar) Username(ctx context.Context, id int64) string {
+	p, err := g.players.Get(ctx, id)
+	if err != nil || p == nil {
+		return ""
+	}
+	return p.Username
+}
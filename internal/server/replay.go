@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// replayBufferSize bounds how many broadcasts per room a resuming client can
+// catch up on. A player gone longer than the pulse window is eliminated
+// anyway, so a short buffer covers real reconnects; beyond it a snapshot is sent.
+const replayBufferSize = 128
+
+// roomStream numbers a room's broadcasts and keeps the most recent ones.
+// Its mutex also orders fan-out, so clients see seqs in increasing order.
+type roomStream struct {
+	mu  sync.Mutex
+	seq uint64
+	buf []WSMessage // ring of the last replayBufferSize messages
+	pos int         // next write index once buf is full
+}
+
+func (s *roomStream) append(msg WSMessage) {
+	if len(s.buf) < replayBufferSize {
+		s.buf = append(s.buf, msg)
+		return
+	}
+	s.buf[s.pos] = msg
+	s.pos = (s.pos + 1) % replayBufferSize
+}
+
+// since returns the buffered messages after lastSeq, oldest first. ok is false
+// when the buffer no longer reaches back to lastSeq+1, or lastSeq is from
+// another stream (ahead of this one, e.g. after a server restart).
+func (s *roomStream) since(lastSeq uint64) (msgs []WSMessage, ok bool) {
+	if lastSeq > s.seq {
+		return nil, false
+	}
+	oldest := s.seq - uint64(len(s.buf)) + 1
+	if lastSeq+1 < oldest {
+		return nil, false
+	}
+	n := int(s.seq - lastSeq)
+	msgs = make([]WSMessage, 0, n)
+	for i := len(s.buf) - n; i < len(s.buf); i++ {
+		msgs = append(msgs, s.buf[(s.pos+i)%len(s.buf)])
+	}
+	return msgs, true
+}
+
+func (h *Hub) stream(roomID string) *roomStream {
+	h.mu.RLock()
+	st, ok := h.streams[roomID]
+	h.mu.RUnlock()
+	if ok {
+		return st
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if st, ok = h.streams[roomID]; !ok {
+		st = &roomStream{}
+		h.streams[roomID] = st
+	}
+	return st
+}
+
+// Resume puts a reconnecting client back into a room and sends what it missed
+// after lastSeq. A "resumed" message goes first; then either the missed
+// broadcasts in order, or, when they are no longer buffered, snapshot stamped
+// with the current seq. Holding the stream lock means no broadcast lands
+// between the replay and live traffic.
+func (h *Hub) Resume(clientID int64, roomID string, lastSeq uint64, snapshot WSMessage) {
+	st := h.stream(roomID)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	h.JoinRoom(clientID, roomID)
+	missed, ok := st.since(lastSeq)
+	payload, _ := json.Marshal(map[string]any{
+		"room_id":  roomID,
+		"seq":      st.seq,
+		"replayed": len(missed),
+		"snapshot": !ok,
+	})
+	h.SendTo(clientID, WSMessage{Type: "resumed", Payload: payload})
+	if !ok {
+		snapshot.Seq = st.seq
+		h.SendTo(clientID, snapshot)
+		return
+	}
+	for _, msg := range missed {
+		h.SendTo(clientID, msg)
+	}
+}
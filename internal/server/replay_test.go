@@ -0,0 +1,45 @@
+package server
+
+import "testing"
+
+func pushN(s *roomStream, n int) {
+	for i := 0; i < n; i++ {
+		s.seq++
+		s.append(WSMessage{Type: "tick", Seq: s.seq})
+	}
+}
+
+func TestRoomStreamSince(t *testing.T) {
+	s := &roomStream{}
+	pushN(s, 10)
+
+	msgs, ok := s.since(7)
+	if !ok || len(msgs) != 3 || msgs[0].Seq != 8 || msgs[2].Seq != 10 {
+		t.Fatalf("since(7) = %v, %v", msgs, ok)
+	}
+	if msgs, ok := s.since(10); !ok || len(msgs) != 0 {
+		t.Fatalf("since(current) = %v, %v", msgs, ok)
+	}
+	if _, ok := s.since(11); ok {
+		t.Fatal("since(ahead) should require a snapshot")
+	}
+}
+
+func TestRoomStreamWraps(t *testing.T) {
+	s := &roomStream{}
+	pushN(s, replayBufferSize+50)
+
+	oldest := uint64(51)
+	msgs, ok := s.since(oldest - 1)
+	if !ok || len(msgs) != replayBufferSize {
+		t.Fatalf("since(oldest-1): %d msgs, ok=%v", len(msgs), ok)
+	}
+	for i, m := range msgs {
+		if m.Seq != oldest+uint64(i) {
+			t.Fatalf("msgs[%d].Seq = %d, want %d", i, m.Seq, oldest+uint64(i))
+		}
+	}
+	if _, ok := s.since(oldest - 2); ok {
+		t.Fatal("gap past buffer should require a snapshot")
+	}
+}
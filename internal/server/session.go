@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lastclick/lastclick/internal/auth"
+	"github.com/lastclick/lastclick/internal/cache"
+	"github.com/lastclick/lastclick/internal/sanction"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// SessionTokens is returned when initData is exchanged or a session is refreshed.
+type SessionTokens struct {
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// SessionManager issues signed session tokens and tracks live sessions in Redis.
+// A token is only accepted while its session key exists, so deleting the key
+// (logout, ban) revokes both access and refresh tokens immediately.
+type SessionManager struct {
+	secret []byte
+	rdb    *redis.Client
+}
+
+func NewSessionManager(secret []byte, rdb *redis.Client) *SessionManager {
+	return &SessionManager{secret: secret, rdb: rdb}
+}
+
+// Issue starts a new session for a player whose initData was just validated.
+func (m *SessionManager) Issue(ctx context.Context, playerID int64) (*SessionTokens, error) {
+	sid := uuid.New().String()
+	pipe := m.rdb.TxPipeline()
+	pipe.Set(ctx, fmt.Sprintf(cache.KeySession, sid), playerID, refreshTokenTTL)
+	pipe.SAdd(ctx, fmt.Sprintf(cache.KeyPlayerSessions, playerID), sid)
+	pipe.Expire(ctx, fmt.Sprintf(cache.KeyPlayerSessions, playerID), refreshTokenTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("store session: %w", err)
+	}
+	return m.sign(playerID, sid, time.Now())
+}
+
+// Refresh rotates a refresh token: the old session is revoked and a new one
+// issued. A refresh token that was already used is rejected.
+func (m *SessionManager) Refresh(ctx context.Context, refreshToken string) (*SessionTokens, error) {
+	claims, err := auth.ParseSession(refreshToken, m.secret, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Kind != auth.RefreshToken {
+		return nil, fmt.Errorf("not a refresh token")
+	}
+	n, err := m.rdb.Del(ctx, fmt.Sprintf(cache.KeySession, claims.SessionID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("revoke session: %w", err)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("session revoked")
+	}
+	m.rdb.SRem(ctx, fmt.Sprintf(cache.KeyPlayerSessions, claims.PlayerID), claims.SessionID)
+	return m.Issue(ctx, claims.PlayerID)
+}
+
+// Verify checks an access token and that its session has not been revoked.
+func (m *SessionManager) Verify(ctx context.Context, accessToken string) (*auth.SessionClaims, error) {
+	claims, err := auth.ParseSession(accessToken, m.secret, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Kind != auth.AccessToken {
+		return nil, fmt.Errorf("not an access token")
+	}
+	owner, err := m.rdb.Get(ctx, fmt.Sprintf(cache.KeySession, claims.SessionID)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("session revoked")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load session: %w", err)
+	}
+	if owner != strconv.FormatInt(claims.PlayerID, 10) {
+		return nil, fmt.Errorf("session owner mismatch")
+	}
+	return claims, nil
+}
+
+// Revoke ends a single session.
+func (m *SessionManager) Revoke(ctx context.Context, playerID int64, sessionID string) error {
+	pipe := m.rdb.TxPipeline()
+	pipe.Del(ctx, fmt.Sprintf(cache.KeySession, sessionID))
+	pipe.SRem(ctx, fmt.Sprintf(cache.KeyPlayerSessions, playerID), sessionID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RevokePlayer ends every session of a player, e.g. when they are banned.
+func (m *SessionManager) RevokePlayer(ctx context.Context, playerID int64) error {
+	setKey := fmt.Sprintf(cache.KeyPlayerSessions, playerID)
+	sids, err := m.rdb.SMembers(ctx, setKey).Result()
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(sids)+1)
+	for _, sid := range sids {
+		keys = append(keys, fmt.Sprintf(cache.KeySession, sid))
+	}
+	keys = append(keys, setKey)
+	return m.rdb.Del(ctx, keys...).Err()
+}
+
+func (m *SessionManager) sign(playerID int64, sid string, now time.Time) (*SessionTokens, error) {
+	accessExp := now.Add(accessTokenTTL)
+	refreshExp := now.Add(refreshTokenTTL)
+	access, err := auth.SignSession(auth.SessionClaims{
+		PlayerID:  playerID,
+		SessionID: sid,
+		Kind:      auth.AccessToken,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: accessExp.Unix(),
+	}, m.secret)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := auth.SignSession(auth.SessionClaims{
+		PlayerID:  playerID,
+		SessionID: sid,
+		Kind:      auth.RefreshToken,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: refreshExp.Unix(),
+	}, m.secret)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionTokens{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExp,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: refreshExp,
+	}, nil
+}
+
+// handleCreateSession exchanges initData ("Authorization: tma <initData>") for
+// a session token pair that stays valid after initData's 5-minute window.
+func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := s.requestIdentity(r)
+	if err != nil || id.SessionID != "" {
+		w.Header().Set("WWW-Authenticate", "tma")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := s.sanctionChecker.Check(r.Context(), id.PlayerID, sanction.Connect); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if s.registrar != nil && id.User != nil {
+		if _, err := s.registrar.Register(r.Context(), id.User); err != nil {
+			s.logger.Error("register player", "player", id.PlayerID, "err", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
+	tokens, err := s.sessions.Issue(r.Context(), id.PlayerID)
+	if err != nil {
+		s.logger.Error("issue session", "player", id.PlayerID, "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, tokens)
+}
+
+func (s *Server) handleRefreshSession(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	tokens, err := s.sessions.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, tokens)
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	playerID, _ := PlayerIDFromContext(r.Context())
+	if sid, ok := r.Context().Value(sessionIDKey).(string); ok && s.sessions != nil {
+		if err := s.sessions.Revoke(r.Context(), playerID, sid); err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
+	writeJSON(w, map[string]string{"status": "logged_out"})
+}
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lastclick/lastclick/internal/auth"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis speaks just enough RESP2 for SessionManager: strings, sets and
+// MULTI/EXEC. Expiry is accepted and ignored.
+type fakeRedis struct {
+	mu   sync.Mutex
+	strs map[string]string
+	sets map[string]map[string]bool
+}
+
+func newFakeRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	f := &fakeRedis{strs: map[string]string{}, sets: map[string]map[string]bool{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	rdb := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "MULTI":
+			inMulti, queued = true, nil
+			w.WriteString("+OK\r\n")
+		case cmd == "EXEC":
+			fmt.Fprintf(w, "*%d\r\n", len(queued))
+			for _, q := range queued {
+				w.WriteString(f.exec(q))
+			}
+			inMulti = false
+		case inMulti:
+			queued = append(queued, args)
+			w.WriteString("+QUEUED\r\n")
+		default:
+			w.WriteString(f.exec(args))
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "SET":
+		f.strs[args[1]] = args[2]
+		return "+OK\r\n"
+	case "GET":
+		v, ok := f.strs[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "DEL":
+		n := 0
+		for _, k := range args[1:] {
+			if _, ok := f.strs[k]; ok {
+				n++
+			} else if _, ok := f.sets[k]; ok {
+				n++
+			}
+			delete(f.strs, k)
+			delete(f.sets, k)
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "SADD":
+		if f.sets[args[1]] == nil {
+			f.sets[args[1]] = map[string]bool{}
+		}
+		for _, m := range args[2:] {
+			f.sets[args[1]][m] = true
+		}
+		return fmt.Sprintf(":%d\r\n", len(args)-2)
+	case "SREM":
+		for _, m := range args[2:] {
+			delete(f.sets[args[1]], m)
+		}
+		return fmt.Sprintf(":%d\r\n", len(args)-2)
+	case "SMEMBERS":
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%d\r\n", len(f.sets[args[1]]))
+		for m := range f.sets[args[1]] {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(m), m)
+		}
+		return b.String()
+	case "EXPIRE":
+		return ":1\r\n"
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("bad command header %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil { // $<len>
+			return nil, err
+		}
+		s, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(s, "\r\n")
+	}
+	return args, nil
+}
+
+func TestSessionRefreshRotates(t *testing.T) {
+	ctx := context.Background()
+	m := NewSessionManager([]byte("secret"), newFakeRedis(t))
+
+	first, err := m.Issue(ctx, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := m.Verify(ctx, first.AccessToken); err != nil || c.PlayerID != 42 {
+		t.Fatalf("fresh access token: %+v, %v", c, err)
+	}
+	if _, err := m.Verify(ctx, first.RefreshToken); err == nil {
+		t.Fatal("refresh token accepted as an access token")
+	}
+
+	second, err := m.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Refresh(ctx, first.RefreshToken); err == nil {
+		t.Fatal("rotated refresh token accepted again")
+	}
+	if _, err := m.Verify(ctx, first.AccessToken); err == nil {
+		t.Fatal("access token of the rotated session still accepted")
+	}
+	if _, err := m.Verify(ctx, second.AccessToken); err != nil {
+		t.Fatalf("new access token: %v", err)
+	}
+
+	if err := m.RevokePlayer(ctx, 42); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Verify(ctx, second.AccessToken); err == nil {
+		t.Fatal("access token accepted after RevokePlayer")
+	}
+	if _, err := m.Refresh(ctx, second.RefreshToken); err == nil {
+		t.Fatal("refresh token accepted after RevokePlayer")
+	}
+}
+
+func TestSessionVerifyRejectsForgedOwner(t *testing.T) {
+	ctx := context.Background()
+	m := NewSessionManager([]byte("secret"), newFakeRedis(t))
+	tokens, err := m.Issue(ctx, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := auth.ParseSession(tokens.AccessToken, []byte("secret"), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A validly signed token naming another player for the same session.
+	claims.PlayerID = 7
+	forged, _ := auth.SignSession(*claims, []byte("secret"))
+	if _, err := m.Verify(ctx, forged); err == nil {
+		t.Fatal("session accepted for a player who does not own it")
+	}
+}
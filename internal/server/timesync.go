@@ -0,0 +1,140 @@
+package server
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// clockSamples is how many recent time_sync samples feed a client's estimate.
+const clockSamples = 8
+
+// TimeSample is one NTP-style exchange, all in unix milliseconds: the client
+// sends at ClientSend, the server receives at ServerRecv and replies at
+// ServerSend, and the client receives the reply at ClientRecv.
+type TimeSample struct {
+	ClientSend int64
+	ServerRecv int64
+	ServerSend int64
+	ClientRecv int64
+}
+
+// Offset is how far the server clock is ahead of the client's.
+func (s TimeSample) Offset() int64 {
+	return ((s.ServerRecv - s.ClientSend) + (s.ServerSend - s.ClientRecv)) / 2
+}
+
+// RTT is the network round trip, excluding server processing time.
+func (s TimeSample) RTT() int64 {
+	return (s.ClientRecv - s.ClientSend) - (s.ServerSend - s.ServerRecv)
+}
+
+// ClockEstimate is the best current guess at a client's clock offset.
+type ClockEstimate struct {
+	OffsetMs  int64     `json:"offset_ms"`
+	RTTMs     int64     `json:"rtt_ms"`
+	Samples   int       `json:"samples"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EstimateClock takes the offset from the sample with the lowest RTT: queueing
+// delay is what makes the two legs asymmetric, and the fastest exchange has
+// the least of it. Samples with a negative RTT are inconsistent and skipped.
+func EstimateClock(samples []TimeSample) (ClockEstimate, bool) {
+	var best TimeSample
+	n := 0
+	for _, s := range samples {
+		if s.RTT() < 0 {
+			continue
+		}
+		if n == 0 || s.RTT() < best.RTT() {
+			best = s
+		}
+		n++
+	}
+	if n == 0 {
+		return ClockEstimate{}, false
+	}
+	return ClockEstimate{OffsetMs: best.Offset(), RTTMs: best.RTT(), Samples: n}, true
+}
+
+// clockSync holds one client's time_sync state. The server only learns
+// ClientRecv when the next request echoes it, so the last reply stays pending.
+type clockSync struct {
+	mu       sync.Mutex
+	pending  *TimeSample
+	samples  []TimeSample
+	estimate ClockEstimate
+	ok       bool
+}
+
+type timeSyncRequest struct {
+	ClientMs int64 `json:"client_ms"`
+	// Echo of the previous reply: its client_ms and when it arrived.
+	AckClientMs int64 `json:"ack_client_ms,omitempty"`
+	AckRecvMs   int64 `json:"ack_recv_ms,omitempty"`
+}
+
+type timeSyncResponse struct {
+	ClientMs     int64  `json:"client_ms"`
+	ServerRecvMs int64  `json:"server_recv_ms"`
+	ServerSendMs int64  `json:"server_send_ms"`
+	OffsetMs     *int64 `json:"offset_ms,omitempty"`
+	RTTMs        *int64 `json:"rtt_ms,omitempty"`
+}
+
+func (cs *clockSync) handle(req timeSyncRequest, recv time.Time) timeSyncResponse {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if p := cs.pending; p != nil && req.AckClientMs != 0 && req.AckClientMs == p.ClientSend {
+		p.ClientRecv = req.AckRecvMs
+		cs.samples = append(cs.samples, *p)
+		if len(cs.samples) > clockSamples {
+			cs.samples = cs.samples[len(cs.samples)-clockSamples:]
+		}
+		if est, ok := EstimateClock(cs.samples); ok {
+			est.UpdatedAt = recv
+			cs.estimate, cs.ok = est, true
+		}
+	}
+
+	resp := timeSyncResponse{
+		ClientMs:     req.ClientMs,
+		ServerRecvMs: recv.UnixMilli(),
+		ServerSendMs: time.Now().UnixMilli(),
+	}
+	if cs.ok {
+		resp.OffsetMs, resp.RTTMs = &cs.estimate.OffsetMs, &cs.estimate.RTTMs
+	}
+	cs.pending = &TimeSample{ClientSend: req.ClientMs, ServerRecv: resp.ServerRecvMs, ServerSend: resp.ServerSendMs}
+	return resp
+}
+
+func (cs *clockSync) get() (ClockEstimate, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.estimate, cs.ok
+}
+
+// handleTimeSync answers a time_sync request directly from the read loop so
+// game handling never delays the reply and skews the sample.
+func (h *Hub) handleTimeSync(c *Client, msg WSMessage, recv time.Time) {
+	var req timeSyncRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil || req.ClientMs == 0 {
+		h.Reject(c.ID, msg, CommandErrorf(CodeBadRequest, "time_sync needs client_ms"))
+		return
+	}
+	payload, _ := json.Marshal(c.clock.handle(req, recv))
+	h.SendTo(c.ID, WSMessage{Type: "time_sync", Payload: payload})
+}
+
+// ClockEstimate returns the connected player's estimated clock offset, if
+// they have completed at least one time_sync exchange.
+func (h *Hub) ClockEstimate(playerID int64) (ClockEstimate, bool) {
+	c, ok := h.GetClient(playerID)
+	if !ok {
+		return ClockEstimate{}, false
+	}
+	return c.clock.get()
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeSampleOffsetRTT(t *testing.T) {
+	// Server clock 1000ms ahead; 40ms each way; 5ms processing.
+	s := TimeSample{ClientSend: 0, ServerRecv: 1040, ServerSend: 1045, ClientRecv: 85}
+	if got := s.Offset(); got != 1000 {
+		t.Errorf("Offset = %d, want 1000", got)
+	}
+	if got := s.RTT(); got != 80 {
+		t.Errorf("RTT = %d, want 80", got)
+	}
+}
+
+func TestEstimateClockPrefersLowestRTT(t *testing.T) {
+	samples := []TimeSample{
+		{ClientSend: 0, ServerRecv: 1300, ServerSend: 1300, ClientRecv: 340},   // delayed outbound leg
+		{ClientSend: 500, ServerRecv: 1510, ServerSend: 1510, ClientRecv: 520}, // fast
+		{ClientSend: 900, ServerRecv: 1950, ServerSend: 1950, ClientRecv: 800}, // negative RTT
+	}
+	est, ok := EstimateClock(samples)
+	if !ok {
+		t.Fatal("expected an estimate")
+	}
+	if est.OffsetMs != 1000 || est.RTTMs != 20 || est.Samples != 2 {
+		t.Fatalf("estimate = %+v", est)
+	}
+	if _, ok := EstimateClock(nil); ok {
+		t.Fatal("no samples should give no estimate")
+	}
+}
+
+func TestClockSyncAck(t *testing.T) {
+	var cs clockSync
+	recv := time.Now()
+	base := recv.UnixMilli() - 2000 // client clock 2s behind
+	resp := cs.handle(timeSyncRequest{ClientMs: base}, recv)
+	if resp.ServerRecvMs != recv.UnixMilli() || resp.OffsetMs != nil {
+		t.Fatalf("first response = %+v", resp)
+	}
+	// Acking an unknown exchange is ignored.
+	cs.handle(timeSyncRequest{ClientMs: base + 100, AckClientMs: 1, AckRecvMs: base + 50}, recv)
+	if _, ok := cs.get(); ok {
+		t.Fatal("mismatched ack produced an estimate")
+	}
+	p := *cs.pending
+	p.ClientRecv = base + 200
+	cs.handle(timeSyncRequest{ClientMs: base + 300, AckClientMs: base + 100, AckRecvMs: p.ClientRecv}, recv)
+	est, ok := cs.get()
+	if !ok || est.Samples != 1 {
+		t.Fatalf("estimate = %+v, %v", est, ok)
+	}
+	if est.OffsetMs != p.Offset() || est.RTTMs != p.RTT() {
+		t.Fatalf("estimate = %+v, want offset %d rtt %d", est, p.Offset(), p.RTT())
+	}
+}
+
+func TestHandleTimeSyncRejectsMalformed(t *testing.T) {
+	hub := NewHub("123:test", true, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	c := &Client{ID: 7, out: newOutbox()}
+	hub.register(c)
+	hub.handleTimeSync(c, WSMessage{Type: "time_sync", ID: "3", Payload: json.RawMessage(`{"client_ms":"soon"}`)}, time.Now())
+
+	msgs, _ := c.out.drain(nil)
+	if len(msgs) != 1 || msgs[0].Type != "error" || msgs[0].ID != "3" || !strings.Contains(string(msgs[0].Payload), string(CodeBadRequest)) {
+		t.Fatalf("got %+v, want a bad_request error", msgs)
+	}
+}
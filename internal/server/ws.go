@@ -6,28 +6,39 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"net/url"
+	"sort"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/coder/websocket"
-	"github.com/coder/websocket/wsjson"
 	"github.com/lastclick/lastclick/internal/auth"
+	"github.com/lastclick/lastclick/internal/sanction"
 )
 
 // WSMessage is the envelope for all WebSocket communication.
+// Room broadcasts carry Seq, increasing per room, so a reconnecting client can
+// resume from the last one it saw. Direct messages have no Seq. ID is set by
+// the client on commands and echoed on the ack or error that answers them.
 type WSMessage struct {
 	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Seq     uint64          `json:"seq,omitempty"`
 	Payload json.RawMessage `json:"payload,omitempty"`
 }
 
 // Client represents a connected Mini App player.
 type Client struct {
-	ID     int64
-	RoomID string
-	conn   *websocket.Conn
-	send   chan WSMessage
+	ID       int64
+	Username string
+	RoomID   string
+	conn     *websocket.Conn
+	out      *outbox
+	clock    clockSync
+	proto    Protocol
+	delta    tickDelta // owned by writePump
+	replaced atomic.Bool
 }
 
 // Hub manages all WebSocket clients and room-level broadcasting.
@@ -35,9 +46,16 @@ type Hub struct {
 	mu               sync.RWMutex
 	clients          map[int64]*Client
 	rooms            map[string]map[int64]*Client
-	lastRoomByPlayer map[int64]string // so reconnect (sync) can restore room; set on unregister
+	streams          map[string]*roomStream
+	lastRoomByPlayer map[int64]string      // so reconnect (sync) can restore room; set on unregister
+	pending          map[int64][]WSMessage // critical messages waiting for the player's next connection
 	handler          MessageHandler
-	botToken         string
+	sessions         *SessionManager
+	registrar        *Registrar
+	verifier         auth.Verifier
+	sanctions        *sanction.Checker
+	metrics          *Metrics
+	opts             WSOptions
 	devMode          bool
 	logger           *slog.Logger
 }
@@ -51,30 +69,104 @@ func NewHub(botToken string, devMode bool, handler MessageHandler, logger *slog.
 	return &Hub{
 		clients:          make(map[int64]*Client),
 		rooms:            make(map[string]map[int64]*Client),
+		streams:          make(map[string]*roomStream),
 		lastRoomByPlayer: make(map[int64]string),
+		pending:          make(map[int64][]WSMessage),
 		handler:          handler,
-		botToken:         botToken,
+		verifier:         auth.NewHMACVerifier(botToken, auth.DefaultWindow),
+		opts:             DefaultWSOptions,
 		devMode:          devMode,
 		logger:           logger,
 	}
 }
 
+// WSOptions hardens accepted sockets.
+type WSOptions struct {
+	// OriginPatterns are host patterns (path.Match syntax) for pages allowed
+	// to open a socket; the server's own host is always allowed.
+	OriginPatterns []string
+	ReadLimit      int64 // largest inbound frame, in bytes
+	PingInterval   time.Duration
+	MessageRate    float64 // inbound messages per second per connection
+	MessageBurst   int
+	Compression    bool // negotiate permessage-deflate
+}
+
+var DefaultWSOptions = WSOptions{
+	ReadLimit:    4096,
+	PingInterval: 30 * time.Second,
+	MessageRate:  20,
+	MessageBurst: 40,
+}
+
+// SetWSOptions replaces DefaultWSOptions. Zero fields keep their defaults.
+func (h *Hub) SetWSOptions(o WSOptions) {
+	if o.ReadLimit <= 0 {
+		o.ReadLimit = DefaultWSOptions.ReadLimit
+	}
+	if o.PingInterval <= 0 {
+		o.PingInterval = DefaultWSOptions.PingInterval
+	}
+	if o.MessageRate <= 0 {
+		o.MessageRate = DefaultWSOptions.MessageRate
+	}
+	if o.MessageBurst <= 0 {
+		o.MessageBurst = DefaultWSOptions.MessageBurst
+	}
+	h.opts = o
+}
+
+// SetSessionManager lets clients connect with a session access token (?token=)
+// instead of initData, so reconnects keep working after initData expires.
+func (h *Hub) SetSessionManager(sm *SessionManager) {
+	h.sessions = sm
+}
+
+// SetVerifier replaces the default bot-token HMAC check for initData.
+func (h *Hub) SetVerifier(v auth.Verifier) {
+	h.verifier = v
+}
+
+func (h *Hub) SetMetrics(m *Metrics) {
+	h.metrics = m
+}
+
+// SetSanctionChecker refuses connections from banned or suspended players.
+func (h *Hub) SetSanctionChecker(c *sanction.Checker) {
+	h.sanctions = c
+}
+
+// SetRegistrar upserts the player's profile on every initData connect.
+func (h *Hub) SetRegistrar(g *Registrar) {
+	h.registrar = g
+}
+
 func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	initData := r.URL.Query().Get("initData")
+	token := r.URL.Query().Get("token")
 
 	var userID int64
+	var user *auth.User // set when the connect carries a profile to register
 	var err error
 
-	if initData != "" {
-		if authErr := auth.ValidateInitData(initData, h.botToken); authErr != nil {
+	if token != "" && h.sessions != nil {
+		claims, verr := h.sessions.Verify(r.Context(), token)
+		if verr != nil {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 			return
 		}
-		userID, err = extractUserID(initData)
+		userID = claims.PlayerID
+	} else if initData != "" {
+		if authErr := h.verifier.Verify(initData); authErr != nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		user, err = auth.ParseUser(initData)
 		if err != nil {
 			http.Error(w, "bad init data", http.StatusBadRequest)
 			return
 		}
+		userID = user.ID
 	} else if h.devMode {
 		uidStr := r.URL.Query().Get("user_id")
 		if uidStr == "" {
@@ -86,24 +178,56 @@ func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 		}
+		user = &auth.User{ID: userID, Username: fmt.Sprintf("dev_%d", userID)}
 		h.logger.Info("dev mode ws connect", "user_id", userID)
 	} else {
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
-		InsecureSkipVerify: true,
-	})
+	if err := h.sanctions.Check(r.Context(), userID, sanction.Connect); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	var username string
+	if h.registrar != nil {
+		if user != nil {
+			p, rerr := h.registrar.Register(r.Context(), user)
+			if rerr != nil {
+				h.logger.Error("register player", "player", userID, "err", rerr)
+				http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			username = p.Username
+		} else {
+			username = h.registrar.Username(r.Context(), userID)
+		}
+	} else if user != nil {
+		username = user.DisplayName()
+	}
+
+	accept := &websocket.AcceptOptions{
+		Subprotocols:   subprotocols(),
+		OriginPatterns: h.opts.OriginPatterns,
+	}
+	if h.opts.Compression {
+		accept.CompressionMode = websocket.CompressionNoContextTakeover
+	}
+	conn, err := websocket.Accept(w, r, accept)
 	if err != nil {
-		h.logger.Error("ws accept", "err", err)
+		// Accept has already written the response (403 for a bad origin).
+		h.logger.Warn("ws accept", "origin", r.Header.Get("Origin"), "err", err)
 		return
 	}
+	conn.SetReadLimit(h.opts.ReadLimit)
 
 	client := &Client{
-		ID:   userID,
-		conn: conn,
-		send: make(chan WSMessage, 64),
+		ID:       userID,
+		Username: username,
+		conn:     conn,
+		out:      newOutbox(),
+		proto:    protocolFor(conn.Subprotocol()),
 	}
 
 	h.register(client)
@@ -116,18 +240,49 @@ func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.readPump(ctx, client)
 }
 
+// register makes c the player's live connection. Newest wins: an older
+// socket for the same player (second tab or device) is told
+// "session_replaced" and closed, and c takes over its room membership so
+// the player is never seen as disconnected.
 func (h *Hub) register(c *Client) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	if old, ok := h.clients[c.ID]; ok {
+		old.replaced.Store(true)
+		if old.RoomID != "" {
+			c.RoomID = old.RoomID
+			if room, ok := h.rooms[old.RoomID]; ok {
+				room[c.ID] = c
+			}
+		}
+		payload, _ := json.Marshal(map[string]string{"reason": "connected from another device"})
+		old.out.push(WSMessage{Type: "session_replaced", Payload: payload}, time.Now())
+		old.out.close()
+		h.metrics.WSSessionReplaced()
+	}
 	h.clients[c.ID] = c
+	h.metrics.WSConnected()
+	now := time.Now()
+	for _, msg := range h.pending[c.ID] {
+		c.out.push(msg, now)
+	}
+	delete(h.pending, c.ID)
 }
 
+// unregister drops c when its connection ends. A replaced client no longer
+// owns the player's slot, so only its socket is accounted for.
 func (h *Hub) unregister(c *Client) {
 	h.mu.Lock()
-	if _, ok := h.clients[c.ID]; ok {
-		delete(h.clients, c.ID)
-		close(c.send)
+	h.metrics.WSDisconnected()
+	if h.clients[c.ID] != c {
+		h.mu.Unlock()
+		c.out.close()
+		h.keepCritical(c.ID, c.out.takeCritical())
+		return
 	}
+	delete(h.clients, c.ID)
+	c.out.close()
+	h.stashLocked(c.ID, c.out.takeCritical())
 	if c.RoomID != "" {
 		h.lastRoomByPlayer[c.ID] = c.RoomID
 		if room, ok := h.rooms[c.RoomID]; ok {
@@ -201,35 +356,152 @@ func (h *Hub) LeaveRoom(clientID int64, roomID string) {
 	}
 }
 
-// BroadcastRoom sends a message to every client in a room.
+// BroadcastRoom stamps a message with the room's next seq, keeps it for
+// replay and sends it to every client in the room.
 func (h *Hub) BroadcastRoom(roomID string, msg WSMessage) {
+	start := time.Now()
+	st := h.stream(roomID)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.seq++
+	msg.Seq = st.seq
+	st.append(msg)
+
 	h.mu.RLock()
-	defer h.mu.RUnlock()
+	defer func() {
+		h.mu.RUnlock()
+		h.metrics.Broadcast(msg.Type, time.Since(start))
+	}()
 	room, ok := h.rooms[roomID]
 	if !ok {
 		return
 	}
+	now := time.Now()
 	for _, c := range room {
-		select {
-		case c.send <- msg:
-		default:
-			h.logger.Warn("client send buffer full", "client", c.ID)
-		}
+		h.enqueue(c, msg, now)
 	}
 }
 
-// SendTo sends a message to a specific client.
+// SendTo sends a message to a specific client. Critical messages for a
+// player who is not connected are kept for their next connection.
 func (h *Hub) SendTo(clientID int64, msg WSMessage) {
 	h.mu.RLock()
-	defer h.mu.RUnlock()
 	c, ok := h.clients[clientID]
+	if ok {
+		h.enqueue(c, msg, time.Now())
+	}
+	h.mu.RUnlock()
+	if !ok {
+		h.keepCritical(clientID, []WSMessage{msg})
+	}
+}
+
+// maxPending bounds the critical messages kept for an offline player.
+const maxPending = 8
+
+// keepCritical hands critical messages that never reached a connection to
+// the player's live connection, or keeps them for the next one.
+func (h *Hub) keepCritical(playerID int64, msgs []WSMessage) {
+	msgs = criticalOnly(msgs)
+	if len(msgs) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c, ok := h.clients[playerID]; ok && !c.out.isClosed() {
+		now := time.Now()
+		for _, msg := range msgs {
+			c.out.push(msg, now)
+		}
+		return
+	}
+	h.stashLocked(playerID, msgs)
+}
+
+// stashLocked keeps msgs for the player's next connection. h.mu must be held.
+func (h *Hub) stashLocked(playerID int64, msgs []WSMessage) {
+	if len(msgs) == 0 {
+		return
+	}
+	p := append(h.pending[playerID], msgs...)
+	if len(p) > maxPending {
+		p = p[len(p)-maxPending:]
+	}
+	h.pending[playerID] = p
+}
+
+// enqueue applies the outbound backpressure policy (see outbox.go).
+func (h *Hub) enqueue(c *Client, msg WSMessage, now time.Time) {
+	coalesced, slow := c.out.push(msg, now)
+	if coalesced {
+		h.metrics.WSCoalesced(msg.Type)
+	}
+	if slow == "" {
+		return
+	}
+	h.metrics.WSSlowDisconnect(slow)
+	h.logger.Warn("disconnecting slow client", "client", c.ID, "reason", slow)
+	go c.conn.Close(websocket.StatusTryAgainLater, "too slow, reconnect to resume")
+}
+
+// Kick tells a connected player why and closes their socket, e.g. when a ban
+// is applied. Their room state is handled like any other disconnect.
+func (h *Hub) Kick(playerID int64, reason string) {
+	h.mu.RLock()
+	c, ok := h.clients[playerID]
+	h.mu.RUnlock()
 	if !ok {
 		return
 	}
-	select {
-	case c.send <- msg:
-	default:
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		payload, _ := json.Marshal(map[string]string{"reason": reason})
+		if data, err := c.proto.Codec.Encode(WSMessage{Type: "kicked", Payload: payload}); err == nil {
+			_ = c.conn.Write(ctx, c.proto.Codec.FrameType(), data)
+		}
+		if len(reason) > 120 { // close reasons are limited to 123 bytes
+			reason = reason[:120]
+		}
+		_ = c.conn.Close(websocket.StatusPolicyViolation, reason)
+	}()
+}
+
+// ConnectionInfo describes one live connection for operators.
+type ConnectionInfo struct {
+	PlayerID int64          `json:"player_id"`
+	Username string         `json:"username"`
+	RoomID   string         `json:"room_id,omitempty"`
+	Protocol string         `json:"protocol"`
+	Outbox   OutboxStats    `json:"outbox"`
+	Clock    *ClockEstimate `json:"clock,omitempty"`
+}
+
+// Connections lists every connected client, most backed-up first.
+func (h *Hub) Connections() []ConnectionInfo {
+	h.mu.RLock()
+	out := make([]ConnectionInfo, 0, len(h.clients))
+	for _, c := range h.clients {
+		info := ConnectionInfo{
+			PlayerID: c.ID,
+			Username: c.Username,
+			RoomID:   c.RoomID,
+			Protocol: c.proto.Name,
+			Outbox:   c.out.snapshot(),
+		}
+		if est, ok := c.clock.get(); ok {
+			info.Clock = &est
+		}
+		out = append(out, info)
 	}
+	h.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Outbox.Queued != out[j].Outbox.Queued {
+			return out[i].Outbox.Queued > out[j].Outbox.Queued
+		}
+		return out[i].PlayerID < out[j].PlayerID
+	})
+	return out
 }
 
 // GetClient returns a client by ID.
@@ -247,17 +519,46 @@ func (h *Hub) GetLastRoom(playerID int64) string {
 	return h.lastRoomByPlayer[playerID]
 }
 
+// inboundDropsToClose is how many rate-limited messages in a row a client may
+// send before it is disconnected.
+const inboundDropsToClose = 100
+
 func (h *Hub) readPump(ctx context.Context, c *Client) {
 	defer func() {
 		if err := c.conn.CloseNow(); err != nil {
 			h.logger.Error("close conn", "err", err)
 		}
 	}()
+	limit := newBucket(h.opts.MessageBurst, time.Now())
+	dropped := 0
 	for {
-		var msg WSMessage
-		if err := wsjson.Read(ctx, c.conn, &msg); err != nil {
+		_, data, err := c.conn.Read(ctx)
+		if err != nil || c.replaced.Load() {
 			return
 		}
+		// Pulses have their own, stricter limiter; this one caps everything.
+		if !limit.take(time.Now(), h.opts.MessageRate, h.opts.MessageBurst) {
+			h.metrics.WSRateLimited()
+			dropped++
+			if dropped == 1 {
+				h.Reject(c.ID, WSMessage{}, CommandErrorf(CodeRateLimited, "too many messages"))
+			}
+			if dropped > inboundDropsToClose {
+				c.conn.Close(websocket.StatusPolicyViolation, "message rate exceeded")
+				return
+			}
+			continue
+		}
+		dropped = 0
+		msg, err := c.proto.Codec.Decode(data)
+		if err != nil {
+			h.Reject(c.ID, msg, CommandErrorf(CodeBadRequest, "malformed %s frame", c.proto.Codec.Name()))
+			continue
+		}
+		if msg.Type == "time_sync" {
+			h.handleTimeSync(c, msg, time.Now())
+			continue
+		}
 		if h.handler != nil {
 			h.handler.HandleMessage(ctx, c, msg)
 		}
@@ -265,20 +566,30 @@ func (h *Hub) readPump(ctx context.Context, c *Client) {
 }
 
 func (h *Hub) writePump(ctx context.Context, c *Client) {
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(h.opts.PingInterval)
 	defer ticker.Stop()
+	var batch []WSMessage
 	for {
 		select {
-		case msg, ok := <-c.send:
-			if !ok {
-				c.conn.Close(websocket.StatusNormalClosure, "")
-				return
+		case <-c.out.ready:
+			var closed bool
+			batch, closed = c.out.drain(batch[:0])
+			for i, msg := range batch {
+				if err := h.write(ctx, c, msg); err != nil {
+					h.keepCritical(c.ID, batch[i:])
+					return
+				}
 			}
-			if err := wsjson.Write(ctx, c.conn, msg); err != nil {
+			clear(batch)
+			if closed {
+				c.conn.Close(websocket.StatusNormalClosure, "")
 				return
 			}
 		case <-ticker.C:
-			if err := c.conn.Ping(ctx); err != nil {
+			pingCtx, cancel := context.WithTimeout(ctx, writeTimeout)
+			err := c.conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
 				return
 			}
 		case <-ctx.Done():
@@ -287,25 +598,21 @@ func (h *Hub) writePump(ctx context.Context, c *Client) {
 	}
 }
 
-func extractUserID(initData string) (int64, error) {
-	vals, err := url.ParseQuery(initData)
-	if err != nil {
-		return 0, err
+func (h *Hub) write(ctx context.Context, c *Client, msg WSMessage) error {
+	if c.proto.Version >= 2 {
+		msg = c.delta.apply(msg)
 	}
-
-	userJSON := vals.Get("user")
-	if userJSON == "" {
-		return 0, fmt.Errorf("missing user")
-	}
-
-	var u struct {
-		ID int64 `json:"id"`
-	}
-	if err := json.Unmarshal([]byte(userJSON), &u); err != nil {
-		return 0, err
+	data, err := c.proto.Codec.Encode(msg)
+	if err != nil {
+		h.logger.Error("ws encode", "type", msg.Type, "codec", c.proto.Codec.Name(), "err", err)
+		return nil
 	}
-	if u.ID == 0 {
-		return 0, fmt.Errorf("invalid user id")
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	if err := c.conn.Write(ctx, c.proto.Codec.FrameType(), data); err != nil {
+		return err
 	}
-	return u.ID, nil
+	c.out.sent()
+	h.metrics.WSSent(c.proto.Name, msg.Type, len(data))
+	return nil
 }
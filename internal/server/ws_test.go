@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func TestHubOriginAllowList(t *testing.T) {
+	hub := NewHub("123:test", true, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	hub.SetWSOptions(WSOptions{OriginPatterns: []string{"lastclick.app", "*.web.telegram.org"}})
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?user_id=7"
+
+	dial := func(origin string) (*websocket.Conn, *http.Response, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		return websocket.Dial(ctx, url, &websocket.DialOptions{
+			HTTPHeader: http.Header{"Origin": {origin}},
+		})
+	}
+
+	if _, resp, err := dial("https://evil.example"); err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("foreign origin: err=%v resp=%v", err, resp)
+	}
+	for _, origin := range []string{"https://lastclick.app", "https://a.web.telegram.org"} {
+		conn, _, err := dial(origin)
+		if err != nil {
+			t.Fatalf("%s: %v", origin, err)
+		}
+		if got := conn.Subprotocol(); got != "" {
+			t.Errorf("%s: subprotocol %q without an offer", origin, got)
+		}
+		conn.Close(websocket.StatusNormalClosure, "")
+	}
+}
+
+type disconnectRecorder struct {
+	mu   sync.Mutex
+	seen []*Client
+}
+
+func (d *disconnectRecorder) HandleMessage(context.Context, *Client, WSMessage) {}
+
+func (d *disconnectRecorder) OnDisconnect(c *Client) {
+	d.mu.Lock()
+	d.seen = append(d.seen, c)
+	d.mu.Unlock()
+}
+
+func newTestClient(id int64) *Client {
+	return &Client{ID: id, out: newOutbox()}
+}
+
+func TestHubNewestSessionWins(t *testing.T) {
+	rec := &disconnectRecorder{}
+	hub := NewHub("123:test", true, rec, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	old := newTestClient(7)
+	hub.register(old)
+	hub.JoinRoom(7, "r1")
+
+	cur := newTestClient(7)
+	hub.register(cur)
+	if !old.replaced.Load() {
+		t.Fatal("old client not marked replaced")
+	}
+	msgs, closed := old.out.drain(nil)
+	if !closed || len(msgs) != 1 || msgs[0].Type != "session_replaced" {
+		t.Fatalf("old outbox: closed=%v msgs=%v", closed, types(msgs))
+	}
+	if cur.RoomID != "r1" || hub.rooms["r1"][7] != cur {
+		t.Fatalf("room membership not moved: room=%q member=%p", cur.RoomID, hub.rooms["r1"][7])
+	}
+
+	// The old socket closing afterwards must not disturb the new one.
+	hub.unregister(old)
+	if hub.clients[7] != cur || hub.rooms["r1"][7] != cur {
+		t.Fatal("stale unregister removed the live session")
+	}
+	if _, ok := hub.lastRoomByPlayer[7]; ok {
+		t.Error("stale unregister recorded a last room")
+	}
+	hub.BroadcastRoom("r1", WSMessage{Type: "tick"})
+	hub.SendTo(7, WSMessage{Type: "ack"})
+	if msgs, _ := cur.out.drain(nil); len(msgs) != 2 {
+		t.Errorf("live client got %v", types(msgs))
+	}
+
+	hub.unregister(cur)
+	if len(rec.seen) != 1 || rec.seen[0] != cur {
+		t.Fatalf("OnDisconnect calls: %v", rec.seen)
+	}
+	if len(hub.clients) != 0 || len(hub.rooms) != 0 || hub.lastRoomByPlayer[7] != "r1" {
+		t.Errorf("after last disconnect: clients=%d rooms=%d last=%q", len(hub.clients), len(hub.rooms), hub.lastRoomByPlayer[7])
+	}
+}
+
+func TestHubConcurrentSessionsRace(t *testing.T) {
+	hub := NewHub("123:test", true, &disconnectRecorder{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := newTestClient(7)
+			hub.register(c)
+			hub.JoinRoom(7, "r1")
+			hub.BroadcastRoom("r1", WSMessage{Type: "tick"})
+			hub.SendTo(7, WSMessage{Type: "pulse_ack"})
+			hub.unregister(c)
+		}()
+	}
+	wg.Wait()
+	if len(hub.clients) != 0 || len(hub.rooms) != 0 {
+		t.Fatalf("leaked state: clients=%d rooms=%v", len(hub.clients), hub.rooms)
+	}
+}
@@ -0,0 +1,234 @@
+package settlement
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/lastclick/lastclick/internal/room"
+	"github.com/lastclick/lastclick/internal/store"
+)
+
+// Plan is the full set of balance changes for a finished round. Every Star of
+// the pool is accounted for: payouts in Entries, plus House, plus
+// WarChestShare for each player, plus WarChestDust.
+type Plan struct {
+	Economy       room.EconomyPolicy
+	Ties          string // tie policy applied to the co-survivors
+	SplitCount    int    // top places sharing their payouts; 0 if none
+	Pool          int64
+	Rake          int64
+	WarChest      int64
+	WarChestShare int64
+	House         int64 // rake minus war chest, plus dust sent to the house
+	Dust          int64 // integer-division remainder, already included above
+	DustTo        string
+	WarChestDust  int64 // dust for the winner's squad war chest
+	WinnerID      int64
+	Withheld      int64   // Stars kept from sanctioned players; included in House
+	WithheldFrom  []int64 // players whose payouts and shards were withheld
+	Entries       []store.LedgerEntry
+	Placement     map[int64]int   // player → 1-based place
+	Stars         map[int64]int64 // player → Stars paid out
+	Shards        map[int64]int64 // player → shards granted
+
+	players int
+}
+
+// FromRoom snapshots a finished room into a round finished record.
+func FromRoom(r *room.Room) *store.RoundSettlement {
+	players := make([]int64, 0, r.PlayerCount())
+	for _, p := range r.AllPlayers() {
+		players = append(players, p.ID)
+	}
+	economy, _ := json.Marshal(r.Economy)
+	return &store.RoundSettlement{
+		RoundID:       r.RoundID,
+		RoomID:        r.ID,
+		RoomType:      string(r.Type),
+		Tier:          r.Tier.Tier,
+		EntryCost:     r.Tier.EntryCost,
+		Pool:          r.Pool,
+		VolatilityMul: r.VolatilityMul,
+		PlayerIDs:     players,
+		Placements:    r.Placements(),
+		CoSurvivors:   r.AliveCount(),
+		StartedAt:     r.StartedAt,
+		EndedAt:       r.EndedAt,
+		Economy:       economy,
+	}
+}
+
+// Economy returns the policy a round was played under. Rounds recorded before
+// policies were stored all used room.EconomyV1.
+func Economy(rs *store.RoundSettlement) (room.EconomyPolicy, error) {
+	if len(rs.Economy) == 0 {
+		return room.EconomyV1(), nil
+	}
+	var p room.EconomyPolicy
+	if err := json.Unmarshal(rs.Economy, &p); err != nil {
+		return p, fmt.Errorf("decode economy policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return p, fmt.Errorf("economy policy %s: %w", p.Ref(), err)
+	}
+	return p, nil
+}
+
+// BuildPlan computes payouts for the top places, shards for everyone else, and
+// the rake / war chest split, under the economy policy recorded with the
+// round. Pure function of the record, so retries always apply the same amounts.
+func BuildPlan(rs *store.RoundSettlement) (Plan, error) {
+	econ, err := Economy(rs)
+	if err != nil {
+		return Plan{}, err
+	}
+	plan := Plan{
+		Economy:   econ,
+		Pool:      rs.Pool,
+		players:   len(rs.PlayerIDs),
+		Rake:      econ.RakeAmount(rs.Pool),
+		Ties:      econ.TiePolicy(room.RoomType(rs.RoomType), rs.Tier),
+		Placement: make(map[int64]int, len(rs.Placements)),
+		Stars:     make(map[int64]int64),
+		Shards:    make(map[int64]int64),
+	}
+	for i, pid := range rs.Placements {
+		plan.Placement[pid] = i + 1
+	}
+
+	var paid int64
+	plan.SplitCount = econ.SplitCount(room.RoomType(rs.RoomType), rs.Tier, min(rs.CoSurvivors, len(rs.Placements)))
+	payouts := econ.PlacementPayouts(rs.Pool, len(rs.PlayerIDs), plan.SplitCount)
+	topPlaces := len(payouts)
+	for _, pp := range payouts {
+		if pp.Place-1 < len(rs.Placements) && pp.Amount > 0 {
+			plan.Entries = append(plan.Entries, store.LedgerEntry{
+				PlayerID: rs.Placements[pp.Place-1],
+				Type:     store.TxPayout,
+				Stars:    pp.Amount,
+			})
+			plan.Stars[rs.Placements[pp.Place-1]] = pp.Amount
+			paid += pp.Amount
+		}
+	}
+
+	for _, pid := range rs.PlayerIDs {
+		place := plan.Placement[pid]
+		if place > 0 && place <= topPlaces {
+			continue
+		}
+		shards := econ.ShardsForLoser(rs.EntryCost, rs.VolatilityMul, place)
+		if shards > 0 {
+			plan.Entries = append(plan.Entries, store.LedgerEntry{
+				PlayerID: pid,
+				Type:     store.TxShardGrant,
+				Shards:   shards,
+			})
+			plan.Shards[pid] = shards
+		}
+	}
+
+	plan.WarChest = econ.WarChestContribution(plan.Rake)
+	plan.House = plan.Rake - plan.WarChest
+	if len(rs.PlayerIDs) > 0 {
+		plan.WarChestShare = plan.WarChest / int64(len(rs.PlayerIDs))
+	}
+
+	// Whatever the divisions left over, including shares for places nobody
+	// reached, goes to one named destination.
+	plan.Dust = (rs.Pool - plan.Rake - paid) + (plan.WarChest - plan.WarChestShare*int64(len(rs.PlayerIDs)))
+	plan.DustTo = econ.DustDestination()
+	if len(rs.Placements) > 0 {
+		plan.WinnerID = rs.Placements[0]
+	} else {
+		plan.DustTo = room.DustHouse
+	}
+	switch plan.DustTo {
+	case room.DustWinner:
+		plan.addWinnerDust()
+	case room.DustWarChest:
+		plan.WarChestDust = plan.Dust
+	default:
+		plan.House += plan.Dust
+	}
+
+	if err := plan.Check(); err != nil {
+		return Plan{}, err
+	}
+	return plan, nil
+}
+
+func (p *Plan) addWinnerDust() {
+	if p.Dust == 0 {
+		return
+	}
+	for i, e := range p.Entries {
+		if e.PlayerID == p.WinnerID && e.Type == store.TxPayout {
+			p.Entries[i].Stars += p.Dust
+			p.Stars[p.WinnerID] += p.Dust
+			return
+		}
+	}
+	p.Stars[p.WinnerID] += p.Dust
+	p.Entries = append(p.Entries, store.LedgerEntry{PlayerID: p.WinnerID, Type: store.TxPayout, Stars: p.Dust})
+}
+
+// Withhold drops the credits of players blocked from balance changes. Their
+// Stars stay with the house, recorded as Withheld so an operator can reissue
+// them; their shards are not granted.
+func (p *Plan) Withhold(blocked func(playerID int64) bool) {
+	entries := p.Entries[:0]
+	for _, e := range p.Entries {
+		if !blocked(e.PlayerID) {
+			entries = append(entries, e)
+			continue
+		}
+		p.House += e.Stars
+		p.Withheld += e.Stars
+		delete(p.Stars, e.PlayerID)
+		delete(p.Shards, e.PlayerID)
+		if !slices.Contains(p.WithheldFrom, e.PlayerID) {
+			p.WithheldFrom = append(p.WithheldFrom, e.PlayerID)
+		}
+	}
+	p.Entries = entries
+}
+
+// Check is the conservation invariant: payouts, house, war chest shares and
+// dust add up to exactly the pool, and nothing is negative.
+func (p Plan) Check() error {
+	var paid int64
+	for _, e := range p.Entries {
+		if e.Stars < 0 || e.Shards < 0 {
+			return fmt.Errorf("%w: negative entry for player %d", store.ErrUnbalanced, e.PlayerID)
+		}
+		paid += e.Stars
+	}
+	if p.House < 0 || p.Dust < 0 || p.WarChestShare < 0 {
+		return fmt.Errorf("%w: negative house %d, dust %d or war chest share %d", store.ErrUnbalanced, p.House, p.Dust, p.WarChestShare)
+	}
+	out := paid + p.House + p.WarChestShare*int64(p.players) + p.WarChestDust
+	if out != p.Pool {
+		return fmt.Errorf("%w: pool %d, allocated %d (payouts %d, house %d, war chest %d×%d + %d)",
+			store.ErrUnbalanced, p.Pool, out, paid, p.House, p.WarChestShare, p.players, p.WarChestDust)
+	}
+	return nil
+}
+
+func (p Plan) result() store.SettlementResult {
+	return store.SettlementResult{
+		Entries:       p.Entries,
+		Rake:          p.Rake,
+		WarChest:      p.WarChest,
+		WarChestShare: p.WarChestShare,
+		House:         p.House,
+		Dust:          p.Dust,
+		DustTo:        p.DustTo,
+		WarChestDust:  p.WarChestDust,
+		WinnerID:      p.WinnerID,
+		Withheld:      p.Withheld,
+		WithheldFrom:  p.WithheldFrom,
+		Pool:          p.Pool,
+	}
+}
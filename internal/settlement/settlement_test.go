@@ -0,0 +1,257 @@
+package settlement
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lastclick/lastclick/internal/room"
+	"github.com/lastclick/lastclick/internal/store"
+)
+
+func TestBuildPlan(t *testing.T) {
+	rs := &store.RoundSettlement{
+		EntryCost:     20,
+		Pool:          100,
+		VolatilityMul: 1.0,
+		PlayerIDs:     []int64{1, 2, 3, 4, 5},
+		Placements:    []int64{3, 1, 5, 2, 4},
+	}
+	plan, err := BuildPlan(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan.Rake != 12 {
+		t.Fatalf("rake: want 12, got %d", plan.Rake)
+	}
+
+	var paid int64
+	payouts := map[int64]int64{}
+	for _, e := range plan.Entries {
+		if e.Type == store.TxPayout {
+			payouts[e.PlayerID] = e.Stars
+			paid += e.Stars
+		}
+	}
+	if len(payouts) != 3 || payouts[3] == 0 || payouts[1] == 0 || payouts[5] == 0 {
+		t.Fatalf("expected payouts for top 3 (3, 1, 5), got %v", payouts)
+	}
+	if paid > rs.Pool-plan.Rake {
+		t.Fatalf("paid %d exceeds post-rake pool %d", paid, rs.Pool-plan.Rake)
+	}
+
+	if plan.Placement[2] != 4 || plan.Shards[2] != 16 {
+		t.Fatalf("4th place: want place 4 with 16 shards, got place %d shards %d", plan.Placement[2], plan.Shards[2])
+	}
+	if _, ok := plan.Shards[3]; ok {
+		t.Fatal("winner should not receive shards")
+	}
+
+	again, _ := BuildPlan(rs)
+	if len(again.Entries) != len(plan.Entries) {
+		t.Fatal("plan must be deterministic for retries")
+	}
+	for i := range plan.Entries {
+		if again.Entries[i] != plan.Entries[i] {
+			t.Fatalf("entry %d differs between builds: %+v vs %+v", i, plan.Entries[i], again.Entries[i])
+		}
+	}
+}
+
+func TestBuildPlanUsesRecordedEconomy(t *testing.T) {
+	rs := &store.RoundSettlement{
+		EntryCost:  20,
+		Pool:       100,
+		PlayerIDs:  []int64{1, 2},
+		Placements: []int64{2, 1},
+	}
+	if plan, _ := BuildPlan(rs); plan.Economy.Ref() != "standard@v1" || plan.Rake != 12 {
+		t.Fatalf("legacy round: %s rake %d", plan.Economy.Ref(), plan.Rake)
+	}
+
+	econ := room.EconomyV1()
+	econ.Version = 2
+	econ.RakePct = 10
+	econ.Payouts = []room.PayoutSplit{{MinPlayers: 1, SharesPct: []int64{100}}}
+	rs.Economy, _ = json.Marshal(econ)
+
+	// The live policy must not leak into a recorded round.
+	plan, err := BuildPlan(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Rake != 10 || len(plan.Entries) != 2 || plan.Entries[0].Stars != 90 {
+		t.Fatalf("recorded policy not applied: rake %d entries %+v", plan.Rake, plan.Entries)
+	}
+
+	rs.Economy = json.RawMessage(`{"id":"broken"}`)
+	if _, err := BuildPlan(rs); err == nil {
+		t.Fatal("invalid recorded policy accepted")
+	}
+}
+
+func TestBuildPlanAllocatesDust(t *testing.T) {
+	// 47 Stars among 3 players: rake 5, war chest 0, post-rake 42 splits
+	// 25/10/6 with 1 left over.
+	rs := &store.RoundSettlement{
+		EntryCost:  15,
+		Pool:       47,
+		PlayerIDs:  []int64{1, 2, 3},
+		Placements: []int64{2, 3, 1},
+	}
+	stars := func(p Plan, pid int64) int64 {
+		for _, e := range p.Entries {
+			if e.PlayerID == pid && e.Type == store.TxPayout {
+				return e.Stars
+			}
+		}
+		return 0
+	}
+	for _, to := range []string{room.DustHouse, room.DustWinner, room.DustWarChest} {
+		econ := room.EconomyV1()
+		econ.Version = 2
+		econ.DustTo = to
+		rs.Economy, _ = json.Marshal(econ)
+		plan, err := BuildPlan(rs)
+		if err != nil {
+			t.Fatalf("%s: %v", to, err)
+		}
+		if plan.Dust != 1 || plan.DustTo != to {
+			t.Fatalf("%s: dust %d to %s", to, plan.Dust, plan.DustTo)
+		}
+		var winner, house, chest int64 = 25, 5, 0
+		switch to {
+		case room.DustWinner:
+			winner++
+		case room.DustWarChest:
+			chest++
+		default:
+			house++
+		}
+		if stars(plan, 2) != winner || plan.House != house || plan.WarChestDust != chest {
+			t.Errorf("%s: winner %d house %d war chest dust %d", to, stars(plan, 2), plan.House, plan.WarChestDust)
+		}
+	}
+
+	// Places nobody reached are dust too; with no placements it all stays
+	// with the house.
+	rs.Placements = nil
+	plan, err := BuildPlan(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.DustTo != room.DustHouse || plan.House != rs.Pool {
+		t.Fatalf("no placements: house %d dust to %s", plan.House, plan.DustTo)
+	}
+}
+
+func TestPlanCheck(t *testing.T) {
+	plan, err := BuildPlan(&store.RoundSettlement{Pool: 100, PlayerIDs: []int64{1}, Placements: []int64{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan.Entries[0].Stars++
+	if err := plan.Check(); !errors.Is(err, store.ErrUnbalanced) {
+		t.Fatalf("overpayment not caught: %v", err)
+	}
+}
+
+func TestBuildPlanTiePolicies(t *testing.T) {
+	// Two of five players survive; 88 Stars after rake.
+	rs := &store.RoundSettlement{
+		RoomType:    "blitz",
+		Tier:        2,
+		EntryCost:   20,
+		Pool:        100,
+		PlayerIDs:   []int64{1, 2, 3, 4, 5},
+		Placements:  []int64{3, 1, 5, 2, 4},
+		CoSurvivors: 2,
+	}
+	econ := room.EconomyV1()
+	econ.Version = 2
+	econ.TiesBy = map[string]string{"blitz": room.TieSplit, "tier3": room.TieHash}
+	rs.Economy, _ = json.Marshal(econ)
+
+	plan, err := BuildPlan(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1st 52 + 2nd 22 shared; 3rd keeps 13.
+	if plan.Ties != room.TieSplit || plan.SplitCount != 2 || plan.Stars[3] != 37 || plan.Stars[1] != 37 || plan.Stars[5] != 13 {
+		t.Fatalf("split: ties %s count %d stars %v", plan.Ties, plan.SplitCount, plan.Stars)
+	}
+
+	// More survivors than paid places: everyone alive shares, nobody gets shards.
+	rs.CoSurvivors = 4
+	plan, err = BuildPlan(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pid := range rs.Placements[:4] {
+		if plan.Stars[pid] != 21 || plan.Shards[pid] != 0 {
+			t.Errorf("player %d: %d stars %d shards", pid, plan.Stars[pid], plan.Shards[pid])
+		}
+	}
+
+	// A tier override beats the room type.
+	rs.Tier = 3
+	if plan, _ := BuildPlan(rs); plan.Ties != room.TieHash || plan.SplitCount != 0 || plan.Stars[3] != 52 {
+		t.Fatalf("tier override: ties %s stars %v", plan.Ties, plan.Stars)
+	}
+}
+
+func TestRankSurvivorsByEfficiency(t *testing.T) {
+	s := []room.Survivor{{ID: 1, PulseCount: 9}, {ID: 2, PulseCount: 4}, {ID: 3, PulseCount: 6}}
+	room.RankSurvivors(room.TieEfficiency, s, 42)
+	if s[0].ID != 2 || s[1].ID != 3 || s[2].ID != 1 {
+		t.Fatalf("want fewest pulses first, got %+v", s)
+	}
+}
+
+func TestPlacementsVaryTieOrderByRound(t *testing.T) {
+	r := room.NewRoom("room-1", room.RoomBlitz, room.Tiers[1])
+	r.AddPlayer(1, "a")
+	r.AddPlayer(2, "b")
+	firsts := map[int64]int{}
+	for i := 0; i < 40; i++ {
+		r.RoundID = fmt.Sprintf("round-%d", i)
+		firsts[r.Placements()[0]]++
+	}
+	if firsts[1] == 0 || firsts[2] == 0 {
+		t.Fatalf("the same room always resolves the tie one way: %v", firsts)
+	}
+}
+
+func TestPlanWithhold(t *testing.T) {
+	rs := &store.RoundSettlement{
+		EntryCost:     20,
+		Pool:          100,
+		VolatilityMul: 1.0,
+		PlayerIDs:     []int64{1, 2, 3, 4, 5},
+		Placements:    []int64{3, 1, 5, 2, 4},
+	}
+	plan, err := BuildPlan(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	winnings, house := plan.Stars[3], plan.House
+	frozen := map[int64]bool{3: true, 4: true}
+	plan.Withhold(func(pid int64) bool { return frozen[pid] })
+
+	for _, e := range plan.Entries {
+		if frozen[e.PlayerID] {
+			t.Fatalf("entry left for frozen player: %+v", e)
+		}
+	}
+	if plan.Withheld != winnings || plan.House != house+winnings {
+		t.Fatalf("withheld %d, house %d; want %d, %d", plan.Withheld, plan.House, winnings, house+winnings)
+	}
+	if len(plan.WithheldFrom) != 2 || plan.Stars[3] != 0 || plan.Shards[4] != 0 {
+		t.Fatalf("withheld from %v, stars %v, shards %v", plan.WithheldFrom, plan.Stars, plan.Shards)
+	}
+	if err := plan.Check(); err != nil {
+		t.Fatalf("plan no longer balances: %v", err)
+	}
+}
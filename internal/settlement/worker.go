@@ -0,0 +1,178 @@
+package settlement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lastclick/lastclick/internal/sanction"
+	"github.com/lastclick/lastclick/internal/store"
+)
+
+const (
+	pollInterval   = 5 * time.Second
+	batchSize      = 50
+	maxRetryDelay  = 5 * time.Minute
+	enqueueRetries = 5
+)
+
+// Recorder receives settlement outcomes, e.g. for Prometheus.
+type Recorder interface {
+	Settlement(ok bool, d time.Duration)
+}
+
+// Worker settles finished rounds from the outbox. Every round is settled in a
+// single DB transaction that also marks it settled, so retries never pay twice.
+type Worker struct {
+	store     *store.SettlementStore
+	sanctions *sanction.Checker
+	metrics   Recorder
+	logger    *slog.Logger
+	wake      chan struct{}
+}
+
+func NewWorker(st *store.SettlementStore, logger *slog.Logger) *Worker {
+	return &Worker{
+		store:  st,
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+func (w *Worker) SetMetrics(m Recorder) {
+	w.metrics = m
+}
+
+// SetSanctionChecker withholds payouts and shards from players who may not
+// move balances (economy freeze, ban or suspension) when their round settles.
+func (w *Worker) SetSanctionChecker(c *sanction.Checker) {
+	w.sanctions = c
+}
+
+// Blocked reports whether a player's settlement credits must be withheld.
+func (w *Worker) Blocked(ctx context.Context, playerID int64) bool {
+	return w.sanctions.Check(ctx, playerID, sanction.Economy) != nil
+}
+
+// Submit durably records a finished round, retrying briefly on DB errors, then
+// wakes the worker. The record is the source of truth; settlement happens later.
+func (w *Worker) Submit(ctx context.Context, rs *store.RoundSettlement) error {
+	var err error
+	for attempt := 0; attempt < enqueueRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * 200 * time.Millisecond):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err = w.store.Enqueue(ctx, rs); err == nil {
+			w.Notify()
+			return nil
+		}
+		w.logger.Warn("enqueue settlement failed", "room", rs.RoomID, "attempt", attempt+1, "err", err)
+	}
+	return fmt.Errorf("enqueue settlement: %w", err)
+}
+
+// Notify asks the worker to process due rounds now instead of at the next poll.
+func (w *Worker) Notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run processes due rounds until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		w.processDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+	}
+}
+
+func (w *Worker) processDue(ctx context.Context) {
+	due, err := w.store.Due(ctx, batchSize)
+	if err != nil {
+		w.logger.Error("list due settlements", "err", err)
+		return
+	}
+	for i := range due {
+		w.settle(ctx, &due[i])
+	}
+}
+
+func (w *Worker) settle(ctx context.Context, rs *store.RoundSettlement) {
+	plan, err := BuildPlan(rs)
+	if err != nil {
+		// A stored policy that no longer decodes, or a plan that does not
+		// balance, will not fix itself; park the round for an operator
+		// instead of retrying it hot.
+		w.logger.Error("settlement plan", "settlement", rs.ID, "room", rs.RoomID, "err", err)
+		if markErr := w.store.MarkFailed(ctx, rs.ID, err, maxRetryDelay); markErr != nil {
+			w.logger.Error("mark settlement failed", "settlement", rs.ID, "err", markErr)
+		}
+		return
+	}
+	plan.Withhold(func(pid int64) bool { return w.Blocked(ctx, pid) })
+	start := time.Now()
+	settled, err := w.store.Settle(ctx, rs.ID, plan.result())
+	if w.metrics != nil {
+		w.metrics.Settlement(err == nil, time.Since(start))
+	}
+	if err != nil {
+		delay := retryDelay(rs.Attempts + 1)
+		if errors.Is(err, store.ErrUnbalanced) {
+			delay = maxRetryDelay
+		}
+		w.logger.Error("settle round failed",
+			"settlement", rs.ID, "room", rs.RoomID,
+			"attempt", rs.Attempts+1, "retry_in", delay, "err", err)
+		if markErr := w.store.MarkFailed(ctx, rs.ID, err, delay); markErr != nil {
+			w.logger.Error("mark settlement failed", "settlement", rs.ID, "err", markErr)
+		}
+		return
+	}
+	if settled {
+		w.logger.Info("round settled",
+			"settlement", rs.ID,
+			"room", rs.RoomID,
+			"pool", rs.Pool,
+			"economy", plan.Economy.Ref(),
+			"rake", plan.Rake,
+			"war_chest", plan.WarChest,
+			"house", plan.House,
+			"dust", plan.Dust,
+			"dust_to", plan.DustTo,
+			"entries", len(plan.Entries),
+		)
+		if len(plan.WithheldFrom) > 0 {
+			w.logger.Warn("settlement credits withheld from sanctioned players",
+				"settlement", rs.ID, "room", rs.RoomID, "players", plan.WithheldFrom, "stars", plan.Withheld)
+		}
+	}
+}
+
+// retryDelay backs off exponentially from 1s, capped at maxRetryDelay.
+func retryDelay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt > 10 {
+		return maxRetryDelay
+	}
+	d := time.Second << (attempt - 1)
+	if d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	return d
+}
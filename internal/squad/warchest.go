@@ -34,12 +34,7 @@ func (w *WarChestService) AutoDistribute(ctx context.Context, squadID string) er
 		return nil
 	}
 
-	// Distribute 10% of war chest each cycle
-	distributable := sq.WarChest / 10
-	if distributable == 0 {
-		return nil
-	}
-	perMember := distributable / int64(sq.MemberCount)
+	distributable, perMember := Distribution(sq.WarChest, sq.MemberCount)
 	if perMember == 0 {
 		return nil
 	}
@@ -57,3 +52,13 @@ func (w *WarChestService) AutoDistribute(ctx context.Context, squadID string) er
 	)
 	return nil
 }
+
+// Distribution is one auto-distribution cycle: 10% of the war chest, split
+// equally among members. perMember is 0 when there is nothing to hand out.
+func Distribution(warChest int64, members int) (distributable, perMember int64) {
+	if warChest <= 0 || members <= 0 {
+		return 0, 0
+	}
+	distributable = warChest / 10
+	return distributable, distributable / int64(members)
+}
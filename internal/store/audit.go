@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuditEntry records one operator action. The table is append-only.
+type AuditEntry struct {
+	ID        int64
+	Actor     string
+	Action    string
+	Target    string
+	Reason    string
+	Before    json.RawMessage
+	After     json.RawMessage
+	CreatedAt time.Time
+}
+
+// AuditFilter narrows an audit listing. Zero values mean "any".
+type AuditFilter struct {
+	Actor    string
+	Action   string
+	Target   string
+	BeforeID int64 // only entries with a smaller ID (pagination)
+	Limit    int
+}
+
+type AuditStore struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditStore(db *pgxpool.Pool) *AuditStore {
+	return &AuditStore{db: db}
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+func insertAudit(ctx context.Context, db execer, e AuditEntry) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO admin_audit (actor, action, target, reason, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, e.Actor, e.Action, e.Target, e.Reason, nullJSON(e.Before), nullJSON(e.After))
+	return err
+}
+
+func nullJSON(b json.RawMessage) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+func (s *AuditStore) Record(ctx context.Context, e AuditEntry) error {
+	return insertAudit(ctx, s.db, e)
+}
+
+// List returns audit entries newest first.
+func (s *AuditStore) List(ctx context.Context, f AuditFilter) ([]AuditEntry, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT id, actor, action, target, reason,
+		       COALESCE(before::text, ''), COALESCE(after::text, ''), created_at
+		FROM admin_audit
+		WHERE ($1 = '' OR actor = $1)
+		  AND ($2 = '' OR action = $2)
+		  AND ($3 = '' OR target = $3)
+		  AND ($4 = 0 OR id < $4)
+		ORDER BY id DESC
+		LIMIT $5
+	`, f.Actor, f.Action, f.Target, f.BeforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var before, after string
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.Target, &e.Reason, &before, &after, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if before != "" {
+			e.Before = json.RawMessage(before)
+		}
+		if after != "" {
+			e.After = json.RawMessage(after)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -2,6 +2,9 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -18,9 +21,45 @@ type Player struct {
 	ShardsBalance int64
 	SquadID       *string
 	PrestigeMult  float64
+	FirstName     string
+	LastName      string
+	LanguageCode  string
+	IsPremium     bool
+	PhotoURL      string
 	CreatedAt     time.Time
 }
 
+// Profile is the Telegram-side identity of a player, refreshed on every connect.
+type Profile struct {
+	ID           int64
+	Username     string
+	FirstName    string
+	LastName     string
+	LanguageCode string
+	IsPremium    bool
+	PhotoURL     string
+}
+
+// StarterGrant is credited once, when a player's row is first created.
+type StarterGrant struct {
+	Stars  int64
+	Shards int64
+}
+
+const playerColumns = `id, username, elo, lifetime_elo, efficiency_avg,
+	stars_balance, shards_balance, squad_id, prestige_mult,
+	first_name, last_name, language_code, is_premium, photo_url, created_at`
+
+func scanPlayer(row pgx.Row) (*Player, error) {
+	p := &Player{}
+	err := row.Scan(
+		&p.ID, &p.Username, &p.Elo, &p.LifetimeElo, &p.EfficiencyAvg,
+		&p.StarsBalance, &p.ShardsBalance, &p.SquadID, &p.PrestigeMult,
+		&p.FirstName, &p.LastName, &p.LanguageCode, &p.IsPremium, &p.PhotoURL, &p.CreatedAt,
+	)
+	return p, err
+}
+
 type PlayerStore struct {
 	db *pgxpool.Pool
 }
@@ -30,29 +69,75 @@ func NewPlayerStore(db *pgxpool.Pool) *PlayerStore {
 }
 
 func (s *PlayerStore) Upsert(ctx context.Context, id int64, username string) (*Player, error) {
-	p := &Player{}
-	err := s.db.QueryRow(ctx, `
+	return scanPlayer(s.db.QueryRow(ctx, `
 		INSERT INTO players (id, username) VALUES ($1, $2)
 		ON CONFLICT (id) DO UPDATE SET username = EXCLUDED.username
-		RETURNING id, username, elo, lifetime_elo, efficiency_avg,
-		          stars_balance, shards_balance, squad_id, prestige_mult, created_at
-	`, id, username).Scan(
-		&p.ID, &p.Username, &p.Elo, &p.LifetimeElo, &p.EfficiencyAvg,
-		&p.StarsBalance, &p.ShardsBalance, &p.SquadID, &p.PrestigeMult, &p.CreatedAt,
-	)
-	return p, err
+		RETURNING `+playerColumns, id, username))
+}
+
+// UpsertProfile creates or refreshes a player from their Telegram profile.
+// A newly created player is credited the starter grant, with matching ledger
+// rows, in the same transaction. created reports whether the row is new.
+func (s *PlayerStore) UpsertProfile(ctx context.Context, pr Profile, grant StarterGrant) (p *Player, created bool, err error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	// xmax = 0 only for freshly inserted rows.
+	err = tx.QueryRow(ctx, `
+		INSERT INTO players (id, username, first_name, last_name, language_code, is_premium, photo_url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			username      = EXCLUDED.username,
+			first_name    = EXCLUDED.first_name,
+			last_name     = EXCLUDED.last_name,
+			language_code = EXCLUDED.language_code,
+			is_premium    = EXCLUDED.is_premium,
+			photo_url     = EXCLUDED.photo_url,
+			last_seen_at  = NOW()
+		RETURNING (xmax = 0)
+	`, pr.ID, pr.Username, pr.FirstName, pr.LastName, pr.LanguageCode, pr.IsPremium, pr.PhotoURL).Scan(&created)
+	if err != nil {
+		return nil, false, fmt.Errorf("upsert player: %w", err)
+	}
+
+	if created && (grant.Stars > 0 || grant.Shards > 0) {
+		if _, err := tx.Exec(ctx, `
+			UPDATE players SET stars_balance = stars_balance + $2, shards_balance = shards_balance + $3
+			WHERE id = $1
+		`, pr.ID, grant.Stars, grant.Shards); err != nil {
+			return nil, false, fmt.Errorf("starter balance: %w", err)
+		}
+		if grant.Stars > 0 {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO transactions (player_id, type, amount) VALUES ($1, $2, $3)
+			`, pr.ID, TxStarter, grant.Stars); err != nil {
+				return nil, false, fmt.Errorf("starter stars tx: %w", err)
+			}
+		}
+		if grant.Shards > 0 {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO transactions (player_id, type, amount) VALUES ($1, $2, $3)
+			`, pr.ID, TxShardGrant, grant.Shards); err != nil {
+				return nil, false, fmt.Errorf("starter shards tx: %w", err)
+			}
+		}
+	}
+
+	p, err = scanPlayer(tx.QueryRow(ctx, `SELECT `+playerColumns+` FROM players WHERE id = $1`, pr.ID))
+	if err != nil {
+		return nil, false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+	return p, created, nil
 }
 
 func (s *PlayerStore) Get(ctx context.Context, id int64) (*Player, error) {
-	p := &Player{}
-	err := s.db.QueryRow(ctx, `
-		SELECT id, username, elo, lifetime_elo, efficiency_avg,
-		       stars_balance, shards_balance, squad_id, prestige_mult, created_at
-		FROM players WHERE id = $1
-	`, id).Scan(
-		&p.ID, &p.Username, &p.Elo, &p.LifetimeElo, &p.EfficiencyAvg,
-		&p.StarsBalance, &p.ShardsBalance, &p.SquadID, &p.PrestigeMult, &p.CreatedAt,
-	)
+	p, err := scanPlayer(s.db.QueryRow(ctx, `SELECT `+playerColumns+` FROM players WHERE id = $1`, id))
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
@@ -69,6 +154,61 @@ func (s *PlayerStore) UpdateBalance(ctx context.Context, id int64, starsDelta, s
 	return err
 }
 
+var ErrNegativeBalance = errors.New("balance would become negative")
+
+// AdjustBalance applies an operator correction through the ledger and writes
+// the audit entry with the before/after balances, all in one transaction.
+// Returns nil, nil when the player does not exist.
+func (s *PlayerStore) AdjustBalance(ctx context.Context, id, starsDelta, shardsDelta int64, audit AuditEntry) (before, after *Player, err error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	before, err = scanPlayer(tx.QueryRow(ctx, `SELECT `+playerColumns+` FROM players WHERE id = $1 FOR UPDATE`, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if before.StarsBalance+starsDelta < 0 || before.ShardsBalance+shardsDelta < 0 {
+		return nil, nil, ErrNegativeBalance
+	}
+
+	after, err = scanPlayer(tx.QueryRow(ctx, `
+		UPDATE players SET stars_balance = stars_balance + $2, shards_balance = shards_balance + $3
+		WHERE id = $1
+		RETURNING `+playerColumns, id, starsDelta, shardsDelta))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, e := range []struct {
+		t      TxType
+		amount int64
+	}{{TxAdjustment, starsDelta}, {TxShardAdjustment, shardsDelta}} {
+		if e.amount == 0 {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO transactions (player_id, type, amount) VALUES ($1, $2, $3)
+		`, id, e.t, e.amount); err != nil {
+			return nil, nil, fmt.Errorf("ledger: %w", err)
+		}
+	}
+
+	audit.Before, _ = json.Marshal(map[string]int64{"stars": before.StarsBalance, "shards": before.ShardsBalance})
+	audit.After, _ = json.Marshal(map[string]int64{"stars": after.StarsBalance, "shards": after.ShardsBalance})
+	if err := insertAudit(ctx, tx, audit); err != nil {
+		return nil, nil, fmt.Errorf("audit: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
 func (s *PlayerStore) UpdateElo(ctx context.Context, id int64, newElo int) error {
 	_, err := s.db.Exec(ctx, `
 		UPDATE players
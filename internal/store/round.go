@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Round is one played round, stored in the rooms table (one row per round).
+type Round struct {
+	ID          string
+	SlotID      string // in-memory room ID, reused across rounds
+	Type        string
+	Tier        int
+	EntryCost   int64
+	Pool        int64
+	State       string
+	WinnerID    *int64
+	PlayerCount int
+	StartedAt   *time.Time
+	EndedAt     *time.Time
+	CreatedAt   time.Time
+	Economy     json.RawMessage // economy policy the round was played under; empty for early rounds
+}
+
+// RoundDetail adds settlement data to a round once it has finished.
+type RoundDetail struct {
+	Round
+	Placements []int64
+	Settled    bool
+}
+
+// RoundCursor identifies the last row of a page; the next page starts strictly after it.
+type RoundCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// RoundFilter narrows a round listing. Zero values mean "any".
+type RoundFilter struct {
+	Type   string
+	Tier   int
+	State  string
+	Before time.Time // only rounds created strictly before this time
+	After  *RoundCursor
+	Limit  int
+}
+
+type RoundStore struct {
+	db *pgxpool.Pool
+}
+
+func NewRoundStore(db *pgxpool.Pool) *RoundStore {
+	return &RoundStore{db: db}
+}
+
+const roundColumns = `id, slot_id, type, tier, entry_cost, pool, state, winner_id,
+	player_count, started_at, ended_at, created_at, COALESCE(economy_policy::text, '')`
+
+// Start inserts the row for a round that has just left the waiting state.
+func (s *RoundStore) Start(ctx context.Context, rd *Round) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO rooms (id, slot_id, type, tier, entry_cost, pool, state, player_count, started_at, economy_policy)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO NOTHING
+	`, rd.ID, rd.SlotID, rd.Type, rd.Tier, rd.EntryCost, rd.Pool, rd.State, rd.PlayerCount, rd.StartedAt, nullJSON(rd.Economy))
+	return err
+}
+
+// Void records a round that an operator terminated before it could finish.
+// Voided rounds are never settled.
+func (s *RoundStore) Void(ctx context.Context, rd *Round) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO rooms (id, slot_id, type, tier, entry_cost, pool, state, player_count, started_at, ended_at, economy_policy)
+		VALUES ($1, $2, $3, $4, $5, $6, 'voided', $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE SET state = 'voided', pool = EXCLUDED.pool,
+			player_count = EXCLUDED.player_count, ended_at = EXCLUDED.ended_at
+	`, rd.ID, rd.SlotID, rd.Type, rd.Tier, rd.EntryCost, rd.Pool, rd.PlayerCount, rd.StartedAt, rd.EndedAt, nullJSON(rd.Economy))
+	return err
+}
+
+func (s *RoundStore) Get(ctx context.Context, id string) (*RoundDetail, error) {
+	d := &RoundDetail{}
+	var settledAt *time.Time
+	var economy string
+	err := s.db.QueryRow(ctx, `
+		SELECT r.id, r.slot_id, r.type, r.tier, r.entry_cost, r.pool, r.state, r.winner_id,
+		       r.player_count, r.started_at, r.ended_at, r.created_at, COALESCE(r.economy_policy::text, ''),
+		       COALESCE(rs.placements, '{}'), rs.settled_at
+		FROM rooms r
+		LEFT JOIN round_settlements rs ON rs.round_id = r.id
+		WHERE r.id = $1
+	`, id).Scan(
+		&d.ID, &d.SlotID, &d.Type, &d.Tier, &d.EntryCost, &d.Pool, &d.State, &d.WinnerID,
+		&d.PlayerCount, &d.StartedAt, &d.EndedAt, &d.CreatedAt, &economy,
+		&d.Placements, &settledAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if economy != "" {
+		d.Economy = json.RawMessage(economy)
+	}
+	d.Settled = settledAt != nil
+	return d, err
+}
+
+// List returns rounds newest first.
+func (s *RoundStore) List(ctx context.Context, f RoundFilter) ([]Round, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	var before, afterTS *time.Time
+	var afterID *string
+	if !f.Before.IsZero() {
+		before = &f.Before
+	}
+	if f.After != nil {
+		afterTS = &f.After.CreatedAt
+		afterID = &f.After.ID
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT `+roundColumns+`
+		FROM rooms
+		WHERE ($1 = '' OR type::text = $1)
+		  AND ($2 = 0 OR tier = $2)
+		  AND ($3 = '' OR state::text = $3)
+		  AND ($4::timestamptz IS NULL OR created_at < $4)
+		  AND ($5::timestamptz IS NULL OR (created_at, id) < ($5, $6::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $7
+	`, f.Type, f.Tier, f.State, before, afterTS, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Round
+	for rows.Next() {
+		var rd Round
+		var economy string
+		if err := rows.Scan(
+			&rd.ID, &rd.SlotID, &rd.Type, &rd.Tier, &rd.EntryCost, &rd.Pool, &rd.State, &rd.WinnerID,
+			&rd.PlayerCount, &rd.StartedAt, &rd.EndedAt, &rd.CreatedAt, &economy,
+		); err != nil {
+			return nil, err
+		}
+		if economy != "" {
+			rd.Economy = json.RawMessage(economy)
+		}
+		out = append(out, rd)
+	}
+	return out, rows.Err()
+}
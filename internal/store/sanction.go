@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SanctionKind string
+
+const (
+	SanctionBan           SanctionKind = "ban"            // permanent lockout
+	SanctionSuspension    SanctionKind = "suspension"     // temporary lockout
+	SanctionMute          SanctionKind = "mute"           // may connect and join, not pulse
+	SanctionEconomyFreeze SanctionKind = "economy_freeze" // no balance movements
+)
+
+// Valid reports whether k is a known sanction kind.
+func (k SanctionKind) Valid() bool {
+	switch k {
+	case SanctionBan, SanctionSuspension, SanctionMute, SanctionEconomyFreeze:
+		return true
+	}
+	return false
+}
+
+type Sanction struct {
+	ID        int64
+	PlayerID  int64
+	Kind      SanctionKind
+	Reason    string
+	IssuedBy  string
+	ExpiresAt *time.Time // nil = until lifted
+	LiftedAt  *time.Time
+	LiftedBy  string
+	CreatedAt time.Time
+}
+
+// ActiveAt reports whether the sanction is in force at t.
+func (s *Sanction) ActiveAt(t time.Time) bool {
+	return s.LiftedAt == nil && (s.ExpiresAt == nil || t.Before(*s.ExpiresAt))
+}
+
+type SanctionStore struct {
+	db *pgxpool.Pool
+}
+
+func NewSanctionStore(db *pgxpool.Pool) *SanctionStore {
+	return &SanctionStore{db: db}
+}
+
+const sanctionColumns = `id, player_id, kind, reason, issued_by, expires_at, lifted_at, lifted_by, created_at`
+
+func scanSanction(row pgx.Row) (*Sanction, error) {
+	s := &Sanction{}
+	err := row.Scan(&s.ID, &s.PlayerID, &s.Kind, &s.Reason, &s.IssuedBy,
+		&s.ExpiresAt, &s.LiftedAt, &s.LiftedBy, &s.CreatedAt)
+	return s, err
+}
+
+func (s *SanctionStore) Create(ctx context.Context, sn Sanction) (*Sanction, error) {
+	return scanSanction(s.db.QueryRow(ctx, `
+		INSERT INTO player_sanctions (player_id, kind, reason, issued_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+sanctionColumns,
+		sn.PlayerID, sn.Kind, sn.Reason, sn.IssuedBy, sn.ExpiresAt))
+}
+
+// Lift ends a sanction early. Returns nil when it does not exist or was already lifted.
+func (s *SanctionStore) Lift(ctx context.Context, id int64, by string) (*Sanction, error) {
+	sn, err := scanSanction(s.db.QueryRow(ctx, `
+		UPDATE player_sanctions SET lifted_at = NOW(), lifted_by = $2
+		WHERE id = $1 AND lifted_at IS NULL
+		RETURNING `+sanctionColumns, id, by))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return sn, err
+}
+
+// Active returns the sanctions currently in force for a player.
+func (s *SanctionStore) Active(ctx context.Context, playerID int64) ([]Sanction, error) {
+	return s.query(ctx, `
+		SELECT `+sanctionColumns+` FROM player_sanctions
+		WHERE player_id = $1 AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY created_at DESC
+	`, playerID)
+}
+
+// ForPlayer returns a player's full sanction history, newest first.
+func (s *SanctionStore) ForPlayer(ctx context.Context, playerID int64) ([]Sanction, error) {
+	return s.query(ctx, `
+		SELECT `+sanctionColumns+` FROM player_sanctions
+		WHERE player_id = $1 ORDER BY created_at DESC
+	`, playerID)
+}
+
+func (s *SanctionStore) query(ctx context.Context, sql string, args ...any) ([]Sanction, error) {
+	rows, err := s.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Sanction
+	for rows.Next() {
+		sn, err := scanSanction(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *sn)
+	}
+	return out, rows.Err()
+}
@@ -45,3 +45,24 @@ func (s *SeasonStore) Create(ctx context.Context, start, end time.Time) (*Season
 	`, start, end).Scan(&se.ID, &se.StartDate, &se.EndDate, &se.IsActive)
 	return se, err
 }
+
+// List returns all seasons, newest first.
+func (s *SeasonStore) List(ctx context.Context) ([]Season, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, start_date, end_date, is_active FROM seasons ORDER BY id DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Season
+	for rows.Next() {
+		var se Season
+		if err := rows.Scan(&se.ID, &se.StartDate, &se.EndDate, &se.IsActive); err != nil {
+			return nil, err
+		}
+		out = append(out, se)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,309 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RoundSettlement is the durable "round finished" record. It is written once
+// when a round ends and carries everything needed to settle it later.
+type RoundSettlement struct {
+	ID            int64
+	RoundID       string
+	RoomID        string
+	RoomType      string
+	Tier          int
+	EntryCost     int64
+	Pool          int64
+	VolatilityMul float64
+	PlayerIDs     []int64
+	Placements    []int64
+	CoSurvivors   int // players alive at the end; they head Placements
+	StartedAt     *time.Time
+	EndedAt       *time.Time
+	Economy       json.RawMessage // policy snapshot; empty for rounds recorded before policies were stored
+	Rake          int64
+	WarChest      int64 // Stars actually credited to squad war chests
+	House         int64
+	Dust          int64
+	DustTo        string
+	Withheld      int64   // Stars kept from sanctioned or deleted players; included in House
+	WithheldFrom  []int64 // players whose credits were withheld
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	SettledAt     *time.Time
+	CreatedAt     time.Time
+}
+
+// LedgerEntry is a single balance change applied during settlement.
+type LedgerEntry struct {
+	PlayerID int64
+	Type     TxType
+	Stars    int64
+	Shards   int64
+}
+
+// SettlementResult is what a settlement applies in one transaction. Entries'
+// Stars, House, WarChestShare per player and WarChestDust add up to Pool.
+type SettlementResult struct {
+	Entries       []LedgerEntry
+	Pool          int64
+	Rake          int64
+	WarChest      int64
+	WarChestShare int64 // per player in the round; credited to their squad
+	House         int64
+	Dust          int64
+	DustTo        string
+	WarChestDust  int64 // credited to WinnerID's squad
+	WinnerID      int64
+	Withheld      int64 // Stars kept from sanctioned players; included in House
+	WithheldFrom  []int64
+}
+
+// ErrUnbalanced means a settlement's outputs do not add up to its pool.
+var ErrUnbalanced = errors.New("settlement does not balance")
+
+type SettlementStore struct {
+	db *pgxpool.Pool
+}
+
+func NewSettlementStore(db *pgxpool.Pool) *SettlementStore {
+	return &SettlementStore{db: db}
+}
+
+const settlementColumns = `id, COALESCE(round_id::text, ''), room_id, room_type, tier, entry_cost, pool, volatility_mul,
+	player_ids, placements, co_survivors, started_at, ended_at, COALESCE(economy_policy::text, ''), rake, war_chest,
+	house, dust, dust_to, withheld, withheld_players, attempts, last_error, next_attempt_at, settled_at, created_at`
+
+// Enqueue writes the round finished record together with the finished row in
+// rooms, in one transaction. Enqueueing the same round twice is a no-op, so
+// callers may retry after an ambiguous failure. ID and timestamps are filled in.
+func (s *SettlementStore) Enqueue(ctx context.Context, rs *RoundSettlement) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var winner *int64
+	if len(rs.Placements) > 0 {
+		winner = &rs.Placements[0]
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO rooms (id, slot_id, type, tier, entry_cost, pool, state, winner_id, player_count, started_at, ended_at, economy_policy)
+		VALUES ($1, $2, $3, $4, $5, $6, 'finished', $7, $8, $9, $10, $11)
+		ON CONFLICT (id) DO UPDATE SET
+			pool = EXCLUDED.pool,
+			state = 'finished',
+			winner_id = EXCLUDED.winner_id,
+			player_count = EXCLUDED.player_count,
+			ended_at = EXCLUDED.ended_at,
+			economy_policy = COALESCE(rooms.economy_policy, EXCLUDED.economy_policy)
+	`, rs.RoundID, rs.RoomID, rs.RoomType, rs.Tier, rs.EntryCost, rs.Pool, winner,
+		len(rs.PlayerIDs), rs.StartedAt, rs.EndedAt, nullJSON(rs.Economy)); err != nil {
+		return fmt.Errorf("upsert round: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO round_settlements
+			(round_id, room_id, room_type, tier, entry_cost, pool, volatility_mul,
+			 player_ids, placements, co_survivors, started_at, ended_at, economy_policy)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (round_id) DO UPDATE SET round_id = EXCLUDED.round_id
+		RETURNING id, next_attempt_at, created_at
+	`, rs.RoundID, rs.RoomID, rs.RoomType, rs.Tier, rs.EntryCost, rs.Pool, rs.VolatilityMul,
+		rs.PlayerIDs, rs.Placements, rs.CoSurvivors, rs.StartedAt, rs.EndedAt, nullJSON(rs.Economy),
+	).Scan(&rs.ID, &rs.NextAttemptAt, &rs.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert settlement: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// Due returns unsettled rounds whose next attempt time has passed, oldest first.
+func (s *SettlementStore) Due(ctx context.Context, limit int) ([]RoundSettlement, error) {
+	return s.list(ctx, `
+		SELECT `+settlementColumns+`
+		FROM round_settlements
+		WHERE settled_at IS NULL AND next_attempt_at <= NOW()
+		ORDER BY id LIMIT $1
+	`, limit)
+}
+
+// Unsettled returns every round that has not been settled yet, oldest first.
+func (s *SettlementStore) Unsettled(ctx context.Context, limit int) ([]RoundSettlement, error) {
+	return s.list(ctx, `
+		SELECT `+settlementColumns+`
+		FROM round_settlements
+		WHERE settled_at IS NULL
+		ORDER BY id LIMIT $1
+	`, limit)
+}
+
+// Settle applies the result and marks the round settled in a single transaction.
+// Returns false without error if the round was already settled by someone else.
+func (s *SettlementStore) Settle(ctx context.Context, id int64, res SettlementResult) (bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var settledAt *time.Time
+	var roundID *string
+	var playerIDs []int64
+	err = tx.QueryRow(ctx, `
+		SELECT settled_at, round_id, player_ids FROM round_settlements WHERE id = $1 FOR UPDATE
+	`, id).Scan(&settledAt, &roundID, &playerIDs)
+	if err != nil {
+		return false, fmt.Errorf("lock settlement: %w", err)
+	}
+	if settledAt != nil {
+		return false, nil
+	}
+
+	// A credit for a player row that no longer exists can never be applied;
+	// retrying would hold the round forever, so it is withheld like a
+	// sanctioned player's and stays with the house.
+	house, dustTo := res.House, res.DustTo
+	withheld, withheldFrom := res.Withheld, append([]int64{}, res.WithheldFrom...)
+	var paid int64
+	for _, e := range res.Entries {
+		tag, err := tx.Exec(ctx, `
+			UPDATE players
+			SET stars_balance = stars_balance + $2,
+			    shards_balance = shards_balance + $3
+			WHERE id = $1
+		`, e.PlayerID, e.Stars, e.Shards)
+		if err != nil {
+			return false, fmt.Errorf("update balance %d: %w", e.PlayerID, err)
+		}
+		if tag.RowsAffected() == 0 {
+			house += e.Stars
+			withheld += e.Stars
+			if !slices.Contains(withheldFrom, e.PlayerID) {
+				withheldFrom = append(withheldFrom, e.PlayerID)
+			}
+			continue
+		}
+		paid += e.Stars
+		amount := e.Stars
+		if e.Shards != 0 {
+			amount = e.Shards
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO transactions (player_id, type, amount, room_id) VALUES ($1, $2, $3, $4)
+		`, e.PlayerID, e.Type, amount, roundID); err != nil {
+			return false, fmt.Errorf("record %s %d: %w", e.Type, e.PlayerID, err)
+		}
+	}
+
+	// Players outside a squad have no war chest; their shares, and dust for a
+	// squadless winner, stay with the house.
+	var warChest int64
+	if res.WarChestShare > 0 {
+		rows, err := tx.Query(ctx, `
+			UPDATE squads SET war_chest = war_chest + $2 * m.members
+			FROM (
+				SELECT squad_id, COUNT(*) AS members FROM players
+				WHERE id = ANY($1) AND squad_id IS NOT NULL
+				GROUP BY squad_id
+			) m
+			WHERE squads.id = m.squad_id
+			RETURNING m.members
+		`, playerIDs, res.WarChestShare)
+		if err != nil {
+			return false, fmt.Errorf("war chest: %w", err)
+		}
+		for rows.Next() {
+			var members int64
+			if err := rows.Scan(&members); err != nil {
+				rows.Close()
+				return false, fmt.Errorf("war chest: %w", err)
+			}
+			warChest += members * res.WarChestShare
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return false, fmt.Errorf("war chest: %w", err)
+		}
+	}
+	house += res.WarChestShare*int64(len(playerIDs)) - warChest
+	if res.WarChestDust > 0 {
+		tag, err := tx.Exec(ctx, `
+			UPDATE squads SET war_chest = war_chest + $2
+			WHERE id = (SELECT squad_id FROM players WHERE id = $1)
+		`, res.WinnerID, res.WarChestDust)
+		if err != nil {
+			return false, fmt.Errorf("war chest dust: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			house += res.WarChestDust
+			dustTo = "house"
+		} else {
+			warChest += res.WarChestDust
+		}
+	}
+
+	if paid+house+warChest != res.Pool {
+		return false, fmt.Errorf("%w: pool %d, paid %d, house %d, war chest %d", ErrUnbalanced, res.Pool, paid, house, warChest)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE round_settlements
+		SET settled_at = NOW(), rake = $2, war_chest = $3, house = $4, dust = $5, dust_to = $6,
+		    withheld = $7, withheld_players = $8, attempts = attempts + 1, last_error = ''
+		WHERE id = $1
+	`, id, res.Rake, warChest, house, res.Dust, dustTo, withheld, withheldFrom); err != nil {
+		return false, fmt.Errorf("mark settled: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MarkFailed records a failed attempt and schedules the next one.
+func (s *SettlementStore) MarkFailed(ctx context.Context, id int64, cause error, retryIn time.Duration) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE round_settlements
+		SET attempts = attempts + 1, last_error = $2,
+		    next_attempt_at = NOW() + $3 * INTERVAL '1 millisecond'
+		WHERE id = $1 AND settled_at IS NULL
+	`, id, cause.Error(), retryIn.Milliseconds())
+	return err
+}
+
+func (s *SettlementStore) list(ctx context.Context, query string, args ...any) ([]RoundSettlement, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []RoundSettlement
+	for rows.Next() {
+		var rs RoundSettlement
+		var economy string
+		if err := rows.Scan(
+			&rs.ID, &rs.RoundID, &rs.RoomID, &rs.RoomType, &rs.Tier, &rs.EntryCost, &rs.Pool, &rs.VolatilityMul,
+			&rs.PlayerIDs, &rs.Placements, &rs.CoSurvivors, &rs.StartedAt, &rs.EndedAt, &economy, &rs.Rake, &rs.WarChest,
+			&rs.House, &rs.Dust, &rs.DustTo, &rs.Withheld, &rs.WithheldFrom, &rs.Attempts, &rs.LastError, &rs.NextAttemptAt, &rs.SettledAt, &rs.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if economy != "" {
+			rs.Economy = json.RawMessage(economy)
+		}
+		out = append(out, rs)
+	}
+	return out, rows.Err()
+}
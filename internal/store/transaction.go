@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -16,6 +17,11 @@ const (
 	TxPayout     TxType = "payout"
 	TxShardGrant TxType = "shard_grant"
 	TxCosmetic   TxType = "cosmetic"
+	TxStarter    TxType = "starter"
+
+	// Operator corrections, written by the admin API.
+	TxAdjustment      TxType = "adjustment"
+	TxShardAdjustment TxType = "shard_adjustment"
 )
 
 type Transaction struct {
@@ -63,3 +69,126 @@ func (s *TransactionStore) PlayerHistory(ctx context.Context, playerID int64, li
 	}
 	return out, rows.Err()
 }
+
+// shardTxTypes move the shards balance; every other type moves Stars.
+var shardTxTypes = []TxType{TxShardGrant, TxCosmetic, TxShardAdjustment}
+
+// Currency reports which balance a transaction type moves: "stars" or "shards".
+func (t TxType) Currency() string {
+	if slices.Contains(shardTxTypes, t) {
+		return "shards"
+	}
+	return "stars"
+}
+
+// shardTypeNames is shardTxTypes as a query parameter.
+func shardTypeNames() []string {
+	out := make([]string, len(shardTxTypes))
+	for i, t := range shardTxTypes {
+		out[i] = string(t)
+	}
+	return out
+}
+
+// Valid reports whether t is a known transaction type.
+func (t TxType) Valid() bool {
+	switch t {
+	case TxEntry, TxPulse, TxRake, TxPayout, TxShardGrant, TxCosmetic, TxStarter,
+		TxAdjustment, TxShardAdjustment:
+		return true
+	}
+	return false
+}
+
+// HistoryCursor identifies the last row of a page; the next page starts strictly after it.
+type HistoryCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// HistoryFilter narrows a player's transaction history. Zero values mean "any".
+type HistoryFilter struct {
+	PlayerID int64
+	Types    []TxType
+	RoomID   string
+	From     time.Time // inclusive
+	To       time.Time // exclusive
+	After    *HistoryCursor
+	Limit    int
+}
+
+// HistoryEntry is a transaction with the player's balance in its currency right after it.
+type HistoryEntry struct {
+	Transaction
+	Currency string
+	Balance  int64
+}
+
+// History returns a page of a player's transactions, newest first, ordered by
+// (created_at, id) so pagination is stable across rows with equal timestamps.
+// Running balances are computed over the player's full ledger before filtering.
+func (s *TransactionStore) History(ctx context.Context, f HistoryFilter) ([]HistoryEntry, error) {
+	types := make([]string, len(f.Types))
+	for i, t := range f.Types {
+		types[i] = string(t)
+	}
+	var (
+		room    *string
+		fromTS  *time.Time
+		toTS    *time.Time
+		afterTS *time.Time
+		afterID int64
+	)
+	if f.RoomID != "" {
+		room = &f.RoomID
+	}
+	if !f.From.IsZero() {
+		fromTS = &f.From
+	}
+	if !f.To.IsZero() {
+		toTS = &f.To
+	}
+	if f.After != nil {
+		afterTS = &f.After.CreatedAt
+		afterID = f.After.ID
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	rows, err := s.db.Query(ctx, `
+		WITH ledger AS (
+			SELECT id, player_id, type, amount, room_id, created_at,
+			       SUM(amount) OVER (
+			           PARTITION BY type::text = ANY($9)
+			           ORDER BY created_at, id
+			       ) AS balance
+			FROM transactions WHERE player_id = $1
+		)
+		SELECT id, player_id, type, amount, room_id, created_at, balance
+		FROM ledger
+		WHERE (cardinality($2::text[]) = 0 OR type::text = ANY($2))
+		  AND ($3::uuid IS NULL OR room_id = $3)
+		  AND ($4::timestamptz IS NULL OR created_at >= $4)
+		  AND ($5::timestamptz IS NULL OR created_at < $5)
+		  AND ($6::timestamptz IS NULL OR (created_at, id) < ($6, $7))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $8
+	`, f.PlayerID, types, room, fromTS, toTS, afterTS, afterID, limit, shardTypeNames())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		if err := rows.Scan(&e.ID, &e.PlayerID, &e.Type, &e.Amount, &e.RoomID, &e.CreatedAt, &e.Balance); err != nil {
+			return nil, err
+		}
+		e.Currency = e.Type.Currency()
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,22 @@
+package store
+
+import (
+	"slices"
+	"testing"
+)
+
+// History partitions running balances by shardTypeNames; it must agree with
+// Currency for every type or balances mix Stars and shards.
+func TestShardTypeNamesMatchCurrency(t *testing.T) {
+	all := []TxType{TxEntry, TxPulse, TxRake, TxPayout, TxShardGrant, TxCosmetic, TxStarter, TxAdjustment, TxShardAdjustment}
+	names := shardTypeNames()
+	for _, tx := range all {
+		inPartition := slices.Contains(names, string(tx))
+		if shards := tx.Currency() == "shards"; shards != inPartition {
+			t.Errorf("%s: Currency() = %s but shard partition membership = %v", tx, tx.Currency(), inPartition)
+		}
+	}
+	if !slices.Contains(names, string(TxShardAdjustment)) {
+		t.Errorf("shard_adjustment missing from the shard partition: %v", names)
+	}
+}
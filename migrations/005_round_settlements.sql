@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE round_settlements (
+    id              BIGSERIAL PRIMARY KEY,
+    room_id         TEXT NOT NULL,
+    room_type       room_type NOT NULL,
+    tier            INT NOT NULL,
+    entry_cost      BIGINT NOT NULL,
+    pool            BIGINT NOT NULL,
+    volatility_mul  DOUBLE PRECISION NOT NULL DEFAULT 1.0,
+    player_ids      BIGINT[] NOT NULL,
+    placements      BIGINT[] NOT NULL,
+    rake            BIGINT NOT NULL DEFAULT 0,
+    war_chest       BIGINT NOT NULL DEFAULT 0,
+    attempts        INT NOT NULL DEFAULT 0,
+    last_error      TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    settled_at      TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_round_settlements_pending ON round_settlements (next_attempt_at) WHERE settled_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS round_settlements;
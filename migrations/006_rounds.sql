@@ -0,0 +1,24 @@
+-- +goose Up
+-- rooms holds one row per round. slot_id is the in-memory room ID, which is
+-- reused by every round played in that room.
+ALTER TABLE rooms ADD COLUMN slot_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE rooms ADD COLUMN player_count INT NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_rooms_created ON rooms (created_at DESC, id DESC);
+CREATE INDEX idx_rooms_slot ON rooms (slot_id);
+
+ALTER TABLE round_settlements ADD COLUMN round_id UUID REFERENCES rooms(id);
+ALTER TABLE round_settlements ADD COLUMN started_at TIMESTAMPTZ;
+ALTER TABLE round_settlements ADD COLUMN ended_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX idx_round_settlements_round ON round_settlements (round_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_round_settlements_round;
+ALTER TABLE round_settlements DROP COLUMN IF EXISTS ended_at;
+ALTER TABLE round_settlements DROP COLUMN IF EXISTS started_at;
+ALTER TABLE round_settlements DROP COLUMN IF EXISTS round_id;
+DROP INDEX IF EXISTS idx_rooms_slot;
+DROP INDEX IF EXISTS idx_rooms_created;
+ALTER TABLE rooms DROP COLUMN IF EXISTS player_count;
+ALTER TABLE rooms DROP COLUMN IF EXISTS slot_id;
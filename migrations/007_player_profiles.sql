@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE players
+    ADD COLUMN first_name    TEXT NOT NULL DEFAULT '',
+    ADD COLUMN last_name     TEXT NOT NULL DEFAULT '',
+    ADD COLUMN language_code TEXT NOT NULL DEFAULT '',
+    ADD COLUMN is_premium    BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN photo_url     TEXT NOT NULL DEFAULT '',
+    ADD COLUMN last_seen_at  TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+ALTER TYPE tx_type ADD VALUE IF NOT EXISTS 'starter';
+
+-- +goose Down
+-- Postgres cannot drop an enum value; 'starter' stays in tx_type.
+ALTER TABLE players
+    DROP COLUMN IF EXISTS first_name,
+    DROP COLUMN IF EXISTS last_name,
+    DROP COLUMN IF EXISTS language_code,
+    DROP COLUMN IF EXISTS is_premium,
+    DROP COLUMN IF EXISTS photo_url,
+    DROP COLUMN IF EXISTS last_seen_at;
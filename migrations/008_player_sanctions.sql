@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TYPE sanction_kind AS ENUM ('ban', 'suspension', 'mute', 'economy_freeze');
+
+CREATE TABLE player_sanctions (
+    id          BIGSERIAL PRIMARY KEY,
+    player_id   BIGINT NOT NULL REFERENCES players(id),
+    kind        sanction_kind NOT NULL,
+    reason      TEXT NOT NULL DEFAULT '',
+    issued_by   TEXT NOT NULL DEFAULT '',
+    expires_at  TIMESTAMPTZ,
+    lifted_at   TIMESTAMPTZ,
+    lifted_by   TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_sanctions_player_open ON player_sanctions (player_id) WHERE lifted_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS player_sanctions;
+DROP TYPE IF EXISTS sanction_kind;
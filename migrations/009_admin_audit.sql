@@ -0,0 +1,35 @@
+-- +goose Up
+CREATE TABLE admin_audit (
+    id          BIGSERIAL PRIMARY KEY,
+    actor       TEXT NOT NULL,
+    action      TEXT NOT NULL,
+    target      TEXT NOT NULL DEFAULT '',
+    reason      TEXT NOT NULL,
+    before      JSONB,
+    after       JSONB,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_admin_audit_created ON admin_audit (id DESC);
+CREATE INDEX idx_admin_audit_target ON admin_audit (target, id DESC);
+
+-- +goose StatementBegin
+CREATE FUNCTION admin_audit_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'admin_audit is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER admin_audit_no_update BEFORE UPDATE OR DELETE ON admin_audit
+    FOR EACH ROW EXECUTE FUNCTION admin_audit_append_only();
+
+ALTER TYPE room_state ADD VALUE IF NOT EXISTS 'voided';
+ALTER TYPE tx_type ADD VALUE IF NOT EXISTS 'adjustment';
+ALTER TYPE tx_type ADD VALUE IF NOT EXISTS 'shard_adjustment';
+
+-- +goose Down
+-- Enum values 'voided', 'adjustment' and 'shard_adjustment' cannot be dropped.
+DROP TRIGGER IF EXISTS admin_audit_no_update ON admin_audit;
+DROP FUNCTION IF EXISTS admin_audit_append_only();
+DROP TABLE IF EXISTS admin_audit;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- The economy policy (rake, payout table, shard formula, war chest share) a
+-- round was played under, as JSON with its id and version. NULL for rounds
+-- recorded earlier; those were all played under standard@v1.
+ALTER TABLE rooms ADD COLUMN economy_policy JSONB;
+ALTER TABLE round_settlements ADD COLUMN economy_policy JSONB;
+
+-- +goose Down
+ALTER TABLE round_settlements DROP COLUMN IF EXISTS economy_policy;
+ALTER TABLE rooms DROP COLUMN IF EXISTS economy_policy;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Where every Star of a settled pool went: rake and war_chest already exist;
+-- house is what the house kept (its rake share, unclaimed war chest shares and
+-- any remainder sent its way), dust is the integer-division remainder and
+-- dust_to where it went.
+ALTER TABLE round_settlements ADD COLUMN house BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE round_settlements ADD COLUMN dust BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE round_settlements ADD COLUMN dust_to TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE round_settlements DROP COLUMN IF EXISTS dust_to;
+ALTER TABLE round_settlements DROP COLUMN IF EXISTS dust;
+ALTER TABLE round_settlements DROP COLUMN IF EXISTS house;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- How many players were alive when the round ended; under the split tie
+-- policy they share the top places' payouts. 0 for earlier rounds.
+ALTER TABLE round_settlements ADD COLUMN co_survivors INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE round_settlements DROP COLUMN IF EXISTS co_survivors;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Payouts held back from players under a ban, suspension or economy freeze
+-- when their round settled. The Stars stay with the house (and are counted in
+-- house); withheld_players lets an operator reissue them with an adjustment.
+ALTER TABLE round_settlements ADD COLUMN withheld BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE round_settlements ADD COLUMN withheld_players BIGINT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE round_settlements DROP COLUMN IF EXISTS withheld_players;
+ALTER TABLE round_settlements DROP COLUMN IF EXISTS withheld;
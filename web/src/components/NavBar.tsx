@@ -15,7 +15,7 @@ export function NavBar({
   title,
 }: NavBarProps) {
   const { state } = useGame();
-  const { connected } = useSocket();
+  const { connected, replaced, reconnect } = useSocket();
 
   return (
     <div className="sticky top-0 z-50 border-b border-border/50 bg-background/80 backdrop-blur-sm safe-top">
@@ -65,6 +65,14 @@ export function NavBar({
           )}
         </nav>
       </div>
+      {replaced && (
+        <div className="max-w-7xl mx-auto px-3 sm:px-4 pb-3 flex items-center justify-between gap-2 text-xs text-muted-foreground">
+          <span>Last Click is open on another device or tab.</span>
+          <Button variant="outline" size="sm" onClick={reconnect}>
+            Play here
+          </Button>
+        </div>
+      )}
     </div>
   );
 }
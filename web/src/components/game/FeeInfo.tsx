@@ -0,0 +1,65 @@
+import { useGame } from "@/context/GameContext";
+import { TIE_POLICY_LABELS } from "@/types/game";
+
+export function FeeInfo() {
+  const { state } = useGame();
+  const room = state.currentRoom;
+  const economy = room?.economy;
+
+  if (!room || !economy || room.tier === 0) return null;
+
+  let split = economy.payouts[0]?.shares_pct ?? [];
+  for (const p of economy.payouts) {
+    if (p.min_players <= Math.max(room.total, 1)) split = p.shares_pct;
+  }
+  const pct = (r: number) => `${Math.round(r * 100)}%`;
+  const dustTo = {
+    house: "House",
+    winner: "Winner",
+    war_chest: "Winner's squad",
+  }[economy.dust_to ?? "house"];
+
+  const ties =
+    economy.ties_by?.[`tier${room.tier}`] ??
+    economy.ties_by?.[room.type] ??
+    economy.ties ??
+    "hash";
+
+  const rows: [string, string][] = [
+    ["Rake", `${economy.rake_pct}% of pool`],
+    ["Payout split", split.map((s) => `${s}%`).join(" / ")],
+    [
+      "Shards back",
+      `${pct(economy.shard_ratio_min)}–${pct(economy.shard_ratio_max)} of entry`,
+    ],
+    ["Ties", TIE_POLICY_LABELS[ties]],
+    ["Remainder", dustTo],
+    [
+      "Pulse cost",
+      economy.pulse_cost > 0 ? `${economy.pulse_cost}⭐` : "Free",
+    ],
+  ];
+
+  return (
+    <div className="rounded-lg border border-border/50 bg-card/50 backdrop-blur-sm p-4 sm:p-6">
+      <div className="flex items-center justify-between mb-3">
+        <h3 className="font-bold text-foreground text-sm sm:text-base">
+          Fees
+        </h3>
+        <span className="text-xs text-muted-foreground font-mono">
+          {economy.id} v{economy.version}
+        </span>
+      </div>
+      <div className="space-y-2">
+        {rows.map(([label, value]) => (
+          <div key={label} className="flex items-center justify-between">
+            <p className="text-xs text-muted-foreground uppercase tracking-wide">
+              {label}
+            </p>
+            <p className="text-sm text-foreground font-mono">{value}</p>
+          </div>
+        ))}
+      </div>
+    </div>
+  );
+}
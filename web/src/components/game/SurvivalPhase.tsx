@@ -1,5 +1,6 @@
 import { useGame } from "@/context/GameContext";
 import { useTelegram } from "@/context/TelegramProvider";
+import { useSocket } from "@/context/SocketContext";
 import { TIERS } from "@/types/game";
 import { Button } from "@/components/ui/button";
 import { useState, useEffect, useRef } from "react";
@@ -44,6 +45,7 @@ function efficiencyDelta(
 export function SurvivalPhase() {
   const { state, pulse } = useGame();
   const { userId } = useTelegram();
+  const { serverNow } = useSocket();
   const room = state.currentRoom;
   const [cooldownUntil, setCooldownUntil] = useState(0);
   const [confirmUntil, setConfirmUntil] = useState(0);
@@ -95,6 +97,15 @@ export function SurvivalPhase() {
   const tier = TIERS[room.tier];
   const timerSec = Math.max(0, Math.ceil(room.timer_ms / 1000));
   const timerDisplaySec = Math.max(0, room.timer_ms / 1000);
+  // Personal deadline is judged on the server clock; serverNow() corrects for
+  // local clock skew. Falls back to the room timer until the first tick.
+  const myDeadline = room.pulse_deadlines?.find(
+    (d) => d.player_id === userId,
+  )?.pulse_deadline_server_ms;
+  const pulseDueSec =
+    myDeadline != null
+      ? Math.max(0, (myDeadline - serverNow()) / 1000)
+      : timerDisplaySec;
   const isUrgent = timerSec <= 5;
   const zone = riskZone(room.margin_ratio);
   const efficiency =
@@ -154,7 +165,7 @@ export function SurvivalPhase() {
           <p>
             Next required pulse in:{" "}
             <span className="font-mono text-foreground">
-              {timerDisplaySec.toFixed(1)}s
+              {pulseDueSec.toFixed(1)}s
             </span>
           </p>
           {efficiency != null && (
@@ -32,7 +32,7 @@ export function HeroSection() {
 
   useEffect(() => {
     const fetchMetrics = () =>
-      fetch("/metrics")
+      fetch("/metrics?format=json")
         .then((r) => r.json())
         .then(setMetrics)
         .catch(() => {});
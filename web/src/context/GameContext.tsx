@@ -9,6 +9,7 @@ import {
 import { useSocket } from "@/context/SocketContext";
 import { useTelegram } from "@/context/TelegramProvider";
 import { getPlayer, NotFoundError } from "@/lib/api";
+import type { CommandError } from "@/lib/ws";
 import type {
   RoomInfo,
   RoomStatePayload,
@@ -16,6 +17,7 @@ import type {
   EliminationPayload,
   PulseAckPayload,
   PlayerProfile,
+  TiePolicy,
 } from "@/types/game";
 import type {
   DebugCommand,
@@ -27,6 +29,10 @@ import type {
 export interface RoundResultPayload {
   placement: number;
   shards: number;
+  stars?: number;
+  /** Set for co-survivors: how the tie among them was resolved. */
+  tie_policy?: TiePolicy;
+  co_survivors?: number;
 }
 
 interface GameState {
@@ -44,6 +50,8 @@ interface GameState {
   forfeited: boolean;
   /** Set when round finishes; used for results screen (placement, shards). Cleared on CLEAR_ROOM or when room leaves finished. */
   roundResult: RoundResultPayload | null;
+  /** Why the last command failed, from the server's error reply. */
+  lastError?: CommandError | null;
 }
 
 const initialState: GameState = {
@@ -74,6 +82,7 @@ type Action =
   | { type: "PULSE_ACK"; payload: PulseAckPayload }
   | { type: "ROUND_RESULT"; payload: RoundResultPayload }
   | { type: "FORFEIT" }
+  | { type: "COMMAND_ERROR"; payload: CommandError | null }
   | { type: "CLEAR_ROOM" };
 
 function reducer(state: GameState, action: Action): GameState {
@@ -160,6 +169,9 @@ function reducer(state: GameState, action: Action): GameState {
           margin_ratio: action.payload.margin_ratio,
           volatility_mul: action.payload.volatility_mul,
           alive: action.payload.alive,
+          pulse_deadlines:
+            action.payload.pulse_deadlines ??
+            state.currentRoom.pulse_deadlines,
         },
         marginHistory: history,
       };
@@ -181,11 +193,22 @@ function reducer(state: GameState, action: Action): GameState {
 
     case "PULSE_ACK": {
       if (!state.currentRoom) return state;
+      const { player_id, pulse_deadline_server_ms } = action.payload;
+      const deadlines =
+        pulse_deadline_server_ms == null
+          ? state.currentRoom.pulse_deadlines
+          : [
+              ...(state.currentRoom.pulse_deadlines ?? []).filter(
+                (d) => d.player_id !== player_id,
+              ),
+              { player_id, pulse_deadline_server_ms },
+            ];
       return {
         ...state,
         currentRoom: {
           ...state.currentRoom,
           timer_ms: action.payload.timer_ms,
+          pulse_deadlines: deadlines,
         },
         lastPulseAck: action.payload,
       };
@@ -194,6 +217,9 @@ function reducer(state: GameState, action: Action): GameState {
     case "FORFEIT":
       return { ...state, forfeited: true };
 
+    case "COMMAND_ERROR":
+      return { ...state, lastError: action.payload };
+
     case "CLEAR_ROOM":
       return {
         ...state,
@@ -251,7 +277,7 @@ export function useGame() {
 }
 
 export function GameProvider({ children }: { children: ReactNode }) {
-  const { send, on, connected } = useSocket();
+  const { send, request, on, connected, lastSeq } = useSocket();
   const { userId } = useTelegram();
   const [state, dispatch] = useReducer(reducer, initialState);
 
@@ -293,6 +319,9 @@ export function GameProvider({ children }: { children: ReactNode }) {
       on("pulse_ack", (payload) => {
         dispatch({ type: "PULSE_ACK", payload: payload as PulseAckPayload });
       }),
+      on("error", (payload) => {
+        dispatch({ type: "COMMAND_ERROR", payload: payload as CommandError });
+      }),
       on("round_result", (payload) => {
         dispatch({
           type: "ROUND_RESULT",
@@ -303,18 +332,28 @@ export function GameProvider({ children }: { children: ReactNode }) {
     return () => unsubs.forEach((fn) => fn());
   }, [on, connected]);
 
-  // On reconnect, request sync so server restores room state if still alive
+  // On reconnect, resume from the last room broadcast seen so missed messages
+  // are replayed; with nothing seen yet, a plain sync restores room state.
   useEffect(() => {
     if (connected) {
-      send("sync");
+      const seq = lastSeq();
+      if (seq > 0) {
+        send("resume", { last_seq: seq });
+      } else {
+        send("sync");
+      }
       send("list_rooms");
     }
-  }, [connected, send]);
+  }, [connected, send, lastSeq]);
 
   const listRooms = useCallback(() => send("list_rooms"), [send]);
   const joinRoom = useCallback(
-    (roomId: string) => send("join_room", { room_id: roomId }),
-    [send],
+    (roomId: string) => {
+      dispatch({ type: "COMMAND_ERROR", payload: null });
+      // Failures arrive through the "error" listener; nothing to do here.
+      request("join_room", { room_id: roomId }).catch(() => {});
+    },
+    [request],
   );
   const pulse = useCallback(() => send("pulse"), [send]);
 
@@ -7,19 +7,38 @@ import {
   useCallback,
   type ReactNode,
 } from "react";
-import { GameSocket } from "@/lib/ws";
+import { GameSocket, type CommandError } from "@/lib/ws";
+import { session } from "@/lib/session";
 import { useTelegram } from "@/context/TelegramProvider";
 
 interface SocketContextType {
   connected: boolean;
+  /** Set when another tab or device took over this player's session. */
+  replaced: boolean;
+  reconnect: () => void;
   send: (type: string, payload?: unknown) => void;
+  /** Like send, but resolves on the server's ack and rejects with its error. */
+  request: (type: string, payload?: unknown) => Promise<unknown>;
   on: (type: string, listener: (payload: unknown) => void) => () => void;
+  lastSeq: () => number;
+  /** Current server time in unix ms, corrected by time_sync. */
+  serverNow: () => number;
 }
 
 const SocketContext = createContext<SocketContextType>({
   connected: false,
+  replaced: false,
+  reconnect: () => {},
   send: () => {},
+  request: () =>
+    Promise.reject<unknown>({
+      type: "",
+      code: "disconnected",
+      message: "Not connected",
+    } satisfies CommandError),
   on: () => () => {},
+  lastSeq: () => 0,
+  serverNow: () => Date.now(),
 });
 
 export function useSocket() {
@@ -30,13 +49,22 @@ export function SocketProvider({ children }: { children: ReactNode }) {
   const { userId, initDataRaw } = useTelegram();
   const socketRef = useRef<GameSocket | null>(null);
   const [connected, setConnected] = useState(false);
+  const [replaced, setReplaced] = useState(false);
 
   useEffect(() => {
-    let params: string;
+    // Exchange initData for a session once and reconnect with its token;
+    // initData itself is only accepted for 5 minutes.
+    let params: () => Promise<string>;
     if (initDataRaw) {
-      params = `initData=${encodeURIComponent(initDataRaw)}`;
+      session.start(initDataRaw);
+      params = async () => {
+        const token = await session.token();
+        return token
+          ? `token=${encodeURIComponent(token)}`
+          : `initData=${encodeURIComponent(initDataRaw)}`;
+      };
     } else {
-      params = `user_id=${userId ?? 1}`;
+      params = () => Promise.resolve(`user_id=${userId ?? 1}`);
     }
 
     const socket = new GameSocket(params);
@@ -44,18 +72,36 @@ export function SocketProvider({ children }: { children: ReactNode }) {
 
     socket.on("_connected", () => setConnected(true));
     socket.on("_disconnected", () => setConnected(false));
+    socket.on("session_replaced", () => setReplaced(true));
     socket.connect();
 
     return () => {
       socket.disconnect();
       socketRef.current = null;
+      session.stop();
     };
   }, [userId, initDataRaw]);
 
+  const reconnect = useCallback(() => {
+    setReplaced(false);
+    socketRef.current?.reconnect();
+  }, []);
+
   const send = useCallback((type: string, payload?: unknown) => {
     socketRef.current?.send(type, payload);
   }, []);
 
+  const request = useCallback(
+    (type: string, payload?: unknown) =>
+      socketRef.current?.request(type, payload) ??
+      Promise.reject<unknown>({
+        type,
+        code: "disconnected",
+        message: "Not connected",
+      } satisfies CommandError),
+    [],
+  );
+
   const on = useCallback(
     (type: string, listener: (payload: unknown) => void) => {
       return socketRef.current?.on(type, listener) ?? (() => {});
@@ -63,8 +109,25 @@ export function SocketProvider({ children }: { children: ReactNode }) {
     [],
   );
 
+  const lastSeq = useCallback(() => socketRef.current?.lastSeq ?? 0, []);
+  const serverNow = useCallback(
+    () => socketRef.current?.serverNow() ?? Date.now(),
+    [],
+  );
+
   return (
-    <SocketContext.Provider value={{ connected, send, on }}>
+    <SocketContext.Provider
+      value={{
+        connected,
+        replaced,
+        reconnect,
+        send,
+        request,
+        on,
+        lastSeq,
+        serverNow,
+      }}
+    >
       {children}
     </SocketContext.Provider>
   );
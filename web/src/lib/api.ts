@@ -1,4 +1,5 @@
 import type { PlayerProfile, LeaderboardEntry } from "@/types/game";
+import { session } from "@/lib/session";
 
 export class NotFoundError extends Error {
   constructor(url: string) {
@@ -7,8 +8,17 @@ export class NotFoundError extends Error {
   }
 }
 
+// Authenticate as the current Telegram user; the server hides private fields otherwise.
+// The session token outlives initData, which is only accepted for 5 minutes.
+async function authHeaders(): Promise<HeadersInit> {
+  const token = await session.token();
+  if (token) return { Authorization: `Bearer ${token}` };
+  const initData = window.Telegram?.WebApp?.initData;
+  return initData ? { Authorization: `tma ${initData}` } : {};
+}
+
 async function fetchJSON<T>(url: string): Promise<T> {
-  const res = await fetch(url);
+  const res = await fetch(url, { headers: await authHeaders() });
   if (res.status === 404) throw new NotFoundError(url);
   if (!res.ok) throw new Error(`${res.status} ${res.statusText}`);
   return res.json() as Promise<T>;
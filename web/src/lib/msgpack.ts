@@ -0,0 +1,204 @@
+// Minimal MessagePack for the WS protocol: nil, bools, numbers, strings,
+// arrays and string-keyed maps. Mirrors internal/msgpack on the server.
+
+export function encode(value: unknown): Uint8Array {
+  const out: number[] = [];
+  write(out, value);
+  return new Uint8Array(out);
+}
+
+const textEncoder = new TextEncoder();
+const textDecoder = new TextDecoder();
+
+function write(out: number[], v: unknown) {
+  if (v === null || v === undefined) {
+    out.push(0xc0);
+  } else if (typeof v === "boolean") {
+    out.push(v ? 0xc3 : 0xc2);
+  } else if (typeof v === "number") {
+    writeNumber(out, v);
+  } else if (typeof v === "string") {
+    const bytes = textEncoder.encode(v);
+    const n = bytes.length;
+    if (n < 32) out.push(0xa0 | n);
+    else if (n < 0x100) out.push(0xd9, n);
+    else if (n < 0x10000) out.push(0xda, n >> 8, n & 0xff);
+    else out.push(0xdb, ...u32(n));
+    for (const b of bytes) out.push(b);
+  } else if (Array.isArray(v)) {
+    header(out, v.length, 0x90, 0xdc, 0xdd);
+    v.forEach((e) => write(out, e));
+  } else if (typeof v === "object") {
+    const entries = Object.entries(v as Record<string, unknown>).filter(
+      ([, e]) => e !== undefined,
+    );
+    header(out, entries.length, 0x80, 0xde, 0xdf);
+    for (const [k, e] of entries) {
+      write(out, k);
+      write(out, e);
+    }
+  } else {
+    throw new Error(`msgpack: unsupported type ${typeof v}`);
+  }
+}
+
+function header(
+  out: number[],
+  n: number,
+  fix: number,
+  c16: number,
+  c32: number,
+) {
+  if (n < 16) out.push(fix | n);
+  else if (n < 0x10000) out.push(c16, n >> 8, n & 0xff);
+  else out.push(c32, ...u32(n));
+}
+
+function u32(n: number): number[] {
+  return [(n >>> 24) & 0xff, (n >>> 16) & 0xff, (n >>> 8) & 0xff, n & 0xff];
+}
+
+function writeNumber(out: number[], n: number) {
+  if (Number.isInteger(n) && n >= 0 && n < 0x80) {
+    out.push(n);
+  } else if (Number.isInteger(n) && n < 0 && n >= -32) {
+    out.push(n & 0xff);
+  } else if (Number.isSafeInteger(n)) {
+    const view = new DataView(new ArrayBuffer(8));
+    view.setBigInt64(0, BigInt(n));
+    out.push(0xd3, ...new Uint8Array(view.buffer));
+  } else {
+    const view = new DataView(new ArrayBuffer(8));
+    view.setFloat64(0, n);
+    out.push(0xcb, ...new Uint8Array(view.buffer));
+  }
+}
+
+export function decode(buf: ArrayBuffer): unknown {
+  const r = new Reader(new DataView(buf));
+  const v = r.value();
+  if (r.pos !== buf.byteLength) throw new Error("msgpack: trailing data");
+  return v;
+}
+
+class Reader {
+  pos = 0;
+  constructor(private view: DataView) {}
+
+  private u8() {
+    return this.view.getUint8(this.pos++);
+  }
+
+  private uint(width: number): number {
+    const v = this.view;
+    const p = this.pos;
+    this.pos += width;
+    switch (width) {
+      case 1:
+        return v.getUint8(p);
+      case 2:
+        return v.getUint16(p);
+      case 4:
+        return v.getUint32(p);
+      default:
+        return Number(v.getBigUint64(p));
+    }
+  }
+
+  private int(width: number): number {
+    const v = this.view;
+    const p = this.pos;
+    this.pos += width;
+    switch (width) {
+      case 1:
+        return v.getInt8(p);
+      case 2:
+        return v.getInt16(p);
+      case 4:
+        return v.getInt32(p);
+      default:
+        return Number(v.getBigInt64(p));
+    }
+  }
+
+  private str(n: number): string {
+    const bytes = new Uint8Array(
+      this.view.buffer,
+      this.view.byteOffset + this.pos,
+      n,
+    );
+    this.pos += n;
+    return textDecoder.decode(bytes);
+  }
+
+  private array(n: number): unknown[] {
+    const out = new Array(n);
+    for (let i = 0; i < n; i++) out[i] = this.value();
+    return out;
+  }
+
+  private map(n: number): Record<string, unknown> {
+    const out: Record<string, unknown> = {};
+    for (let i = 0; i < n; i++) {
+      const k = this.value();
+      if (typeof k !== "string") throw new Error("msgpack: non-string key");
+      out[k] = this.value();
+    }
+    return out;
+  }
+
+  value(): unknown {
+    const c = this.u8();
+    if (c <= 0x7f) return c;
+    if (c >= 0xe0) return c - 0x100;
+    if ((c & 0xe0) === 0xa0) return this.str(c & 0x1f);
+    if ((c & 0xf0) === 0x90) return this.array(c & 0x0f);
+    if ((c & 0xf0) === 0x80) return this.map(c & 0x0f);
+    switch (c) {
+      case 0xc0:
+        return null;
+      case 0xc2:
+        return false;
+      case 0xc3:
+        return true;
+      case 0xcc:
+      case 0xcd:
+      case 0xce:
+      case 0xcf:
+        return this.uint(1 << (c - 0xcc));
+      case 0xd0:
+      case 0xd1:
+      case 0xd2:
+      case 0xd3:
+        return this.int(1 << (c - 0xd0));
+      case 0xca: {
+        const f = this.view.getFloat32(this.pos);
+        this.pos += 4;
+        return f;
+      }
+      case 0xcb: {
+        const f = this.view.getFloat64(this.pos);
+        this.pos += 8;
+        return f;
+      }
+      case 0xd9:
+      case 0xc4:
+        return this.str(this.uint(1));
+      case 0xda:
+      case 0xc5:
+        return this.str(this.uint(2));
+      case 0xdb:
+      case 0xc6:
+        return this.str(this.uint(4));
+      case 0xdc:
+        return this.array(this.uint(2));
+      case 0xdd:
+        return this.array(this.uint(4));
+      case 0xde:
+        return this.map(this.uint(2));
+      case 0xdf:
+        return this.map(this.uint(4));
+    }
+    throw new Error(`msgpack: unsupported type 0x${c.toString(16)}`);
+  }
+}
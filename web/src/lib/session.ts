@@ -0,0 +1,114 @@
+/** Tokens from POST /api/auth/session and /api/auth/refresh. */
+interface SessionTokens {
+  access_token: string;
+  access_expires_at: string;
+  refresh_token: string;
+  refresh_expires_at: string;
+}
+
+/** Refresh this long before the access token expires. */
+const REFRESH_MARGIN_MS = 60_000;
+
+/**
+ * Exchanges Telegram initData for a session once, then keeps the access
+ * token fresh. initData is only accepted for 5 minutes, so reconnects and API
+ * calls after that must use the token instead.
+ */
+class SessionClient {
+  private initData = "";
+  private tokens: SessionTokens | null = null;
+  private inflight: Promise<string | null> | null = null;
+  private timer: ReturnType<typeof setTimeout> | null = null;
+
+  start(initData: string) {
+    if (initData === this.initData) return;
+    this.stop();
+    this.initData = initData;
+  }
+
+  stop() {
+    if (this.timer) clearTimeout(this.timer);
+    this.timer = null;
+    this.tokens = null;
+    this.inflight = null;
+    this.initData = "";
+  }
+
+  /**
+   * A valid access token, creating or refreshing the session as needed.
+   * Resolves null when no session can be had, e.g. the server has sessions
+   * disabled; callers then fall back to initData.
+   */
+  token(): Promise<string | null> {
+    if (
+      this.tokens &&
+      expiresIn(this.tokens.access_expires_at) > REFRESH_MARGIN_MS
+    ) {
+      return Promise.resolve(this.tokens.access_token);
+    }
+    if (!this.inflight) {
+      this.inflight = this.renew().finally(() => {
+        this.inflight = null;
+      });
+    }
+    return this.inflight;
+  }
+
+  private async renew(): Promise<string | null> {
+    const initData = this.initData;
+    let tokens: SessionTokens | null = null;
+    if (this.tokens && expiresIn(this.tokens.refresh_expires_at) > 0) {
+      tokens = await post("/api/auth/refresh", {
+        body: JSON.stringify({ refresh_token: this.tokens.refresh_token }),
+      });
+    }
+    if (!tokens && initData) {
+      tokens = await post("/api/auth/session", {
+        headers: { Authorization: `tma ${initData}` },
+      });
+    }
+    // Stopped or restarted for another user while the request was out.
+    if (initData !== this.initData) return null;
+    this.tokens = tokens;
+    this.schedule();
+    return tokens?.access_token ?? null;
+  }
+
+  // Refreshes ahead of expiry so a reconnect never waits on it.
+  private schedule() {
+    if (this.timer) clearTimeout(this.timer);
+    this.timer = null;
+    if (!this.tokens) return;
+    const delay = expiresIn(this.tokens.access_expires_at) - REFRESH_MARGIN_MS;
+    this.timer = setTimeout(
+      () => {
+        this.timer = null;
+        void this.token();
+      },
+      Math.max(delay, 0),
+    );
+  }
+}
+
+function expiresIn(iso: string) {
+  return Date.parse(iso) - Date.now();
+}
+
+async function post(
+  url: string,
+  init: { body?: string; headers?: Record<string, string> },
+): Promise<SessionTokens | null> {
+  try {
+    const res = await fetch(url, {
+      method: "POST",
+      body: init.body,
+      headers: { "Content-Type": "application/json", ...init.headers },
+    });
+    if (!res.ok) return null;
+    return (await res.json()) as SessionTokens;
+  } catch {
+    return null;
+  }
+}
+
+export const session = new SessionClient();
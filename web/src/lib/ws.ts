@@ -1,40 +1,108 @@
+import { decode, encode } from "@/lib/msgpack";
+
+/** Offered in preference order; the server picks the first it supports. */
+const SUBPROTOCOLS = ["lastclick.v2.msgpack", "lastclick.v2.json"];
+
 export interface WSEnvelope {
   type: string;
+  id?: string;
+  seq?: number;
   payload?: unknown;
 }
 
 type Listener = (payload: unknown) => void;
 
+/** Payload of an "error" reply; code is stable, message is for display. */
+export interface CommandError {
+  type: string;
+  code: string;
+  message: string;
+}
+
+interface Pending {
+  resolve: (result: unknown) => void;
+  reject: (err: CommandError) => void;
+  timer: ReturnType<typeof setTimeout>;
+}
+
+const REQUEST_TIMEOUT_MS = 10_000;
+
+interface TimeSyncReply {
+  client_ms: number;
+  server_recv_ms: number;
+  server_send_ms: number;
+}
+
+/** time_sync samples per burst; the lowest-RTT one sets the offset. */
+const TIME_SYNC_SAMPLES = 5;
+const TIME_SYNC_INTERVAL_MS = 60_000;
+
 export class GameSocket {
   private ws: WebSocket | null = null;
   private listeners = new Map<string, Set<Listener>>();
   private reconnectDelay = 1000;
   private maxReconnectDelay = 16000;
   private shouldReconnect = true;
-  private connectParams: string;
+  private opening = false;
+  // Resolved on every (re)connect, so an expired credential is never reused.
+  private connectParams: () => Promise<string>;
+  // Seq of the most recent room broadcast; sent back in "resume" after a drop.
+  private seq = 0;
+  // Server clock minus local clock, from the best time_sync sample so far.
+  private offset = 0;
+  private bestRtt = Infinity;
+  private syncRemaining = 0;
+  private syncTimer: ReturnType<typeof setInterval> | null = null;
+  private nextId = 1;
+  private pending = new Map<string, Pending>();
+  // Last full tick, so v2 tick_delta frames can be expanded before emitting.
+  private lastTick: Record<string, unknown> | null = null;
 
-  constructor(connectParams: string) {
+  constructor(connectParams: () => Promise<string>) {
     this.connectParams = connectParams;
   }
 
   connect() {
-    if (this.ws?.readyState === WebSocket.OPEN) return;
+    if (this.ws?.readyState === WebSocket.OPEN || this.opening) return;
+    this.opening = true;
+    void this.connectParams().then((params) => {
+      this.opening = false;
+      if (this.shouldReconnect) this.open(params);
+    });
+  }
 
+  private open(params: string) {
     const proto = window.location.protocol === "https:" ? "wss:" : "ws:";
     const wsUrl =
       import.meta.env.VITE_WS_URL || `${proto}//${window.location.host}`;
-    const url = `${wsUrl}/ws?${this.connectParams}`;
+    const url = `${wsUrl}/ws?${params}`;
 
-    this.ws = new WebSocket(url);
+    this.ws = new WebSocket(url, SUBPROTOCOLS);
+    this.ws.binaryType = "arraybuffer";
 
     this.ws.onopen = () => {
       this.reconnectDelay = 1000;
+      this.startTimeSync();
       this.emit("_connected", null);
     };
 
     this.ws.onmessage = (event) => {
       try {
-        const msg: WSEnvelope = JSON.parse(event.data);
+        const msg = (
+          typeof event.data === "string"
+            ? JSON.parse(event.data)
+            : decode(event.data as ArrayBuffer)
+        ) as WSEnvelope;
+        if (msg.seq) this.seq = msg.seq;
+        this.expandTick(msg);
+        if (msg.type === "time_sync") {
+          this.onTimeSync(msg.payload as TimeSyncReply, Date.now());
+          return;
+        }
+        // Another tab or device took over this player's session; reconnecting
+        // would just kick that one in turn.
+        if (msg.type === "session_replaced") this.shouldReconnect = false;
+        if (msg.id) this.settle(msg);
         this.emit(msg.type, msg.payload);
       } catch {
         /* ignore malformed messages */
@@ -42,6 +110,8 @@ export class GameSocket {
     };
 
     this.ws.onclose = () => {
+      this.stopTimeSync();
+      this.failPending("disconnected");
       this.emit("_disconnected", null);
       if (this.shouldReconnect) {
         setTimeout(() => this.connect(), this.reconnectDelay);
@@ -57,19 +127,53 @@ export class GameSocket {
     };
   }
 
+  /** Reconnects after a session_replaced, taking the session back. */
+  reconnect() {
+    this.shouldReconnect = true;
+    this.connect();
+  }
+
   disconnect() {
     this.shouldReconnect = false;
+    this.stopTimeSync();
     this.ws?.close();
     this.ws = null;
   }
 
-  send(type: string, payload?: unknown) {
-    if (this.ws?.readyState !== WebSocket.OPEN) return;
+  send(type: string, payload?: unknown, id?: string) {
+    if (this.ws?.readyState !== WebSocket.OPEN) return false;
     const msg: WSEnvelope = { type };
+    if (id !== undefined) {
+      msg.id = id;
+    }
     if (payload !== undefined) {
       msg.payload = payload;
     }
-    this.ws.send(JSON.stringify(msg));
+    if (this.ws.protocol.endsWith(".msgpack")) {
+      this.ws.send(encode(msg));
+    } else {
+      this.ws.send(JSON.stringify(msg));
+    }
+    return true;
+  }
+
+  /**
+   * Sends a command with a request id and resolves with the ack's result, or
+   * rejects with the server's error code.
+   */
+  request(type: string, payload?: unknown): Promise<unknown> {
+    const id = String(this.nextId++);
+    return new Promise((resolve, reject) => {
+      if (!this.send(type, payload, id)) {
+        reject({ type, code: "disconnected", message: "Not connected" });
+        return;
+      }
+      const timer = setTimeout(() => {
+        this.pending.delete(id);
+        reject({ type, code: "timeout", message: "No response from server" });
+      }, REQUEST_TIMEOUT_MS);
+      this.pending.set(id, { resolve, reject, timer });
+    });
   }
 
   on(type: string, listener: Listener): () => void {
@@ -80,11 +184,125 @@ export class GameSocket {
     return () => this.listeners.get(type)?.delete(listener);
   }
 
+  /** Current time on the server clock, in unix ms. */
+  serverNow() {
+    return Date.now() + this.offset;
+  }
+
+  get lastSeq() {
+    return this.seq;
+  }
+
   get connected() {
     return this.ws?.readyState === WebSocket.OPEN;
   }
 
+  // Each burst sends samples one at a time; every request echoes when the
+  // previous reply arrived so the server can keep its own estimate too.
+  private startTimeSync() {
+    this.stopTimeSync();
+    const burst = () => {
+      this.syncRemaining = TIME_SYNC_SAMPLES;
+      this.bestRtt = Infinity;
+      this.send("time_sync", { client_ms: Date.now() });
+    };
+    burst();
+    this.syncTimer = setInterval(burst, TIME_SYNC_INTERVAL_MS);
+  }
+
+  private stopTimeSync() {
+    if (this.syncTimer) clearInterval(this.syncTimer);
+    this.syncTimer = null;
+    this.syncRemaining = 0;
+  }
+
+  private onTimeSync(reply: TimeSyncReply, recvMs: number) {
+    const rtt =
+      recvMs - reply.client_ms - (reply.server_send_ms - reply.server_recv_ms);
+    if (rtt >= 0 && rtt <= this.bestRtt) {
+      this.bestRtt = rtt;
+      this.offset = Math.round(
+        (reply.server_recv_ms -
+          reply.client_ms +
+          (reply.server_send_ms - recvMs)) /
+          2,
+      );
+    }
+    this.syncRemaining--;
+    // Past the burst, one last request just delivers the ack.
+    if (this.syncRemaining >= 0) {
+      this.send("time_sync", {
+        client_ms: Date.now(),
+        ack_client_ms: reply.client_ms,
+        ack_recv_ms: recvMs,
+      });
+    }
+  }
+
+  // Turns tick_delta into a full tick: fields are merged over the last tick,
+  // and per-player lists are merged by player_id. "removed" names the
+  // players (or whole fields, as null) that left since. room_state starts over.
+  private expandTick(msg: WSEnvelope) {
+    if (msg.type === "room_state") {
+      this.lastTick = null;
+    } else if (msg.type === "tick") {
+      this.lastTick = msg.payload as Record<string, unknown>;
+    } else if (msg.type === "tick_delta" && this.lastTick) {
+      const { removed, ...delta } = msg.payload as Record<string, unknown>;
+      const merged: Record<string, unknown> = { ...this.lastTick };
+      for (const [k, v] of Object.entries(delta)) {
+        const prev = merged[k];
+        merged[k] =
+          Array.isArray(v) && Array.isArray(prev) ? mergeByPlayer(prev, v) : v;
+      }
+      for (const [k, ids] of Object.entries(
+        (removed ?? {}) as Record<string, number[] | null>,
+      )) {
+        const prev = merged[k];
+        if (ids === null) {
+          delete merged[k];
+        } else if (Array.isArray(prev)) {
+          merged[k] = prev.filter(
+            (e) => !ids.includes((e as PlayerEntry).player_id),
+          );
+        }
+      }
+      this.lastTick = merged;
+      msg.type = "tick";
+      msg.payload = merged;
+    }
+  }
+
+  private settle(msg: WSEnvelope) {
+    const p = this.pending.get(msg.id!);
+    if (!p) return;
+    this.pending.delete(msg.id!);
+    clearTimeout(p.timer);
+    if (msg.type === "error") {
+      p.reject(msg.payload as CommandError);
+    } else {
+      p.resolve((msg.payload as { result?: unknown } | undefined)?.result);
+    }
+  }
+
+  private failPending(code: string) {
+    this.pending.forEach((p) => {
+      clearTimeout(p.timer);
+      p.reject({ type: "", code, message: "Connection lost" });
+    });
+    this.pending.clear();
+  }
+
   private emit(type: string, payload: unknown) {
     this.listeners.get(type)?.forEach((fn) => fn(payload));
   }
 }
+
+type PlayerEntry = { player_id: number } & Record<string, unknown>;
+
+function mergeByPlayer(prev: unknown[], changed: unknown[]): unknown[] {
+  const byId = new Map<number, unknown>();
+  for (const e of prev) byId.set((e as PlayerEntry).player_id, e);
+  for (const e of changed) byId.set((e as PlayerEntry).player_id, e);
+  return [...byId.values()];
+}
@@ -1,12 +1,13 @@
 import { Link } from "react-router-dom";
 import { lazy, Suspense, useEffect, useState } from "react";
 import { SurvivalPhase } from "@/components/game/SurvivalPhase";
-import { TIERS } from "@/types/game";
+import { TIERS, TIE_POLICY_LABELS } from "@/types/game";
 import { TutorialTooltips } from "@/components/game/TutorialTooltips";
 import { CountdownOverlay } from "@/components/game/CountdownOverlay";
 import { LeaderboardPanel } from "@/components/game/LeaderboardPanel";
 import { WhalePositionCard } from "@/components/game/WhalePositionCard";
 import { SquadInfo } from "@/components/game/SquadInfo";
+import { FeeInfo } from "@/components/game/FeeInfo";
 import { GameHeader } from "@/components/game/GameHeader";
 import { SimulationPanel } from "@/components/game/SimulationPanel";
 import { useGame } from "@/context/GameContext";
@@ -214,9 +215,16 @@ export default function Game() {
                         Place
                       </p>
                     )}
-                    {payoutInfo && (
+                    {(state.roundResult?.stars ?? payoutInfo?.amount ?? 0) >
+                      0 && (
                       <p className="text-2xl font-bold text-primary font-mono">
-                        +{payoutInfo.amount} &#9733;
+                        +{state.roundResult?.stars ?? payoutInfo?.amount} &#9733;
+                      </p>
+                    )}
+                    {state.roundResult?.tie_policy && (
+                      <p className="text-xs text-muted-foreground">
+                        {state.roundResult.co_survivors} survivors tied —{" "}
+                        {TIE_POLICY_LABELS[state.roundResult.tie_policy]}
                       </p>
                     )}
                     {(state.roundResult?.shards ?? engine?.shardCredit ?? 0) >
@@ -255,6 +263,7 @@ export default function Game() {
 
           <div className="space-y-4 sm:space-y-6 order-2 lg:order-1">
             <SquadInfo />
+            <FeeInfo />
             <LeaderboardPanel />
           </div>
         </div>
@@ -45,6 +45,11 @@ export default function Rooms() {
               New? Play the demo first — 60s, 0 entry, learn by doing.
             </p>
           )}
+          {state.lastError?.type === "join_room" && (
+            <p className="mt-3 text-sm text-destructive font-medium">
+              {state.lastError.message}
+            </p>
+          )}
         </div>
 
         {waitingRooms.length > 0 && (
@@ -23,6 +23,44 @@ export interface RoomStatePayload {
   margin_ratio: number;
   volatility_mul: number;
   winner_id: number;
+  /** Present during survival. */
+  server_time_ms?: number;
+  pulse_deadlines?: PulseDeadline[];
+  /** The money rules this round was opened with. */
+  economy?: EconomyPolicy;
+}
+
+/** How players still alive when a round ends are ranked. */
+export type TiePolicy = "hash" | "efficiency" | "split";
+
+export const TIE_POLICY_LABELS: Record<TiePolicy, string> = {
+  hash: "Random draw",
+  efficiency: "Fewest pulses",
+  split: "Split pot",
+};
+
+/** Mirrors room.EconomyPolicy; percentages are whole percent. */
+export interface EconomyPolicy {
+  id: string;
+  version: number;
+  rake_pct: number;
+  war_chest_pct: number;
+  payouts: { min_players: number; shares_pct: number[] }[];
+  shard_ratio_min: number;
+  shard_ratio_max: number;
+  shard_bonuses: { place: number; pct: number }[] | null;
+  ties?: TiePolicy;
+  /** Overrides keyed by room type ("blitz") or tier ("tier2"). */
+  ties_by?: Record<string, TiePolicy>;
+  /** Who gets Stars left over by integer division; unset means house. */
+  dust_to?: "house" | "winner" | "war_chest";
+  pulse_cost: number;
+}
+
+/** When a player is eliminated unless they pulse, on the server clock. */
+export interface PulseDeadline {
+  player_id: number;
+  pulse_deadline_server_ms: number;
 }
 
 export interface TickPayload {
@@ -30,6 +68,8 @@ export interface TickPayload {
   margin_ratio: number;
   volatility_mul: number;
   alive: number;
+  server_time_ms?: number;
+  pulse_deadlines?: PulseDeadline[];
 }
 
 export interface EliminationPayload {
@@ -43,6 +83,7 @@ export interface PulseAckPayload {
   timer_ms: number;
   /** Server time when pulse was recorded (for cooldown / display). */
   server_time_ms?: number;
+  pulse_deadline_server_ms?: number;
 }
 
 // ===== Player (from REST /api/player/{id}) =====